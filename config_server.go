@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -10,18 +11,47 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// PEM file of CA trusted by callback besides system roots. for servers with self signed certificate.
+const CALLBACK_CA_FILE_ENV = "WITNESS_CALLBACK_CA_FILE"
+
+func callbackTLSConfig() *tls.Config {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	caFile := os.Getenv(CALLBACK_CA_FILE_ENV)
+	if len(caFile) == 0 {
+		return tlsConfig
+	}
+
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		fmt.Printf("read %s err %s. use system roots\n", caFile, err)
+		return tlsConfig
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		fmt.Printf("no certificate in %s. use system roots\n", caFile)
+		return tlsConfig
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig
+}
+
 func NewClient() *http.Client {
-	tr := &http.Transport{ //x509: certificate signed by unknown authority
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	tr := &http.Transport{
+		TLSClientConfig: callbackTLSConfig(),
 	}
 	client := &http.Client{
 		Timeout:   15 * time.Second,
-		Transport: tr, //x509: certificate signed by unknown authority
+		Transport: tr,
 	}
 	return client
 }
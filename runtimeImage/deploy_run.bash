@@ -20,7 +20,7 @@ echo "install ontio-wasm-build done."
 
 [[ -d $witnessdir ]] || git clone https://github.com/carltraveler/witness
 echo "install witness repo done."
-cd $witnessdir/runtimeImage/witness_server; go build witness_server.go rpc.go; cp witness_server $prefixworkdir; cd -
+cd $witnessdir/runtimeImage/witness_server; go build -o witness_server .; cp witness_server $prefixworkdir; cd -
 echo "build witness_server done."
 cd $witnessdir/runtimeImage; go build confighandle.go
 echo "build witness confighandle done."
@@ -5,7 +5,7 @@ mkdir -p $preparedir
 cd runtimeconfig/
 go build confighandle.go aksk.go req.go
 cd ..
-cd witness_server; go build witness_server.go rpc.go; mv witness_server witness_server_daemon;cd -
+cd witness_server; go build -o witness_server .; mv witness_server witness_server_daemon;cd -
 
 cp runtimeconfig/confighandle $preparedir
 cp witness_server/witness_server_daemon $preparedir
@@ -28,6 +28,8 @@ const (
 
 const (
 	walletname = "wallet.dat"
+	// admin listener of /healthz, /status and /metrics if not set in fixed config.
+	defaultAdminAddr = ":8081"
 )
 
 const (
@@ -60,19 +62,51 @@ func GetStateFromBytes(data []byte) (uint32, string, error) {
 }
 
 type ServerConfig struct {
-	Walletname        string   `json:"walletname"`
-	OntNode           string   `json:"ontnode"`
-	SignerAddress     string   `json:"signeraddress"`
-	ServerPort        int      `json:"serverport"`
-	GasPrice          uint64   `json:"gasprice"`
-	CacheTime         uint32   `json:"cachetime"`
-	BatchNum          uint32   `json:"batchnum"`
-	TryChainInterval  uint32   `json:"trychaininterval"`
-	SendTxInterval    uint32   `json:"sendtxinterval"`
-	SendTxSize        uint32   `json:"sendtxsize"`
-	BatchAddSleepTime uint32   `json:"batchaddsleeptime"`
-	ContracthexAddr   string   `json:"contracthexaddr"`
-	Authorize         []string `json:"authorize"`
+	Walletname         string            `json:"walletname"`
+	OntNode            string            `json:"ontnode"`
+	SignerAddress      string            `json:"signeraddress"`
+	ServerPort         int               `json:"serverport"`
+	GasPrice           uint64            `json:"gasprice"`
+	CacheTime          uint32            `json:"cachetime"`
+	BatchNum           uint32            `json:"batchnum"`
+	TryChainInterval   uint32            `json:"trychaininterval"`
+	SendTxInterval     uint32            `json:"sendtxinterval"`
+	SendTxSize         uint32            `json:"sendtxsize"`
+	ContracthexAddr    string            `json:"contracthexaddr"`
+	Authorize          []string          `json:"authorize"`
+	LegacyResponse     bool              `json:"legacyresponse"`
+	MaxBatchLength     uint32            `json:"maxbatchlength"`
+	MaxBatchBodySize   uint32            `json:"maxbatchbodysize"`
+	MaxVerifyNum       uint32            `json:"maxverifynum"`
+	GrpcPort           int               `json:"grpcport"`
+	RequestSkew        uint32            `json:"requestskew"`
+	TenantId           string            `json:"tenantid"`
+	LegacyBatchAddSig  bool              `json:"legacybatchaddsig"`
+	AdminAuthorize     []string          `json:"adminauthorize"`
+	SubmitterAuthorize []string          `json:"submitterauthorize"`
+	VerifierAuthorize  []string          `json:"verifierauthorize"`
+	AuditorAuthorize   []string          `json:"auditorauthorize"`
+	TlsCertFile        string            `json:"tlscertfile"`
+	TlsKeyFile         string            `json:"tlskeyfile"`
+	TlsClientCAFile    string            `json:"tlsclientcafile"`
+	TlsClientAuth      string            `json:"tlsclientauth"`
+	TlsClientIdentity  map[string]string `json:"tlsclientidentity"`
+	CorsAllowOrigins   []string          `json:"corsalloworigins"`
+	CorsAllowMethods   []string          `json:"corsallowmethods"`
+	CorsAllowHeaders   []string          `json:"corsallowheaders"`
+	ReadHeaderTimeout  uint32            `json:"readheadertimeout"`
+	ReadTimeout        uint32            `json:"readtimeout"`
+	WriteTimeout       uint32            `json:"writetimeout"`
+	IdleTimeout        uint32            `json:"idletimeout"`
+	MaxHeaderBytes     int               `json:"maxheaderbytes"`
+	MaxConnections     int               `json:"maxconnections"`
+	MaxRequestBodySize uint32            `json:"maxrequestbodysize"`
+	MaxMergeDelay      uint32            `json:"maxmergedelay"`
+	IdempotentBatchAdd bool              `json:"idempotentbatchadd"`
+	IdempotencyKeyTtl  uint32            `json:"idempotencykeyttl"`
+	AdminAddr          string            `json:"adminaddr"`
+	PublicStatus       bool              `json:"publicstatus"`
+	PublicMetrics      bool              `json:"publicmetrics"`
 }
 
 type WitnessConfig struct {
@@ -151,6 +185,10 @@ func NewConfigServer(levelDBName string, fixedConfigPath string, witnessConfigPa
 		return nil, fmt.Errorf("NewConfigServer: %s", err)
 	}
 
+	if len(fixedConfig.AdminAddr) == 0 {
+		fixedConfig.AdminAddr = defaultAdminAddr
+	}
+
 	var ismainnet bool
 
 	if witnessConfig.NetType == "testnet" {
@@ -175,6 +213,7 @@ func NewConfigServer(levelDBName string, fixedConfigPath string, witnessConfigPa
 	configServer.OntSdk = ontSdk
 	configServer.ServerConfig = &fixedConfig
 	configServer.ServerConfig.ContracthexAddr = witnessConfig.ContractAddress
+	configServer.ServerConfig.TenantId = witnessConfig.TenantId
 	if hexAddress != "" && witnessConfig.ContractAddress != hexAddress {
 		return nil, fmt.Errorf("contract address already init to %s. not %s", hexAddress, witnessConfig.ContractAddress)
 	}
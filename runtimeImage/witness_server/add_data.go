@@ -0,0 +1,344 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// HashAlgorithm the server hashed the leaf from data with. stored for leafs of addData.
+type HashAlgorithm byte
+
+const (
+	// leaf hashed by client. batchAdd.
+	HASH_CLIENT HashAlgorithm = 0
+	// rfc 6962 leaf hash. sha256 of 0x00 and data. same as the sdk.
+	HASH_SHA256      HashAlgorithm = 1
+	HASH_SHA3_256    HashAlgorithm = 2
+	HASH_BLAKE2B_256 HashAlgorithm = 3
+)
+
+var hashAlgorithmNames = map[HashAlgorithm]string{
+	HASH_CLIENT:      "client",
+	HASH_SHA256:      "sha256",
+	HASH_SHA3_256:    "sha3-256",
+	HASH_BLAKE2B_256: "blake2b-256",
+}
+
+// parseHashAlgorithm sha256 if empty. client is not algorithm of data.
+func parseHashAlgorithm(name string) (HashAlgorithm, error) {
+	if len(name) == 0 {
+		return HASH_SHA256, nil
+	}
+
+	for algorithm, n := range hashAlgorithmNames {
+		if n == name && algorithm != HASH_CLIENT {
+			return algorithm, nil
+		}
+	}
+
+	return HASH_CLIENT, fmt.Errorf("unknown hash algorithm %s. should be sha256, sha3-256 or blake2b-256", name)
+}
+
+func (self HashAlgorithm) String() string {
+	return hashAlgorithmNames[self]
+}
+
+func (self HashAlgorithm) Sum(data []byte) common.Uint256 {
+	switch self {
+	case HASH_SHA3_256:
+		return sha3.Sum256(data)
+	case HASH_BLAKE2B_256:
+		return blake2b.Sum256(data)
+	}
+
+	return hashLeaf(data)
+}
+
+func putLeafHashAlgorithm(store *leveldbstore.LevelDBStore, leaf common.Uint256, algorithm HashAlgorithm) {
+	store.BatchPut(GetKeyByHash(PREFIX_LEAF_HASH_ALGORITHM, leaf), []byte{byte(algorithm)})
+}
+
+// getLeafHashAlgorithm HASH_CLIENT if not hashed by server.
+func getLeafHashAlgorithm(store *leveldbstore.LevelDBStore, leaf common.Uint256) HashAlgorithm {
+	val, err := store.Get(GetKeyByHash(PREFIX_LEAF_HASH_ALGORITHM, leaf))
+	if err != nil || len(val) != 1 {
+		return HASH_CLIENT
+	}
+	return HashAlgorithm(val[0])
+}
+
+// DataParam signed request of addData and verifyData. Data are base64 payloads. or files of multipart upload.
+type DataParam struct {
+	PubKey         string   `json:"pubKey"`
+	Signature      string   `json:"signature"`
+	Timestamp      int64    `json:"timestamp"`
+	Nonce          string   `json:"nonce"`
+	Algorithm      string   `json:"algorithm,omitempty"`
+	Data           []string `json:"data"`
+	Root           string   `json:"root,omitempty"`
+	TreeSize       uint32   `json:"treeSize,omitempty"`
+	IdempotencyKey string   `json:"idempotencyKey,omitempty"`
+	// none or one of each data. addData only.
+	Metadata []*LeafMetadataParam `json:"metadata,omitempty"`
+
+	payloads [][]byte
+}
+
+func (self *DataParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *DataParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *DataParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *DataParam) GetNonce() string {
+	return self.Nonce
+}
+
+// CheckReplay nonce persisted as batchAdd. retry of recorded idempotency key answered by the recorded response.
+func (self *DataParam) CheckReplay(address common.Address) error {
+	if len(self.IdempotencyKey) != 0 && hasIdempotencyRecord(DefStore, address, self.IdempotencyKey) {
+		return nil
+	}
+
+	err := checkRequestTime(self.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	return checkBatchAddNonce(DefStore, address, self.Nonce, self.Timestamp)
+}
+
+// decode the base64 data once. payloads of multipart upload set directly.
+func (self *DataParam) decode() ([][]byte, error) {
+	if self.payloads != nil {
+		return self.payloads, nil
+	}
+
+	payloads := make([][]byte, 0, len(self.Data))
+	for i, d := range self.Data {
+		payload, err := base64.StdEncoding.DecodeString(d)
+		if err != nil {
+			return nil, fmt.Errorf("data %d not base64: %s", i, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	self.payloads = payloads
+
+	return payloads, nil
+}
+
+// canonical of algorithm, sha256 of each payload, root, treeSize, idempotency key and digest of metadata if set. so multipart signed the same as base64.
+func (self *DataParam) SignData() ([]byte, error) {
+	payloads, err := self.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.Algorithm)
+	sink.WriteVarUint(uint64(len(payloads)))
+	for _, payload := range payloads {
+		digest := sha256.Sum256(payload)
+		sink.WriteBytes(digest[:])
+	}
+	sink.WriteString(self.Root)
+	sink.WriteUint32(self.TreeSize)
+	sink.WriteString(self.IdempotencyKey)
+	if len(self.Metadata) != 0 {
+		metadataDigest := leafMetadataDigest(self.Metadata)
+		sink.WriteBytes(metadataDigest[:])
+	}
+	return sink.Bytes(), nil
+}
+
+// leafs hashed from the payloads. and the hex of them.
+func (self *DataParam) leafs(algorithm HashAlgorithm, maxNum uint32) ([]common.Uint256, []string, error) {
+	payloads, err := self.decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint32(len(payloads)) > maxNum || len(payloads) == 0 {
+		return nil, nil, errors.New("too much or empty data")
+	}
+
+	leafs := make([]common.Uint256, 0, len(payloads))
+	hashes := make([]string, 0, len(payloads))
+	for _, payload := range payloads {
+		leaf := algorithm.Sum(payload)
+		leafs = append(leafs, leaf)
+		hashes = append(hashes, hex.EncodeToString(leaf[:]))
+	}
+
+	return leafs, hashes, nil
+}
+
+// signature checked by rpc dispatch. add the leafs hashed by server as batchAdd.
+func rpcAddData(dargs *DataParam) map[string]interface{} {
+	if SystemOutOfService {
+		return responsePack(NODE_OUTSERVICE, "Out of Service")
+	}
+	if len(dargs.Root) != 0 || dargs.TreeSize != 0 {
+		return responsePack(INVALID_PARAM, "root and treeSize only for verifyData")
+	}
+
+	algorithm, err := parseHashAlgorithm(dargs.Algorithm)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	leafs, hashes, err := dargs.leafs(algorithm, maxDeclineNum)
+	if err != nil {
+		log.Infof("addData: %s", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	response, added := addLeafs(dargs.PubKey, addressOfPubKey(dargs.PubKey), leafs, dargs.Metadata, algorithm, dargs.IdempotencyKey)
+	if added {
+		request, err := dargs.SignData()
+		if err != nil {
+			log.Errorf("addData sig record: %s", err)
+			return response
+		}
+		sigDataChan <- &DataSigRecord{
+			PubKey:    dargs.PubKey,
+			Signature: dargs.Signature,
+			Leafs:     hashes,
+			Timestamp: dargs.Timestamp,
+			Nonce:     dargs.Nonce,
+			Request:   request,
+		}
+	}
+
+	return response
+}
+
+// DataSigRecord signed addData request kept in sigDB. Request is the canonical of DataParam with sha256 of payloads. so audited without the payloads.
+type DataSigRecord struct {
+	PubKey    string
+	Signature string
+	Leafs     []string
+	Timestamp int64
+	Nonce     string
+	Request   []byte
+}
+
+// same layout as batchAdd records. leafs then the mark.
+func (self *DataSigRecord) writeSigRecord(sink *common.ZeroCopySink) {
+	sink.WriteString(self.PubKey)
+	sink.WriteString(self.Signature)
+	for _, leaf := range self.Leafs {
+		sink.WriteString(leaf)
+	}
+	sink.WriteString(sigDataAddDataMark)
+	sink.WriteUint64(uint64(self.Timestamp))
+	sink.WriteString(self.Nonce)
+	sink.WriteVarBytes(self.Request)
+}
+
+// readSigRecord the fields after the mark. must be complete.
+func (self *DataSigRecord) readSigRecord(source *common.ZeroCopySource) error {
+	timestamp, eof := source.NextUint64()
+	if eof {
+		return errors.New("wrong decode addData timestamp")
+	}
+	nonce, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return errors.New("wrong decode addData nonce")
+	}
+	request, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return errors.New("wrong decode addData request")
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("%d bytes after addData request", source.Len())
+	}
+
+	self.Timestamp = int64(timestamp)
+	self.Nonce = nonce
+	self.Request = request
+	return nil
+}
+
+// verify the signature of the request. and one payload digest for each leaf.
+func (self *DataSigRecord) verify() error {
+	source := common.NewZeroCopySource(self.Request)
+	_, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return errors.New("wrong decode addData algorithm")
+	}
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return errors.New("wrong decode addData payloads")
+	}
+	if num != uint64(len(self.Leafs)) {
+		return fmt.Errorf("%d payloads signed for %d leafs", num, len(self.Leafs))
+	}
+
+	pubkey, sigData, err := getPublicSigData(self.PubKey, self.Signature)
+	if err != nil {
+		return err
+	}
+
+	return signature.Verify(pubkey, canonicalRequest("addData", self.Request, self.Timestamp, self.Nonce), sigData)
+}
+
+// signature checked by rpc dispatch. batchVerify of the leafs hashed by server.
+func rpcVerifyData(dargs *DataParam) map[string]interface{} {
+	if len(dargs.IdempotencyKey) != 0 || len(dargs.Metadata) != 0 {
+		return responsePack(INVALID_PARAM, "idempotencyKey and metadata only for addData")
+	}
+
+	algorithm, err := parseHashAlgorithm(dargs.Algorithm)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	_, hashes, err := dargs.leafs(algorithm, maxBatchVerifyNum())
+	if err != nil {
+		log.Infof("verifyData: %s", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	return rpcBatchVerify(&VerifyParam{
+		PubKey:   dargs.PubKey,
+		Hashes:   hashes,
+		Root:     dargs.Root,
+		TreeSize: dargs.TreeSize,
+	})
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "addData",
+		Desc:      "add base64 data hashed by server with algorithm sha256 (rfc 6962 leaf), sha3-256 or blake2b-256. result is the batchAdd receipt with the leaf hashes.",
+		Auth:      RPC_AUTH_REQUEST,
+		Role:      ROLE_SUBMITTER,
+		NewParams: func() interface{} { return &DataParam{} },
+		Handler:   func(params interface{}) map[string]interface{} { return rpcAddData(params.(*DataParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "verifyData",
+		Desc:       "get the inclusion proofs of base64 data hashed by server with the algorithm of addData.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_VERIFIER,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &DataParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcVerifyData(params.(*DataParam)) },
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestHashAlgorithm(t *testing.T) {
+	algorithm, err := parseHashAlgorithm("")
+	if err != nil || algorithm != HASH_SHA256 {
+		t.Errorf("default algorithm %s %v", algorithm, err)
+	}
+	for _, name := range []string{"sha3-256", "blake2b-256"} {
+		algorithm, err = parseHashAlgorithm(name)
+		if err != nil || algorithm.String() != name {
+			t.Errorf("algorithm %s parsed %s %v", name, algorithm, err)
+		}
+	}
+	if _, err = parseHashAlgorithm("client"); err == nil {
+		t.Errorf("client accepted as data algorithm")
+	}
+
+	data := []byte("document")
+	if HASH_SHA256.Sum(data) != hashLeaf(data) {
+		t.Errorf("sha256 not the rfc 6962 leaf hash")
+	}
+	if HASH_SHA3_256.Sum(data) == HASH_BLAKE2B_256.Sum(data) {
+		t.Errorf("algorithms hashed the same")
+	}
+}
+
+func TestDataParamSignData(t *testing.T) {
+	payloads := [][]byte{[]byte("a"), []byte("b")}
+	encoded := &DataParam{Algorithm: "sha256", IdempotencyKey: "key"}
+	for _, payload := range payloads {
+		encoded.Data = append(encoded.Data, base64.StdEncoding.EncodeToString(payload))
+	}
+	multipart := &DataParam{Algorithm: "sha256", IdempotencyKey: "key", payloads: payloads}
+
+	a, err := encoded.SignData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := multipart.SignData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("base64 and multipart signed different data")
+	}
+
+	leafs, hashes, err := encoded.leafs(HASH_SHA256, 1)
+	if err == nil {
+		t.Errorf("data more than max accepted %v", leafs)
+	}
+	leafs, hashes, err = encoded.leafs(HASH_SHA256, 2)
+	if err != nil || len(leafs) != 2 || leafs[0] != hashLeaf(payloads[0]) || len(hashes) != 2 {
+		t.Errorf("leafs of data %v %v %v", leafs, hashes, err)
+	}
+
+	if _, err = (&DataParam{Data: []string{"not base64!"}}).SignData(); err == nil {
+		t.Errorf("invalid base64 signed")
+	}
+}
+
+func TestDataParamReplay(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore := DefStore
+	defer func() { DefStore = oldStore }()
+	DefStore = store
+	address := common.AddressFromVmCode([]byte("submitter"))
+
+	param := &DataParam{Timestamp: time.Now().Unix(), Nonce: "data", IdempotencyKey: "key"}
+	if err = param.CheckReplay(address); err != nil {
+		t.Fatal(err)
+	}
+	// nonce persisted. rejected after the nonce cache lost by restart.
+	if err = param.CheckReplay(address); err == nil {
+		t.Errorf("replay accepted")
+	}
+
+	digest := leafsDigest([]common.Uint256{sha256.Sum256([]byte("a"))})
+	if err = putIdempotentResponse(store, address, "key", digest, &ReceiptJson{Id: "receipt"}); err != nil {
+		t.Fatal(err)
+	}
+	param.Timestamp = time.Now().Unix() - requestSkew() - 10
+	if err = param.CheckReplay(address); err != nil {
+		t.Errorf("retry of recorded idempotency key %v", err)
+	}
+
+	param.IdempotencyKey = ""
+	param.Nonce = "old"
+	if err = param.CheckReplay(address); err == nil {
+		t.Errorf("old request accepted")
+	}
+}
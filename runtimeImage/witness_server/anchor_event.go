@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	ANCHOR_EVENT_ANCHORED = "anchored"
+	// batch tx exec failed and reconstructed to NewTxHash.
+	ANCHOR_EVENT_FAILED = "failed"
+)
+
+// AnchorEvent published after the block committed to local ledger.
+type AnchorEvent struct {
+	Type        string
+	Leafs       []common.Uint256
+	StartIndex  uint32
+	Root        common.Uint256
+	TreeSize    uint32
+	BlockHeight uint32
+	TxHash      common.Uint256
+	NewTxHash   common.Uint256
+}
+
+func newAnchoredEvent(leafv []common.Uint256, tree *merkle.CompactMerkleTree, height uint32, txh common.Uint256) *AnchorEvent {
+	return &AnchorEvent{
+		Type:        ANCHOR_EVENT_ANCHORED,
+		Leafs:       leafv,
+		StartIndex:  tree.TreeSize() - uint32(len(leafv)),
+		Root:        tree.Root(),
+		TreeSize:    tree.TreeSize(),
+		BlockHeight: height,
+		TxHash:      txh,
+	}
+}
+
+type AnchorBroker struct {
+	lock sync.RWMutex
+	next uint64
+	subs map[uint64]chan *AnchorEvent
+}
+
+var DefAnchorBroker = NewAnchorBroker()
+
+func NewAnchorBroker() *AnchorBroker {
+	return &AnchorBroker{
+		subs: make(map[uint64]chan *AnchorEvent),
+	}
+}
+
+func (self *AnchorBroker) Subscribe(size int) (uint64, <-chan *AnchorEvent) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.next++
+	ch := make(chan *AnchorEvent, size)
+	self.subs[self.next] = ch
+	return self.next, ch
+}
+
+func (self *AnchorBroker) Unsubscribe(id uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if ch, ok := self.subs[id]; ok {
+		delete(self.subs, id)
+		close(ch)
+	}
+}
+
+// Publish never block RoutineOfAddToLocalStorage. event dropped for the subscriber which channel full.
+func (self *AnchorBroker) Publish(events []*AnchorEvent) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	for _, ev := range events {
+		for id, ch := range self.subs {
+			select {
+			case ch <- ev:
+			default:
+				log.Warnf("AnchorBroker: subscriber %d full. drop event of tx %s", id, ev.TxHash.ToHexString())
+			}
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/types"
+)
+
+const (
+	AUTHORIZED_KEY_SOURCE_CONFIG = "config"
+	AUTHORIZED_KEY_SOURCE_ADMIN  = "admin"
+
+	maxAuthorizedKeyNote = 256
+)
+
+// AuthorizedKey one authorized key with its roles. keys of config role lists are loaded as source config. admin records persisted override them.
+type AuthorizedKey struct {
+	Address   common.Address
+	PubKey    string
+	Source    string
+	AddedBy   common.Address
+	AddedAt   uint64
+	ExpireAt  uint64
+	Revoked   bool
+	RevokedBy common.Address
+	RevokedAt uint64
+	Note      string
+	Roles     KeyRole
+}
+
+func (self *AuthorizedKey) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteAddress(self.Address)
+	sink.WriteString(self.PubKey)
+	sink.WriteString(self.Source)
+	sink.WriteAddress(self.AddedBy)
+	sink.WriteUint64(self.AddedAt)
+	sink.WriteUint64(self.ExpireAt)
+	sink.WriteBool(self.Revoked)
+	sink.WriteAddress(self.RevokedBy)
+	sink.WriteUint64(self.RevokedAt)
+	sink.WriteString(self.Note)
+	sink.WriteUint32(uint32(self.Roles))
+}
+
+func (self *AuthorizedKey) Deserialization(source *common.ZeroCopySource) error {
+	address, eof := source.NextAddress()
+	pubKey, _, irregular, eof := source.NextString()
+	src, _, irregular, eof := source.NextString()
+	addedBy, eof := source.NextAddress()
+	addedAt, eof := source.NextUint64()
+	expireAt, eof := source.NextUint64()
+	revoked, irregular, eof := source.NextBool()
+	revokedBy, eof := source.NextAddress()
+	revokedAt, eof := source.NextUint64()
+	note, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+	// recorded before roles.
+	roles, eof := source.NextUint32()
+	if eof {
+		roles = uint32(ROLE_LEGACY)
+	}
+
+	self.Address = address
+	self.PubKey = pubKey
+	self.Source = src
+	self.AddedBy = addedBy
+	self.AddedAt = addedAt
+	self.ExpireAt = expireAt
+	self.Revoked = revoked
+	self.RevokedBy = revokedBy
+	self.RevokedAt = revokedAt
+	self.Note = note
+	self.Roles = KeyRole(roles)
+	return nil
+}
+
+func (self *AuthorizedKey) valid(now uint64) bool {
+	return !self.Revoked && (self.ExpireAt == 0 || now < self.ExpireAt)
+}
+
+type AuthorizedKeyJson struct {
+	Address   string   `json:"address"`
+	PubKey    string   `json:"pubKey,omitempty"`
+	Source    string   `json:"source"`
+	Roles     []string `json:"roles"`
+	AddedBy   string   `json:"addedBy,omitempty"`
+	AddedAt   uint64   `json:"addedAt,omitempty"`
+	ExpireAt  uint64   `json:"expireAt,omitempty"`
+	Expired   bool     `json:"expired"`
+	Revoked   bool     `json:"revoked"`
+	RevokedBy string   `json:"revokedBy,omitempty"`
+	RevokedAt uint64   `json:"revokedAt,omitempty"`
+	Note      string   `json:"note,omitempty"`
+}
+
+func addressString(address common.Address) string {
+	if address == common.ADDRESS_EMPTY {
+		return ""
+	}
+	return address.ToBase58()
+}
+
+func (self *AuthorizedKey) toJson(now uint64) *AuthorizedKeyJson {
+	return &AuthorizedKeyJson{
+		Address:   self.Address.ToBase58(),
+		PubKey:    self.PubKey,
+		Source:    self.Source,
+		Roles:     self.Roles.Names(),
+		AddedBy:   addressString(self.AddedBy),
+		AddedAt:   self.AddedAt,
+		ExpireAt:  self.ExpireAt,
+		Expired:   self.ExpireAt != 0 && now >= self.ExpireAt,
+		Revoked:   self.Revoked,
+		RevokedBy: addressString(self.RevokedBy),
+		RevokedAt: self.RevokedAt,
+		Note:      self.Note,
+	}
+}
+
+var (
+	authorizedKeys     = make(map[common.Address]*AuthorizedKey)
+	adminKeys          = make(map[common.Address]bool)
+	authorizedKeysLock sync.RWMutex
+)
+
+func getAuthorizedKeyKey(address common.Address) []byte {
+	return append([]byte{byte(PREFIX_AUTHORIZED_KEY)}, address[:]...)
+}
+
+func parseConfigAddresses(addrs []string) (map[common.Address]bool, error) {
+	res := make(map[common.Address]bool)
+	for _, s := range addrs {
+		addr, err := common.AddressFromBase58(s)
+		if err != nil {
+			return nil, fmt.Errorf("config address %s: %s", s, err)
+		}
+		res[addr] = true
+	}
+
+	return res, nil
+}
+
+// InitAuthorizedKeys parse the config keys once. then apply the admin records in store.
+// keys of authorize have all roles but admin. a key in many lists has the roles of all.
+func InitAuthorizedKeys(store *leveldbstore.LevelDBStore) error {
+	admins, err := parseConfigAddresses(DefConfig.AdminAuthorize)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[common.Address]*AuthorizedKey)
+	configRoles := []struct {
+		addrs []string
+		role  KeyRole
+	}{
+		{DefConfig.Authorize, ROLE_LEGACY},
+		{DefConfig.SubmitterAuthorize, ROLE_SUBMITTER},
+		{DefConfig.VerifierAuthorize, ROLE_VERIFIER},
+		{DefConfig.AuditorAuthorize, ROLE_AUDITOR},
+	}
+	for _, c := range configRoles {
+		configKeys, err := parseConfigAddresses(c.addrs)
+		if err != nil {
+			return err
+		}
+		for addr := range configKeys {
+			if key, ok := keys[addr]; ok {
+				key.Roles |= c.role
+				continue
+			}
+			keys[addr] = &AuthorizedKey{
+				Address: addr,
+				Source:  AUTHORIZED_KEY_SOURCE_CONFIG,
+				Roles:   c.role,
+			}
+		}
+	}
+
+	iter := store.NewIterator([]byte{byte(PREFIX_AUTHORIZED_KEY)})
+	defer iter.Release()
+	for iter.Next() {
+		key := &AuthorizedKey{}
+		err = key.Deserialization(common.NewZeroCopySource(iter.Value()))
+		if err != nil {
+			return err
+		}
+		keys[key.Address] = key
+	}
+
+	authorizedKeysLock.Lock()
+	authorizedKeys = keys
+	adminKeys = admins
+	authorizedKeysLock.Unlock()
+
+	log.Infof("InitAuthorizedKeys: %d keys, %d admins", len(keys), len(admins))
+	return iter.Error()
+}
+
+// putAuthorizedKey persist then apply to memory. caller hold authorizedKeysLock.
+func putAuthorizedKey(key *AuthorizedKey) error {
+	sink := common.NewZeroCopySink(nil)
+	key.Serialization(sink)
+	err := DefStore.Put(getAuthorizedKeyKey(key.Address), sink.Bytes())
+	if err != nil {
+		return err
+	}
+
+	authorizedKeys[key.Address] = key
+	return nil
+}
+
+// AuthorizedKeyParam signed request of admin. Key is base58 address or hex pubkey. Roles default all but admin.
+type AuthorizedKeyParam struct {
+	PubKey    string   `json:"pubKey"`
+	Signature string   `json:"signature"`
+	Timestamp int64    `json:"timestamp"`
+	Nonce     string   `json:"nonce"`
+	Key       string   `json:"key"`
+	ExpireAt  uint64   `json:"expireAt,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+func (self *AuthorizedKeyParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *AuthorizedKeyParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *AuthorizedKeyParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *AuthorizedKeyParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of key, expireAt, note and roles. roles only if set. so requests without roles sign the same as before.
+func (self *AuthorizedKeyParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.Key)
+	sink.WriteUint64(self.ExpireAt)
+	sink.WriteString(self.Note)
+	if len(self.Roles) != 0 {
+		sink.WriteVarUint(uint64(len(self.Roles)))
+		for _, r := range self.Roles {
+			sink.WriteString(r)
+		}
+	}
+	return sink.Bytes(), nil
+}
+
+// CheckReplay nonce persisted as batchAdd. so a request of admin not replayed after restart.
+func (self *AuthorizedKeyParam) CheckReplay(address common.Address) error {
+	err := checkRequestTime(self.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	return checkBatchAddNonce(DefStore, address, self.Nonce, self.Timestamp)
+}
+
+// roles of the key added. admin only by config.
+func (self *AuthorizedKeyParam) parseRoles() (KeyRole, error) {
+	if len(self.Roles) == 0 {
+		return ROLE_LEGACY, nil
+	}
+
+	roles, err := parseRoles(self.Roles)
+	if err != nil {
+		return 0, err
+	}
+	if roles&ROLE_ADMIN != 0 {
+		return 0, errors.New("admin role only by config adminauthorize")
+	}
+
+	return roles, nil
+}
+
+// parseKey return the address and hex pubkey if Key is pubkey.
+func (self *AuthorizedKeyParam) parseKey() (common.Address, string, error) {
+	addr, err := common.AddressFromBase58(self.Key)
+	if err == nil {
+		return addr, "", nil
+	}
+
+	pubkey, _, err := getPublicSigData(self.Key, "")
+	if err != nil {
+		return common.ADDRESS_EMPTY, "", errors.New("key should be base58 address or hex pubkey")
+	}
+
+	return types.AddressFromPubKey(pubkey), self.Key, nil
+}
+
+// addressOfPubKey address of the signed request. signature and role checked by rpc dispatch.
+func addressOfPubKey(pubKey string) common.Address {
+	pubkey, _, err := getPublicSigData(pubKey, "")
+	if err != nil {
+		return common.ADDRESS_EMPTY
+	}
+
+	return types.AddressFromPubKey(pubkey)
+}
+
+func rpcAddAuthorizedKey(kargs *AuthorizedKeyParam) map[string]interface{} {
+	address, pubKey, err := kargs.parseKey()
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+	roles, err := kargs.parseRoles()
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	now := uint64(time.Now().Unix())
+	if kargs.ExpireAt != 0 && kargs.ExpireAt <= now {
+		return responsePack(INVALID_PARAM, "expireAt already passed")
+	}
+	if len(kargs.Note) > maxAuthorizedKeyNote {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("note most %d", maxAuthorizedKeyNote))
+	}
+
+	key := &AuthorizedKey{
+		Address:  address,
+		PubKey:   pubKey,
+		Source:   AUTHORIZED_KEY_SOURCE_ADMIN,
+		AddedBy:  addressOfPubKey(kargs.PubKey),
+		AddedAt:  now,
+		ExpireAt: kargs.ExpireAt,
+		Note:     kargs.Note,
+		Roles:    roles,
+	}
+
+	authorizedKeysLock.Lock()
+	defer authorizedKeysLock.Unlock()
+	err = putAuthorizedKey(key)
+	if err != nil {
+		log.Errorf("addAuthorizedKey: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	log.Infof("addAuthorizedKey: %s by %s. roles %v, expireAt %d", address.ToBase58(), key.AddedBy.ToBase58(), roles.Names(), key.ExpireAt)
+	return responseSuccess(key.toJson(now))
+}
+
+func rpcRevokeAuthorizedKey(kargs *AuthorizedKeyParam) map[string]interface{} {
+	address, _, err := kargs.parseKey()
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	authorizedKeysLock.Lock()
+	defer authorizedKeysLock.Unlock()
+
+	old, ok := authorizedKeys[address]
+	if !ok || old.Revoked {
+		return responsePack(INVALID_PARAM, "key not authorized")
+	}
+
+	now := uint64(time.Now().Unix())
+	key := *old
+	key.Revoked = true
+	key.RevokedBy = addressOfPubKey(kargs.PubKey)
+	key.RevokedAt = now
+	err = putAuthorizedKey(&key)
+	if err != nil {
+		log.Errorf("revokeAuthorizedKey: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	log.Infof("revokeAuthorizedKey: %s by %s", address.ToBase58(), key.RevokedBy.ToBase58())
+	return responseSuccess(key.toJson(now))
+}
+
+type AuthorizedKeyListParam struct {
+	PubKey         string `json:"pubKey"`
+	Signature      string `json:"signature"`
+	Timestamp      int64  `json:"timestamp"`
+	Nonce          string `json:"nonce"`
+	IncludeRevoked bool   `json:"includeRevoked,omitempty"`
+}
+
+func (self *AuthorizedKeyListParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *AuthorizedKeyListParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *AuthorizedKeyListParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *AuthorizedKeyListParam) GetNonce() string {
+	return self.Nonce
+}
+
+func (self *AuthorizedKeyListParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBool(self.IncludeRevoked)
+	return sink.Bytes(), nil
+}
+
+func rpcListAuthorizedKeys(kargs *AuthorizedKeyListParam) map[string]interface{} {
+	now := uint64(time.Now().Unix())
+
+	authorizedKeysLock.RLock()
+	res := make([]*AuthorizedKeyJson, 0, len(authorizedKeys))
+	for _, key := range authorizedKeys {
+		if key.Revoked && !kargs.IncludeRevoked {
+			continue
+		}
+		res = append(res, key.toJson(now))
+	}
+	authorizedKeysLock.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Address < res[j].Address
+	})
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "addAuthorizedKey",
+		Desc:      "authorize a key or replace its roles. base58 address or hex pubkey. roles of submitter, verifier and auditor. default all. optional expireAt unix seconds. admin only.",
+		Auth:      RPC_AUTH_REQUEST,
+		Role:      ROLE_ADMIN,
+		NewParams: func() interface{} { return &AuthorizedKeyParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcAddAuthorizedKey(params.(*AuthorizedKeyParam))
+		},
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "revokeAuthorizedKey",
+		Desc:      "revoke a key of any role. config keys too. admin only.",
+		Auth:      RPC_AUTH_REQUEST,
+		Role:      ROLE_ADMIN,
+		NewParams: func() interface{} { return &AuthorizedKeyParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcRevokeAuthorizedKey(params.(*AuthorizedKeyParam))
+		},
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "listAuthorizedKeys",
+		Desc:       "list keys with roles and audit info. admin only.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_ADMIN,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &AuthorizedKeyListParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcListAuthorizedKeys(params.(*AuthorizedKeyListParam))
+		},
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	sdk "github.com/ontio/ontology-go-sdk"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestAuthorizedKeysRuntime(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore := DefStore
+	oldSubmitter, oldVerifier, oldAdmin := DefConfig.SubmitterAuthorize, DefConfig.VerifierAuthorize, DefConfig.AdminAuthorize
+	authorizedKeysLock.Lock()
+	oldKeys, oldAdmins := authorizedKeys, adminKeys
+	authorizedKeysLock.Unlock()
+	defer func() {
+		DefStore = oldStore
+		DefConfig.SubmitterAuthorize, DefConfig.VerifierAuthorize, DefConfig.AdminAuthorize = oldSubmitter, oldVerifier, oldAdmin
+		authorizedKeysLock.Lock()
+		authorizedKeys, adminKeys = oldKeys, oldAdmins
+		authorizedKeysLock.Unlock()
+	}()
+	DefStore = store
+
+	admin, both, overridden := sdk.NewAccount(), common.AddressFromVmCode([]byte("both")), common.AddressFromVmCode([]byte("overridden"))
+	DefConfig.SubmitterAuthorize = []string{both.ToBase58(), overridden.ToBase58()}
+	DefConfig.VerifierAuthorize = []string{both.ToBase58()}
+	DefConfig.AdminAuthorize = []string{admin.Address.ToBase58()}
+	// admin record persisted before restart override config.
+	sink := common.NewZeroCopySink(nil)
+	(&AuthorizedKey{Address: overridden, Source: AUTHORIZED_KEY_SOURCE_ADMIN, Revoked: true, Roles: ROLE_SUBMITTER}).Serialization(sink)
+	store.Put(getAuthorizedKeyKey(overridden), sink.Bytes())
+
+	if err = InitAuthorizedKeys(store); err != nil {
+		t.Fatal(err)
+	}
+	if roles := rolesOfAddress(both); roles != ROLE_SUBMITTER|ROLE_VERIFIER {
+		t.Errorf("roles of config lists %v", roles.Names())
+	}
+	if roles := rolesOfAddress(overridden); roles != 0 {
+		t.Errorf("config key revoked by admin has roles %v", roles.Names())
+	}
+	if !checkRoleOfAddress(admin.Address, ROLE_ADMIN) {
+		t.Errorf("admin of config not admin")
+	}
+
+	acc := sdk.NewAccount()
+	adminPubKey := hex.EncodeToString(keypair.SerializePublicKey(admin.PublicKey))
+	res := rpcAddAuthorizedKey(&AuthorizedKeyParam{
+		PubKey: adminPubKey,
+		Key:    hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+		Roles:  []string{"verifier"},
+		Note:   "runtime",
+	})
+	if res["error"] != SUCCESS {
+		t.Fatalf("addAuthorizedKey %v", res)
+	}
+	if key := res["result"].(*AuthorizedKeyJson); key.Address != acc.Address.ToBase58() || key.AddedBy != admin.Address.ToBase58() || key.Source != AUTHORIZED_KEY_SOURCE_ADMIN {
+		t.Errorf("added key %+v", key)
+	}
+	if roles := rolesOfAddress(acc.Address); roles != ROLE_VERIFIER {
+		t.Errorf("roles of added key %v", roles.Names())
+	}
+	if res = rpcAddAuthorizedKey(&AuthorizedKeyParam{PubKey: adminPubKey, Key: acc.Address.ToBase58(), ExpireAt: 1}); res["error"] != INVALID_PARAM {
+		t.Errorf("expireAt passed accepted %v", res)
+	}
+
+	if res = rpcRevokeAuthorizedKey(&AuthorizedKeyParam{PubKey: adminPubKey, Key: acc.Address.ToBase58()}); res["error"] != SUCCESS {
+		t.Fatalf("revokeAuthorizedKey %v", res)
+	}
+	if checkRoleOfAddress(acc.Address, ROLE_VERIFIER) {
+		t.Errorf("revoked key still authorized")
+	}
+	if res = rpcRevokeAuthorizedKey(&AuthorizedKeyParam{PubKey: adminPubKey, Key: acc.Address.ToBase58()}); res["error"] != INVALID_PARAM {
+		t.Errorf("revoked twice %v", res)
+	}
+
+	list := rpcListAuthorizedKeys(&AuthorizedKeyListParam{})["result"].([]*AuthorizedKeyJson)
+	if len(list) != 1 || list[0].Address != both.ToBase58() {
+		t.Errorf("list without revoked %+v", list)
+	}
+	list = rpcListAuthorizedKeys(&AuthorizedKeyListParam{IncludeRevoked: true})["result"].([]*AuthorizedKeyJson)
+	if len(list) != 3 {
+		t.Errorf("list with revoked %d keys, expect 3", len(list))
+	}
+
+	// revoke persisted.
+	if err = InitAuthorizedKeys(store); err != nil {
+		t.Fatal(err)
+	}
+	if checkRoleOfAddress(acc.Address, ROLE_VERIFIER) {
+		t.Errorf("revoked key authorized after restart")
+	}
+}
+
+func TestAuthorizedKeyReplayAfterRestart(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldCache := DefStore, DefNonceCache
+	authorizedKeysLock.Lock()
+	oldKeys, oldAdmins := authorizedKeys, adminKeys
+	authorizedKeysLock.Unlock()
+	defer func() {
+		DefStore, DefNonceCache = oldStore, oldCache
+		authorizedKeysLock.Lock()
+		authorizedKeys, adminKeys = oldKeys, oldAdmins
+		authorizedKeysLock.Unlock()
+	}()
+	DefStore = store
+
+	admin := sdk.NewAccount()
+	authorizedKeysLock.Lock()
+	authorizedKeys, adminKeys = map[common.Address]*AuthorizedKey{}, map[common.Address]bool{admin.Address: true}
+	authorizedKeysLock.Unlock()
+
+	param := &AuthorizedKeyParam{
+		PubKey:    hex.EncodeToString(keypair.SerializePublicKey(admin.PublicKey)),
+		Timestamp: time.Now().Unix(),
+		Nonce:     "add key",
+		Key:       common.AddressFromVmCode([]byte("key")).ToBase58(),
+	}
+	param.Signature = signTestRequest(t, admin, "addAuthorizedKey", param)
+	if res := checkRpcAuth(context.Background(), "addAuthorizedKey", RPC_AUTH_REQUEST, ROLE_ADMIN, param); res != nil {
+		t.Fatalf("signed request %v", res)
+	}
+
+	// nonce cache lost by restart.
+	DefNonceCache = NewNonceCache()
+	if res := checkRpcAuth(context.Background(), "addAuthorizedKey", RPC_AUTH_REQUEST, ROLE_ADMIN, param); res == nil || res["error"] != NO_AUTH {
+		t.Errorf("replay after restart accepted %v", res)
+	}
+}
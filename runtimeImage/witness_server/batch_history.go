@@ -0,0 +1,522 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+const (
+	defaultBatchHistoryLimit uint32 = 20
+	maxBatchHistoryLimit     uint32 = 100
+)
+
+// BatchInfo history of one batch_add tx. kept after the tx anchored or replaced.
+type BatchInfo struct {
+	Seq         uint64
+	TxHash      common.Uint256
+	Leafs       []common.Uint256
+	Stage       LeafStage
+	Created     uint64
+	Replaces    common.Uint256
+	ReplacedBy  common.Uint256
+	BlockHeight uint32
+	Root        common.Uint256
+	TreeSize    uint32
+}
+
+func (self *BatchInfo) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(self.Seq)
+	sink.WriteHash(self.TxHash)
+	sink.WriteVarUint(uint64(len(self.Leafs)))
+	for _, leaf := range self.Leafs {
+		sink.WriteHash(leaf)
+	}
+	sink.WriteByte(byte(self.Stage))
+	sink.WriteUint64(self.Created)
+	sink.WriteHash(self.Replaces)
+	sink.WriteHash(self.ReplacedBy)
+	sink.WriteUint32(self.BlockHeight)
+	sink.WriteHash(self.Root)
+	sink.WriteUint32(self.TreeSize)
+}
+
+func (self *BatchInfo) Deserialization(source *common.ZeroCopySource) error {
+	seq, eof := source.NextUint64()
+	txh, eof := source.NextHash()
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	leafs := make([]common.Uint256, 0, num)
+	for i := uint64(0); i < num; i++ {
+		leaf, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		leafs = append(leafs, leaf)
+	}
+
+	stage, eof := source.NextByte()
+	created, eof := source.NextUint64()
+	replaces, eof := source.NextHash()
+	replacedBy, eof := source.NextHash()
+	height, eof := source.NextUint32()
+	root, eof := source.NextHash()
+	treeSize, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.Seq = seq
+	self.TxHash = txh
+	self.Leafs = leafs
+	self.Stage = LeafStage(stage)
+	self.Created = created
+	self.Replaces = replaces
+	self.ReplacedBy = replacedBy
+	self.BlockHeight = height
+	self.Root = root
+	self.TreeSize = treeSize
+	return nil
+}
+
+// BatchSend send record of tx. only written by RoutineOfSendTx so not race with the block handle.
+type BatchSend struct {
+	FirstSend uint64
+	LastSend  uint64
+	SendCount uint32
+}
+
+func (self *BatchSend) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(self.FirstSend)
+	sink.WriteUint64(self.LastSend)
+	sink.WriteUint32(self.SendCount)
+}
+
+func (self *BatchSend) Deserialization(source *common.ZeroCopySource) error {
+	first, eof := source.NextUint64()
+	last, eof := source.NextUint64()
+	count, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.FirstSend = first
+	self.LastSend = last
+	self.SendCount = count
+	return nil
+}
+
+type BatchInfoJson struct {
+	Seq         uint64 `json:"seq"`
+	TxHash      string `json:"txHash"`
+	LeafCount   uint32 `json:"leafCount"`
+	Stage       string `json:"stage"`
+	Created     uint64 `json:"created"`
+	FirstSend   uint64 `json:"firstSend"`
+	LastSend    uint64 `json:"lastSend"`
+	SendCount   uint32 `json:"sendCount"`
+	ResendCount uint32 `json:"resendCount"`
+	Replaces    string `json:"replaces,omitempty"`
+	ReplacedBy  string `json:"replacedBy,omitempty"`
+	BlockHeight uint32 `json:"blockheight"`
+	Root        string `json:"root,omitempty"`
+	TreeSize    uint32 `json:"size"`
+}
+
+func (self *BatchInfo) toJson(send *BatchSend) *BatchInfoJson {
+	res := &BatchInfoJson{
+		Seq:         self.Seq,
+		TxHash:      txHashString(self.TxHash),
+		LeafCount:   uint32(len(self.Leafs)),
+		Stage:       leafStageName[self.Stage],
+		Created:     self.Created,
+		FirstSend:   send.FirstSend,
+		LastSend:    send.LastSend,
+		SendCount:   send.SendCount,
+		Replaces:    txHashString(self.Replaces),
+		ReplacedBy:  txHashString(self.ReplacedBy),
+		BlockHeight: self.BlockHeight,
+		TreeSize:    self.TreeSize,
+	}
+
+	if send.SendCount > 1 {
+		res.ResendCount = send.SendCount - 1
+	}
+	if self.Stage == LEAF_STAGE_BATCHED && send.SendCount != 0 {
+		res.Stage = leafStageName[LEAF_STAGE_SUBMITTED]
+	}
+	if self.Stage == LEAF_STAGE_ANCHORED {
+		res.Root = hex.EncodeToString(self.Root[:])
+	}
+
+	return res
+}
+
+var (
+	batchHistorySeq uint64
+	batchSendLock   sync.Mutex
+)
+
+// key of batch history ordered by created.
+func getBatchIndexKey(seq uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = byte(PREFIX_BATCH_INDEX)
+	binary.BigEndian.PutUint64(key[1:], seq)
+	return key
+}
+
+func InitBatchHistory(store *leveldbstore.LevelDBStore) {
+	iter := store.NewIterator([]byte{byte(PREFIX_BATCH_INDEX)})
+	if iter.Last() {
+		batchHistorySeq = binary.BigEndian.Uint64(iter.Key()[1:])
+	}
+	iter.Release()
+}
+
+func writeBatchInfo(store *leveldbstore.LevelDBStore, info *BatchInfo) {
+	sink := common.NewZeroCopySink(nil)
+	info.Serialization(sink)
+	store.BatchPut(GetKeyByHash(PREFIX_BATCH, info.TxHash), sink.Bytes())
+}
+
+// putNewBatch record the batch of tx in store batch. replaces the failed tx this one constructed for if any.
+func putNewBatch(store *leveldbstore.LevelDBStore, txh common.Uint256, leafv []common.Uint256, replaces common.Uint256) *BatchInfo {
+	info := &BatchInfo{
+		Seq:      atomic.AddUint64(&batchHistorySeq, 1),
+		TxHash:   txh,
+		Leafs:    leafv,
+		Stage:    LEAF_STAGE_BATCHED,
+		Created:  uint64(time.Now().Unix()),
+		Replaces: replaces,
+	}
+
+	writeBatchInfo(store, info)
+	store.BatchPut(getBatchIndexKey(info.Seq), txh[:])
+	return info
+}
+
+func getBatchInfo(store *leveldbstore.LevelDBStore, txh common.Uint256) (*BatchInfo, error) {
+	val, err := store.Get(GetKeyByHash(PREFIX_BATCH, txh))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &BatchInfo{}
+	err = info.Deserialization(common.NewZeroCopySource(val))
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// batch of tx constructed before batch history has no record. create it when anchored or failed.
+func getOrNewBatch(store *leveldbstore.LevelDBStore, txh common.Uint256, leafv []common.Uint256) *BatchInfo {
+	info, err := getBatchInfo(store, txh)
+	if err != nil {
+		info = putNewBatch(store, txh, leafv, common.UINT256_EMPTY)
+	}
+
+	return info
+}
+
+func putBatchAnchored(store *leveldbstore.LevelDBStore, txh common.Uint256, leafv []common.Uint256, height uint32, root common.Uint256, treeSize uint32) {
+	info := getOrNewBatch(store, txh, leafv)
+	info.Stage = LEAF_STAGE_ANCHORED
+	info.BlockHeight = height
+	info.Root = root
+	info.TreeSize = treeSize
+	writeBatchInfo(store, info)
+}
+
+// putBatchFailed mark the failed tx replaced by newtxh. and record the batch of newtxh.
+func putBatchFailed(store *leveldbstore.LevelDBStore, txh common.Uint256, leafv []common.Uint256, height uint32, newtxh common.Uint256) {
+	info := getOrNewBatch(store, txh, leafv)
+	info.Stage = LEAF_STAGE_FAILED
+	info.BlockHeight = height
+	info.ReplacedBy = newtxh
+	writeBatchInfo(store, info)
+
+	putNewBatch(store, newtxh, leafv, txh)
+}
+
+func getBatchSend(store *leveldbstore.LevelDBStore, txh common.Uint256) *BatchSend {
+	send := &BatchSend{}
+	val, err := store.Get(GetKeyByHash(PREFIX_BATCH_SEND, txh))
+	if err != nil {
+		return send
+	}
+
+	err = send.Deserialization(common.NewZeroCopySource(val))
+	if err != nil {
+		log.Errorf("getBatchSend: %x. %s", txh, err)
+	}
+
+	return send
+}
+
+// markBatchSent count the send of tx. called by RoutineOfSendTx after each SendTransaction success. return the send count.
+func markBatchSent(txh common.Uint256) uint32 {
+	batchSendLock.Lock()
+	defer batchSendLock.Unlock()
+
+	now := uint64(time.Now().Unix())
+	send := getBatchSend(DefStore, txh)
+	if send.SendCount == 0 {
+		send.FirstSend = now
+	}
+	send.LastSend = now
+	send.SendCount++
+
+	sink := common.NewZeroCopySink(nil)
+	send.Serialization(sink)
+	err := DefStore.Put(GetKeyByHash(PREFIX_BATCH_SEND, txh), sink.Bytes())
+	if err != nil {
+		log.Errorf("markBatchSent: %x. %s", txh, err)
+	}
+
+	return send.SendCount
+}
+
+// lookupBatch get batch history of tx. pending tx constructed before batch history got from PREFIX_TX.
+func lookupBatch(store *leveldbstore.LevelDBStore, txh common.Uint256) (*BatchInfo, error) {
+	info, err := getBatchInfo(store, txh)
+	if err == nil {
+		return info, nil
+	}
+
+	tx, err := getTransaction(store, txh)
+	if err != nil {
+		return nil, fmt.Errorf("batch of tx %s not found", txh.ToHexString())
+	}
+
+	leafv, err := leafvFromTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchInfo{
+		TxHash: txh,
+		Leafs:  leafv,
+		Stage:  LEAF_STAGE_BATCHED,
+	}, nil
+}
+
+type BatchParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	TxHash    string `json:"txHash"`
+}
+
+func (self *BatchParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *BatchParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *BatchParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *BatchParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of tx hash.
+func (self *BatchParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.TxHash)
+	return sink.Bytes(), nil
+}
+
+type BatchListParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	From      uint64 `json:"from,omitempty"`
+	Limit     uint32 `json:"limit,omitempty"`
+}
+
+func (self *BatchListParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *BatchListParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *BatchListParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *BatchListParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of from and limit.
+func (self *BatchListParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(self.From)
+	sink.WriteUint32(self.Limit)
+	return sink.Bytes(), nil
+}
+
+type BatchListResult struct {
+	Batches []*BatchInfoJson `json:"batches"`
+	// seq to continue from. 0 if no more.
+	Next uint64 `json:"next"`
+}
+
+type BatchLeafsResult struct {
+	TxHash string `json:"txHash"`
+	Stage  string `json:"stage"`
+	// index of the first leaf. only when anchored.
+	StartIndex uint32   `json:"startIndex,omitempty"`
+	Leafs      []string `json:"leafs"`
+}
+
+// listBatches list batches with seq not less than from in created order.
+func listBatches(store *leveldbstore.LevelDBStore, from uint64, limit uint32) (*BatchListResult, error) {
+	res := &BatchListResult{
+		Batches: make([]*BatchInfoJson, 0, limit),
+	}
+
+	iter := store.NewIterator([]byte{byte(PREFIX_BATCH_INDEX)})
+	defer iter.Release()
+
+	for ok := iter.Seek(getBatchIndexKey(from)); ok; ok = iter.Next() {
+		if uint32(len(res.Batches)) == limit {
+			res.Next = binary.BigEndian.Uint64(iter.Key()[1:])
+			break
+		}
+
+		txh, err := common.Uint256ParseFromBytes(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := getBatchInfo(store, txh)
+		if err != nil {
+			return nil, err
+		}
+		res.Batches = append(res.Batches, info.toJson(getBatchSend(store, txh)))
+	}
+
+	return res, iter.Error()
+}
+
+func parseBatchParam(bargs *BatchParam) (common.Uint256, error) {
+	txh, err := common.Uint256FromHexString(bargs.TxHash)
+	if err != nil {
+		return common.UINT256_EMPTY, fmt.Errorf("invalid txHash: %s", err)
+	}
+
+	return txh, nil
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcGetBatch(bargs *BatchParam) map[string]interface{} {
+	txh, err := parseBatchParam(bargs)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	info, err := lookupBatch(DefStore, txh)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	return responseSuccess(info.toJson(getBatchSend(DefStore, txh)))
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcListBatches(bargs *BatchListParam) map[string]interface{} {
+	limit := bargs.Limit
+	if limit == 0 {
+		limit = defaultBatchHistoryLimit
+	}
+	if limit > maxBatchHistoryLimit {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("limit most %d", maxBatchHistoryLimit))
+	}
+
+	res, err := listBatches(DefStore, bargs.From, limit)
+	if err != nil {
+		log.Errorf("listBatches: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	return responseSuccess(res)
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcGetLeavesByTx(bargs *BatchParam) map[string]interface{} {
+	txh, err := parseBatchParam(bargs)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	info, err := lookupBatch(DefStore, txh)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	res := &BatchLeafsResult{
+		TxHash: txHashString(txh),
+		Stage:  leafStageName[info.Stage],
+		Leafs:  make([]string, 0, len(info.Leafs)),
+	}
+	if info.Stage == LEAF_STAGE_ANCHORED {
+		res.StartIndex = info.TreeSize - uint32(len(info.Leafs))
+	}
+	for _, leaf := range info.Leafs {
+		res.Leafs = append(res.Leafs, hex.EncodeToString(leaf[:]))
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getBatch",
+		Desc:       "get the history of batch tx. leaf count, send times, resend count, replaced tx, anchored block and root.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &BatchParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetBatch(params.(*BatchParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "listBatches",
+		Desc:       "list batch history in created order. paged by seq.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &BatchListParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcListBatches(params.(*BatchListParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getLeavesByTx",
+		Desc:       "get the leafs of batch tx. and the index of first leaf if anchored.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &BatchParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetLeavesByTx(params.(*BatchParam)) },
+	})
+}
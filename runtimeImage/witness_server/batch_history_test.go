@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestBatchHistory(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldSeq := DefStore, batchHistorySeq
+	defer func() { DefStore, batchHistorySeq = oldStore, oldSeq }()
+	DefStore, batchHistorySeq = store, 0
+
+	leafv := []common.Uint256{{1}, {2}}
+	failed, replaced, other := common.Uint256{0xa}, common.Uint256{0xb}, common.Uint256{0xc}
+	store.NewBatch()
+	putNewBatch(store, failed, leafv, common.UINT256_EMPTY)
+	store.BatchCommit()
+	markBatchSent(failed)
+	markBatchSent(failed)
+
+	info, err := getBatchInfo(store, failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := info.toJson(getBatchSend(store, failed)); res.Stage != "submitted" || res.SendCount != 2 || res.ResendCount != 1 || res.LeafCount != 2 {
+		t.Errorf("sent batch %+v", res)
+	}
+
+	store.NewBatch()
+	putBatchFailed(store, failed, leafv, 100, replaced)
+	store.BatchCommit()
+	// replacement anchored in a later block.
+	store.NewBatch()
+	putBatchAnchored(store, replaced, leafv, 101, common.Uint256{9}, 2)
+	// tx constructed before batch history.
+	putBatchAnchored(store, other, []common.Uint256{{3}}, 102, common.Uint256{8}, 3)
+	store.BatchCommit()
+
+	res, err := listBatches(store, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Batches) != 2 || res.Next != 3 {
+		t.Fatalf("first page %d batches next %d", len(res.Batches), res.Next)
+	}
+	first, second := res.Batches[0], res.Batches[1]
+	if first.Stage != "failed" || first.ReplacedBy != replaced.ToHexString() || first.BlockHeight != 100 {
+		t.Errorf("failed batch %+v", first)
+	}
+	if second.Stage != "anchored" || second.Replaces != failed.ToHexString() || second.TreeSize != 2 || len(second.Root) == 0 {
+		t.Errorf("replacement batch %+v", second)
+	}
+
+	res, err = listBatches(store, res.Next, 2)
+	if err != nil || len(res.Batches) != 1 || res.Next != 0 || res.Batches[0].TxHash != other.ToHexString() {
+		t.Errorf("last page %+v %v", res, err)
+	}
+
+	// seq continued after restart.
+	batchHistorySeq = 0
+	InitBatchHistory(store)
+	if batchHistorySeq != 3 {
+		t.Errorf("seq %d after restart, expect 3", batchHistorySeq)
+	}
+}
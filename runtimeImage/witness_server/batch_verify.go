@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	LEAF_STATUS_ANCHORED  = "anchored"
+	LEAF_STATUS_PENDING   = "pending"
+	LEAF_STATUS_NOT_FOUND = "notfound"
+	LEAF_STATUS_FAILED    = "failed"
+)
+
+type LeafVerifyResult struct {
+	Hash   string        `json:"hash"`
+	Status string        `json:"status"`
+	Result *VerifyResult `json:"result,omitempty"`
+	// pending leafs too. nil if not found or added before metadata recorded.
+	Metadata *LeafMetadataJson `json:"metadata,omitempty"`
+}
+
+// BatchVerifyResult all Results relative to the same Root snapshot.
+type BatchVerifyResult struct {
+	Root        string              `json:"root"`
+	TreeSize    uint32              `json:"size"`
+	BlockHeight uint32              `json:"blockheight"`
+	Results     []*LeafVerifyResult `json:"results"`
+}
+
+func maxBatchVerifyNum() uint32 {
+	if DefConfig.MaxVerifyNum == 0 {
+		return maxDeclineNum
+	}
+
+	return DefConfig.MaxVerifyNum
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcBatchVerify(vargs *VerifyParam) map[string]interface{} {
+	if SystemOutOfService {
+		return responsePack(NODE_OUTSERVICE, "Out of Service")
+	}
+	defer observeVerifyLatency(time.Now())
+
+	if uint32(len(vargs.Hashes)) > maxBatchVerifyNum() || len(vargs.Hashes) == 0 {
+		return responsePack(INVALID_PARAM, "too much or empty hashes")
+	}
+
+	leafs, _, err := convertParamsToLeafs(vargs.Hashes)
+	if err != nil {
+		log.Infof("batchVerify convert params err: %s\n", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	// one snapshot for all leafs. DefMerkleTree and FileHashStore can not change until done.
+	MTlock.RLock()
+	defer MTlock.RUnlock()
+
+	root, treeSize, blockheight, err := verifyTarget(vargs)
+	if err != nil {
+		log.Debugf("batchVerify get verify target failed, %s", err)
+		return responseFailed(VERIFY_FAILED, err.Error(), nil)
+	}
+
+	results, hits := verifyLeafsAt(leafs, vargs.Hashes, root, treeSize, blockheight)
+	metricVerify.WithLabelValues("hit").Add(float64(hits))
+	metricVerify.WithLabelValues("miss").Add(float64(len(leafs) - hits))
+
+	res := &BatchVerifyResult{
+		Root:        hex.EncodeToString(root[:]),
+		TreeSize:    treeSize,
+		BlockHeight: blockheight,
+		Results:     results,
+	}
+
+	log.Debugf("batchVerify %d leafs, root:%x, treeSize: %d\n", len(leafs), root, treeSize)
+	return responseSuccess(res)
+}
+
+// verifyLeafsAt proof of each leaf against root of treeSize. hashes are the leafs in request. return the anchored num. caller must hold MTlock.
+func verifyLeafsAt(leafs []common.Uint256, hashes []string, root common.Uint256, treeSize uint32, blockheight uint32) ([]*LeafVerifyResult, int) {
+	verify := merkle.NewMerkleVerifier()
+	results := make([]*LeafVerifyResult, 0, len(leafs))
+	hits := 0
+	for i, leaf := range leafs {
+		item := &LeafVerifyResult{
+			Hash: hashes[i],
+		}
+		results = append(results, item)
+
+		index, leafBlockHeight, leafTxHash, err := getLeafInfo(DefStore, leaf)
+		if err != nil && err != LEAF_HEIGHT_EMPTY_ERR {
+			item.Status = LEAF_STATUS_NOT_FOUND
+			continue
+		}
+		item.Metadata = leafMetadataJson(DefStore, leaf)
+
+		// index not assigned or anchored after the snapshot.
+		if index == math.MaxUint32 || index >= treeSize {
+			item.Status = LEAF_STATUS_PENDING
+			continue
+		}
+
+		proof, err := DefMerkleTree.InclusionProof(index, treeSize)
+		if err == nil {
+			err = verify.VerifyLeafHashInclusion(leaf, index, proof, root, treeSize)
+		}
+		if err != nil {
+			log.Debugf("batchVerify leaf %x failed %s", leaf, err)
+			item.Status = LEAF_STATUS_FAILED
+			continue
+		}
+
+		item.Status = LEAF_STATUS_ANCHORED
+		hits++
+		item.Result = &VerifyResult{
+			Root:        root,
+			TreeSize:    treeSize,
+			BlockHeight: blockheight,
+			Index:       index,
+			TxHash:      leafTxHash,
+			LeafHeight:  leafBlockHeight,
+			Proof:       proof,
+		}
+	}
+
+	return results, hits
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "batchVerify",
+		Desc:       "get the inclusion proofs of many leaf hashes against one root snapshot.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_VERIFIER,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &VerifyParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcBatchVerify(params.(*VerifyParam)) },
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/merkle"
+)
+
+func TestVerifyLeafsAt(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldTree, oldHashStore := DefStore, DefMerkleTree, FileHashStore
+	defer func() { DefStore, DefMerkleTree, FileHashStore = oldStore, oldTree, oldHashStore }()
+	hashStore := NewMemHashStore()
+	DefStore, FileHashStore = store, hashStore
+	DefMerkleTree = merkle.NewTree(0, nil, hashStore)
+
+	leafs := make([]common.Uint256, 0)
+	hashes := make([]string, 0)
+	store.NewBatch()
+	for i := uint32(0); i < 3; i++ {
+		leaf := hashLeaf([]byte{byte(i)})
+		putLeafIndex(store, leaf, i, 10, common.Uint256{1}.ToHexString())
+		DefMerkleTree.AppendHash(leaf)
+		leafs = append(leafs, leaf)
+		hashes = append(hashes, hex.EncodeToString(leaf[:]))
+	}
+	store.BatchCommit()
+	unknown := sha256.Sum256([]byte("unknown"))
+	leafs = append(leafs, unknown)
+	hashes = append(hashes, hex.EncodeToString(unknown[:]))
+
+	// snapshot before the last leaf anchored.
+	root, err := rootAtSize(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, hits := verifyLeafsAt(leafs, hashes, root, 2, 10)
+	if hits != 2 {
+		t.Errorf("%d anchored, expect 2", hits)
+	}
+	expect := []string{LEAF_STATUS_ANCHORED, LEAF_STATUS_ANCHORED, LEAF_STATUS_PENDING, LEAF_STATUS_NOT_FOUND}
+	for i, res := range results {
+		if res.Hash != hashes[i] || res.Status != expect[i] {
+			t.Errorf("leaf %d %s %s, expect %s", i, res.Hash, res.Status, expect[i])
+		}
+	}
+	if res := results[1].Result; res == nil || res.Root != root || res.TreeSize != 2 || res.Index != 1 {
+		t.Errorf("result of anchored leaf %+v", res)
+	}
+
+	results, hits = verifyLeafsAt(leafs[:1], hashes[:1], DefMerkleTree.Root(), 2, 10)
+	if hits != 0 || results[0].Status != LEAF_STATUS_FAILED {
+		t.Errorf("proof against other root %s", results[0].Status)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+const (
+	// legacy signature over the concatenated leaf bytes. only accepted if DefConfig.LegacyBatchAddSig set.
+	BATCHADD_SIG_LEGACY uint32 = 0
+	// envelope of domain, method, contract, tenant, timestamp, nonce, digest of hashes. idempotency key and digest of metadata if set.
+	BATCHADD_SIG_V1 uint32 = 1
+
+	BATCHADD_SIG_DOMAIN = "ontology-witness"
+)
+
+// RpcReplayParam implemented by params which can not be replayed even after restart. checked after signature verified.
+type RpcReplayParam interface {
+	CheckReplay(address common.Address) error
+}
+
+// batchAddSignData the data signed of version. not check the legacy config so old sigDB records can verify.
+func batchAddSignData(param *RpcParam) ([]byte, error) {
+	leafData, err := getRawDataForVerifySig(param.Hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch param.Version {
+	case BATCHADD_SIG_LEGACY:
+		if len(param.IdempotencyKey) != 0 {
+			return nil, errors.New("idempotency key need the version 1 envelope")
+		}
+		if len(param.Metadata) != 0 {
+			return nil, errors.New("metadata need the version 1 envelope")
+		}
+		return leafData, nil
+	case BATCHADD_SIG_V1:
+		digest := sha256.Sum256(leafData)
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteString(BATCHADD_SIG_DOMAIN)
+		sink.WriteUint32(param.Version)
+		sink.WriteString("batchAdd")
+		sink.WriteAddress(contractAddress)
+		sink.WriteString(DefConfig.TenantId)
+		sink.WriteUint64(uint64(param.Timestamp))
+		sink.WriteString(param.Nonce)
+		sink.WriteBytes(digest[:])
+		// requests without key and metadata sign the same envelope as before. key empty if only metadata.
+		if len(param.IdempotencyKey) != 0 || len(param.Metadata) != 0 {
+			sink.WriteString(param.IdempotencyKey)
+		}
+		if len(param.Metadata) != 0 {
+			metadataDigest := leafMetadataDigest(param.Metadata)
+			sink.WriteBytes(metadataDigest[:])
+		}
+		return sink.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("unknown batchAdd signature version %d", param.Version)
+}
+
+func (self *RpcParam) SignData() ([]byte, error) {
+	if self.Version == BATCHADD_SIG_LEGACY && !DefConfig.LegacyBatchAddSig {
+		return nil, errors.New("legacy batchAdd signature disabled. sign the version 1 envelope")
+	}
+
+	return batchAddSignData(self)
+}
+
+// CheckReplay legacy signature has no timestamp and nonce. can not check. retry of recorded idempotency key answered by the recorded response.
+func (self *RpcParam) CheckReplay(address common.Address) error {
+	if self.Version == BATCHADD_SIG_LEGACY {
+		return nil
+	}
+	if len(self.IdempotencyKey) != 0 && hasIdempotencyRecord(DefStore, address, self.IdempotencyKey) {
+		return nil
+	}
+
+	err := checkRequestTime(self.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	return checkBatchAddNonce(DefStore, address, self.Nonce, self.Timestamp)
+}
+
+var batchAddNonceLock sync.Mutex
+
+func getBatchAddNonceKey(address common.Address, nonce string) []byte {
+	key := make([]byte, 0, 1+common.ADDR_LEN+len(nonce))
+	key = append(key, byte(PREFIX_BATCHADD_NONCE))
+	key = append(key, address[:]...)
+	return append(key, []byte(nonce)...)
+}
+
+// checkBatchAddNonce persist the nonce of address until timestamp out of skew window. so replay rejected after restart.
+func checkBatchAddNonce(store *leveldbstore.LevelDBStore, address common.Address, nonce string, timestamp int64) error {
+	if len(nonce) == 0 || len(nonce) > MAX_NONCE_LENGTH {
+		return fmt.Errorf("nonce should not empty and most %d", MAX_NONCE_LENGTH)
+	}
+
+	batchAddNonceLock.Lock()
+	defer batchAddNonceLock.Unlock()
+
+	key := getBatchAddNonceKey(address, nonce)
+	raw, err := store.Get(key)
+	if err == nil {
+		expire, eof := common.NewZeroCopySource(raw).NextUint64()
+		if eof || expire >= uint64(time.Now().Unix()) {
+			return errors.New("nonce already used")
+		}
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(uint64(timestamp + requestSkew()))
+	return store.Put(key, sink.Bytes())
+}
+
+// RoutineOfBatchAddNonceExpire delete the nonces out of skew window. and the expired idempotency keys.
+func RoutineOfBatchAddNonceExpire() {
+	for {
+		if SystemOutOfService {
+			return
+		}
+
+		time.Sleep(time.Second * time.Duration(requestSkew()))
+
+		var store leveldbstore.LevelDBStore
+		store = *DefStore
+		store.NewBatch()
+
+		now := uint64(time.Now().Unix())
+		expired := 0
+		// both values start with the expire time.
+		for _, prefix := range []DataPrefix{PREFIX_BATCHADD_NONCE, PREFIX_IDEMPOTENCY_KEY} {
+			iter := store.NewIterator([]byte{byte(prefix)})
+			for iter.Next() {
+				expire, eof := common.NewZeroCopySource(iter.Value()).NextUint64()
+				if eof || expire < now {
+					store.BatchDelete(iter.Key())
+					expired++
+				}
+			}
+			iter.Release()
+		}
+
+		if expired == 0 {
+			continue
+		}
+
+		err := store.BatchCommit()
+		if err != nil {
+			log.Errorf("RoutineOfBatchAddNonceExpire: %s", err)
+			continue
+		}
+		log.Debugf("RoutineOfBatchAddNonceExpire: %d nonces and idempotency keys expired", expired)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/merkle"
+)
+
+type ConsistencyParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	First     uint32 `json:"first"`
+	Second    uint32 `json:"second"`
+}
+
+func (self *ConsistencyParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *ConsistencyParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *ConsistencyParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *ConsistencyParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of first and second.
+func (self *ConsistencyParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(self.First)
+	sink.WriteUint32(self.Second)
+	return sink.Bytes(), nil
+}
+
+type ConsistencyProofResult struct {
+	First      uint32           `json:"first"`
+	Second     uint32           `json:"second"`
+	FirstRoot  common.Uint256   `json:"firstRoot"`
+	SecondRoot common.Uint256   `json:"secondRoot"`
+	Proof      []common.Uint256 `json:"proof"`
+}
+
+func (self ConsistencyProofResult) MarshalJSON() ([]byte, error) {
+	proof := make([]string, 0, len(self.Proof))
+	for i := range self.Proof {
+		proof = append(proof, hex.EncodeToString(self.Proof[i][:]))
+	}
+
+	res := struct {
+		First      uint32   `json:"first"`
+		Second     uint32   `json:"second"`
+		FirstRoot  string   `json:"firstRoot"`
+		SecondRoot string   `json:"secondRoot"`
+		Proof      []string `json:"proof"`
+	}{
+		First:      self.First,
+		Second:     self.Second,
+		FirstRoot:  hex.EncodeToString(self.FirstRoot[:]),
+		SecondRoot: hex.EncodeToString(self.SecondRoot[:]),
+		Proof:      proof,
+	}
+
+	return json.Marshal(res)
+}
+
+// hash store position of leaf index. every leaf appended followed by the merged node hashes of it.
+func leafStorePos(index uint32) uint32 {
+	return 2*index - uint32(bits.OnesCount32(index))
+}
+
+// compactHashesAtSize get the compact merkle tree hashes of treeSize from hash store. hashes of perfect subtree from left to right.
+func compactHashesAtSize(store merkle.HashStore, treeSize uint32) ([]common.Uint256, error) {
+	hashes := make([]common.Uint256, 0, bits.OnesCount32(treeSize))
+	start := uint32(0)
+	for k := 31; k >= 0; k-- {
+		width := uint32(1) << uint(k)
+		if treeSize&width == 0 {
+			continue
+		}
+
+		// root of perfect subtree is the k-th merged node after its last leaf.
+		h, err := store.GetHash(leafStorePos(start+width-1) + uint32(k))
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+		start += width
+	}
+
+	return hashes, nil
+}
+
+// rootAtSize caller must hold MTlock.
+func rootAtSize(treeSize uint32) (common.Uint256, error) {
+	if treeSize == DefMerkleTree.TreeSize() {
+		return DefMerkleTree.Root(), nil
+	}
+
+	hashes, err := compactHashesAtSize(FileHashStore, treeSize)
+	if err != nil {
+		return merkle.EMPTY_HASH, err
+	}
+
+	return merkle.NewTree(treeSize, hashes, nil).Root(), nil
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcGetConsistencyProof(cargs *ConsistencyParam) map[string]interface{} {
+	if SystemOutOfService {
+		return responsePack(NODE_OUTSERVICE, "Out of Service")
+	}
+
+	MTlock.RLock()
+	defer MTlock.RUnlock()
+
+	treeSize := DefMerkleTree.TreeSize()
+	if cargs.First == 0 || cargs.First > cargs.Second || cargs.Second > treeSize {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("require 0 < first <= second <= %d", treeSize))
+	}
+
+	firstRoot, err := rootAtSize(cargs.First)
+	if err != nil {
+		log.Errorf("getConsistencyProof root of %d: %s", cargs.First, err)
+		return responsePack(VERIFY_FAILED, nil)
+	}
+
+	secondRoot, err := rootAtSize(cargs.Second)
+	if err != nil {
+		log.Errorf("getConsistencyProof root of %d: %s", cargs.Second, err)
+		return responsePack(VERIFY_FAILED, nil)
+	}
+
+	proof := DefMerkleTree.ConsistencyProof(cargs.First, cargs.Second)
+
+	verify := merkle.NewMerkleVerifier()
+	err = verify.VerifyConsistency(cargs.First, cargs.Second, firstRoot, secondRoot, proof)
+	if err != nil {
+		log.Errorf("getConsistencyProof self check %d to %d failed: %s", cargs.First, cargs.Second, err)
+		return responsePack(VERIFY_FAILED, nil)
+	}
+
+	res := ConsistencyProofResult{
+		First:      cargs.First,
+		Second:     cargs.Second,
+		FirstRoot:  firstRoot,
+		SecondRoot: secondRoot,
+		Proof:      proof,
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getConsistencyProof",
+		Desc:       "get the consistency proof that tree of size second is append only extension of tree of size first.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ConsistencyParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcGetConsistencyProof(params.(*ConsistencyParam))
+		},
+	})
+}
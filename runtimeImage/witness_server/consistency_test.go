@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/merkle"
+)
+
+func TestCompactHashesAtSize(t *testing.T) {
+	store := NewMemHashStore()
+	tree := merkle.NewTree(0, nil, store)
+	roots := make([]common.Uint256, 0)
+
+	sink := common.NewZeroCopySink(nil)
+	for i := uint32(0); i < 70; i++ {
+		sink.Reset()
+		sink.WriteUint32(i)
+		tree.AppendHash(hashLeaf(sink.Bytes()))
+		roots = append(roots, tree.Root())
+	}
+
+	for size := uint32(1); size <= tree.TreeSize(); size++ {
+		hashes, err := compactHashesAtSize(store, size)
+		if err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+
+		root := merkle.NewTree(size, hashes, nil).Root()
+		if root != roots[size-1] {
+			t.Errorf("size %d root %x, expect %x", size, root, roots[size-1])
+		}
+
+		if size == 1 {
+			continue
+		}
+		proof := tree.ConsistencyProof(size/2, size)
+		err = merkle.NewMerkleVerifier().VerifyConsistency(size/2, size, roots[size/2-1], root, proof)
+		if err != nil {
+			t.Errorf("consistency %d to %d: %s", size/2, size, err)
+		}
+	}
+}
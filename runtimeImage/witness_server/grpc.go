@@ -0,0 +1,520 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const grpcWatchBufSize = 256
+
+var grpcCodeMap = map[int64]codes.Code{
+	INVALID_PARAM:    codes.InvalidArgument,
+	ADDHASH_FAILED:   codes.Internal,
+	VERIFY_FAILED:    codes.NotFound,
+	NODE_OUTSERVICE:  codes.Unavailable,
+	NO_AUTH:          codes.PermissionDenied,
+	DUP_HASH:         codes.AlreadyExists,
+	PARSE_ERROR:      codes.InvalidArgument,
+	INVALID_REQUEST:  codes.InvalidArgument,
+	METHOD_NOT_FOUND: codes.Unimplemented,
+	INVALID_PARAMS:   codes.InvalidArgument,
+	INTERNAL_ERROR:   codes.Internal,
+}
+
+type WatchAnchorsParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	FromSize  uint32 `json:"fromSize"`
+}
+
+func (self *WatchAnchorsParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *WatchAnchorsParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *WatchAnchorsParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *WatchAnchorsParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of fromSize.
+func (self *WatchAnchorsParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(self.FromSize)
+	return sink.Bytes(), nil
+}
+
+func grpcStatusError(response map[string]interface{}) error {
+	errcode, _ := response["error"].(int64)
+	code, ok := grpcCodeMap[errcode]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	desc, _ := response["desc"].(string)
+	return status.Error(code, fmt.Sprintf("%d %s", errcode, desc))
+}
+
+// grpcCall call rpc method with the same auth as json rpc.
+func grpcCall(ctx context.Context, name string, params interface{}) (interface{}, error) {
+	method := getRpcMethod(name)
+	if method == nil {
+		return nil, status.Error(codes.Unimplemented, name)
+	}
+
+	response := callRpcMethod(ctx, method, params)
+	if errcode, _ := response["error"].(int64); errcode != SUCCESS {
+		return nil, grpcStatusError(response)
+	}
+
+	return response["result"], nil
+}
+
+func leafMetadataParamsFromGrpc(params []*GrpcLeafMetadataParam) []*LeafMetadataParam {
+	if len(params) == 0 {
+		return nil
+	}
+
+	res := make([]*LeafMetadataParam, 0, len(params))
+	for _, p := range params {
+		res = append(res, &LeafMetadataParam{
+			ExternalId:  p.GetExternalId(),
+			ContentType: p.GetContentType(),
+			Labels:      p.GetLabels(),
+		})
+	}
+
+	return res
+}
+
+func leafMetadataToGrpc(metadata *LeafMetadataJson) *GrpcLeafMetadata {
+	if metadata == nil {
+		return nil
+	}
+
+	return &GrpcLeafMetadata{
+		ExternalId:  metadata.ExternalId,
+		ContentType: metadata.ContentType,
+		Labels:      metadata.Labels,
+		PubKey:      metadata.PubKey,
+		Submitter:   metadata.Submitter,
+		SubmitTime:  metadata.SubmitTime,
+	}
+}
+
+func leafStatusToGrpc(leaf *LeafStatusResult) *GrpcLeafStatus {
+	res := &GrpcLeafStatus{
+		Hash:        leaf.Hash,
+		Stage:       leaf.Stage,
+		TxHash:      leaf.TxHash,
+		BlockHeight: leaf.BlockHeight,
+		Transitions: make([]*GrpcLeafTransition, 0, len(leaf.Transitions)),
+		Algorithm:   leaf.Algorithm,
+	}
+	if leaf.Index != nil {
+		res.Index = *leaf.Index
+	}
+	for _, t := range leaf.Transitions {
+		res.Transitions = append(res.Transitions, &GrpcLeafTransition{Stage: t.Stage, Time: t.Time, TxHash: t.TxHash})
+	}
+
+	return res
+}
+
+func receiptToGrpc(receipt *ReceiptJson) *GrpcReceipt {
+	res := &GrpcReceipt{
+		ReceiptId:   receipt.Id,
+		Contract:    receipt.Contract,
+		TenantId:    receipt.TenantId,
+		Submitter:   receipt.Submitter,
+		LeafCount:   receipt.LeafCount,
+		LeafsDigest: receipt.LeafsDigest,
+		Timestamp:   receipt.Timestamp,
+		TreeSize:    receipt.TreeSize,
+		PubKey:      receipt.PubKey,
+		Signature:   receipt.Signature,
+		Hashes:      receipt.Hashes,
+	}
+	for _, p := range receipt.Promises {
+		res.Promises = append(res.Promises, &GrpcPromise{Hash: p.Leaf, Timestamp: p.Timestamp, Mmd: p.Mmd, Signature: p.Signature})
+	}
+	for _, d := range receipt.Duplicates {
+		res.Duplicates = append(res.Duplicates, leafStatusToGrpc(d))
+	}
+
+	return res
+}
+
+// batchAddReplyToGrpc result of batchAdd and addData. string only for legacy response.
+func batchAddReplyToGrpc(result interface{}) *BatchAddReply {
+	if receipt, ok := result.(*ReceiptJson); ok {
+		return &BatchAddReply{Receipt: receiptToGrpc(receipt)}
+	}
+
+	res, _ := result.(string)
+	return &BatchAddReply{Result: res}
+}
+
+func verifyResultToGrpc(result *VerifyResult) *VerifyReply {
+	res := &VerifyReply{
+		Root:        hex.EncodeToString(result.Root[:]),
+		Size:        result.TreeSize,
+		Blockheight: result.BlockHeight,
+		Index:       result.Index,
+		TxHash:      result.TxHash,
+		LeafHeight:  result.LeafHeight,
+		Proof:       make([]string, 0, len(result.Proof)),
+		Metadata:    leafMetadataToGrpc(result.Metadata),
+	}
+	for i := range result.Proof {
+		res.Proof = append(res.Proof, hex.EncodeToString(result.Proof[i][:]))
+	}
+
+	return res
+}
+
+func batchVerifyToGrpc(result *BatchVerifyResult) *BatchVerifyReply {
+	res := &BatchVerifyReply{
+		Root:        result.Root,
+		Size:        result.TreeSize,
+		Blockheight: result.BlockHeight,
+		Results:     make([]*GrpcLeafVerifyResult, 0, len(result.Results)),
+	}
+	for _, r := range result.Results {
+		leaf := &GrpcLeafVerifyResult{
+			Hash:     r.Hash,
+			Status:   r.Status,
+			Metadata: leafMetadataToGrpc(r.Metadata),
+		}
+		if r.Result != nil {
+			leaf.Result = verifyResultToGrpc(r.Result)
+		}
+		res.Results = append(res.Results, leaf)
+	}
+
+	return res
+}
+
+func findLeavesToGrpc(result *FindLeavesResult) *FindLeavesReply {
+	res := &FindLeavesReply{
+		Leaves: make([]*FoundLeaf, 0, len(result.Leaves)),
+		Next:   result.Next,
+	}
+	for _, leaf := range result.Leaves {
+		found := &FoundLeaf{Hash: leaf.Hash}
+		if m := leaf.LeafMetadataJson; m != nil {
+			found.ExternalId = m.ExternalId
+			found.ContentType = m.ContentType
+			found.Labels = m.Labels
+			found.PubKey = m.PubKey
+			found.Submitter = m.Submitter
+			found.SubmitTime = m.SubmitTime
+		}
+		res.Leaves = append(res.Leaves, found)
+	}
+
+	return res
+}
+
+func rpcParamFromGrpc(req *BatchAddRequest) *RpcParam {
+	return &RpcParam{
+		PubKey:         req.GetPubKey(),
+		Sigature:       req.GetSignature(),
+		Hashes:         req.GetHashes(),
+		Version:        req.GetVersion(),
+		Timestamp:      req.GetTimestamp(),
+		Nonce:          req.GetNonce(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Metadata:       leafMetadataParamsFromGrpc(req.GetMetadata()),
+	}
+}
+
+func dataParamFromGrpc(req *DataRequest) *DataParam {
+	payloads := req.GetData()
+	if payloads == nil {
+		payloads = make([][]byte, 0)
+	}
+
+	return &DataParam{
+		PubKey:         req.GetPubKey(),
+		Signature:      req.GetSignature(),
+		Timestamp:      req.GetTimestamp(),
+		Nonce:          req.GetNonce(),
+		Algorithm:      req.GetAlgorithm(),
+		Root:           req.GetRoot(),
+		TreeSize:       req.GetTreeSize(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Metadata:       leafMetadataParamsFromGrpc(req.GetMetadata()),
+		payloads:       payloads,
+	}
+}
+
+func serverQueryParamFromGrpc(req *SignedQuery) *ServerQueryParam {
+	return &ServerQueryParam{
+		PubKey:    req.GetPubKey(),
+		Signature: req.GetSignature(),
+		Timestamp: req.GetTimestamp(),
+		Nonce:     req.GetNonce(),
+	}
+}
+
+// witnessGrpcServer map the messages of witness.proto to rpc params and results.
+type witnessGrpcServer struct {
+	UnimplementedWitnessServer
+}
+
+func (self *witnessGrpcServer) BatchAdd(ctx context.Context, req *BatchAddRequest) (*BatchAddReply, error) {
+	result, err := grpcCall(ctx, "batchAdd", rpcParamFromGrpc(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return batchAddReplyToGrpc(result), nil
+}
+
+func (self *witnessGrpcServer) Verify(ctx context.Context, req *VerifyRequest) (*VerifyReply, error) {
+	result, err := grpcCall(ctx, "verify", &VerifyParam{
+		PubKey:    req.GetPubKey(),
+		Signature: req.GetSignature(),
+		Timestamp: req.GetTimestamp(),
+		Nonce:     req.GetNonce(),
+		Hashes:    req.GetHashes(),
+		Root:      req.GetRoot(),
+		TreeSize:  req.GetTreeSize(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(VerifyResult)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected verify result")
+	}
+
+	return verifyResultToGrpc(&res), nil
+}
+
+func (self *witnessGrpcServer) AddData(ctx context.Context, req *DataRequest) (*BatchAddReply, error) {
+	result, err := grpcCall(ctx, "addData", dataParamFromGrpc(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return batchAddReplyToGrpc(result), nil
+}
+
+func (self *witnessGrpcServer) VerifyData(ctx context.Context, req *DataRequest) (*BatchVerifyReply, error) {
+	result, err := grpcCall(ctx, "verifyData", dataParamFromGrpc(req))
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*BatchVerifyResult)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected verifyData result")
+	}
+
+	return batchVerifyToGrpc(res), nil
+}
+
+func (self *witnessGrpcServer) FindLeaves(ctx context.Context, req *FindLeavesRequest) (*FindLeavesReply, error) {
+	result, err := grpcCall(ctx, "findLeaves", &FindLeavesParam{
+		PubKey:     req.GetPubKey(),
+		ExternalId: req.GetExternalId(),
+		Label:      req.GetLabel(),
+		Submitter:  req.GetSubmitter(),
+		From:       req.GetFrom(),
+		To:         req.GetTo(),
+		Cursor:     req.GetCursor(),
+		Limit:      req.GetLimit(),
+		Signature:  req.GetSignature(),
+		Timestamp:  req.GetTimestamp(),
+		Nonce:      req.GetNonce(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*FindLeavesResult)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected findLeaves result")
+	}
+
+	return findLeavesToGrpc(res), nil
+}
+
+func (self *witnessGrpcServer) GetRoot(ctx context.Context, req *SignedQuery) (*GrpcRootSize, error) {
+	result, err := grpcCall(ctx, "getRoot", serverQueryParamFromGrpc(req))
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*RootSize)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected getRoot result")
+	}
+
+	return &GrpcRootSize{Root: res.Root, Size: res.Size}, nil
+}
+
+func (self *witnessGrpcServer) GetContractAddress(ctx context.Context, req *SignedQuery) (*ContractAddress, error) {
+	result, err := grpcCall(ctx, "GetContractAddress", serverQueryParamFromGrpc(req))
+	if err != nil {
+		return nil, err
+	}
+
+	res, _ := result.(string)
+	return &ContractAddress{Address: res}, nil
+}
+
+// SubmitHashes each message is a signed batchAdd. duplicates collected not abort the stream.
+func (self *witnessGrpcServer) SubmitHashes(stream Witness_SubmitHashesServer) error {
+	reply := &SubmitHashesReply{
+		Duplicates: make([]string, 0),
+		ReceiptIds: make([]string, 0),
+	}
+
+	method := getRpcMethod("batchAdd")
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(reply)
+		}
+		if err != nil {
+			return err
+		}
+
+		reply.Messages++
+		params := rpcParamFromGrpc(req)
+		response := callRpcMethod(stream.Context(), method, params)
+		errcode, _ := response["error"].(int64)
+		if errcode == DUP_HASH {
+			if dup, ok := response["result"].([]string); ok {
+				reply.Duplicates = append(reply.Duplicates, dup...)
+			}
+			continue
+		}
+		if errcode != SUCCESS {
+			log.Infof("grpc SubmitHashes message %d failed: %v", reply.Messages, response["desc"])
+			return grpcStatusError(response)
+		}
+
+		reply.Accepted += uint32(len(params.Hashes))
+		if receipt, ok := response["result"].(*ReceiptJson); ok {
+			reply.ReceiptIds = append(reply.ReceiptIds, receipt.Id)
+		}
+	}
+}
+
+// WatchAnchors replay the anchored roots from FromSize. then push the new roots until client cancel.
+func (self *witnessGrpcServer) WatchAnchors(req *WatchAnchorsRequest, stream Witness_WatchAnchorsServer) error {
+	params := &WatchAnchorsParam{
+		PubKey:    req.GetPubKey(),
+		Signature: req.GetSignature(),
+		Timestamp: req.GetTimestamp(),
+		Nonce:     req.GetNonce(),
+		FromSize:  req.GetFromSize(),
+	}
+
+	response := checkRpcAuth(stream.Context(), "WatchAnchors", RPC_AUTH_REQUEST, ROLE_VERIFIER|ROLE_AUDITOR, params)
+	if response != nil {
+		return grpcStatusError(response)
+	}
+
+	// subscribe before replay. so no root lost between.
+	id, events := DefAnchorBroker.Subscribe(grpcWatchBufSize)
+	defer DefAnchorBroker.Unsubscribe(id)
+
+	next := params.FromSize
+	for next != 0 {
+		history, err := listRootHistory(DefStore, next, maxRootHistoryLimit)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		for _, root := range history.Roots {
+			err = stream.Send(&AnchorMessage{
+				Root:        root.Root,
+				Size:        root.TreeSize,
+				Blockheight: root.BlockHeight,
+				TxHash:      root.TxHash,
+			})
+			if err != nil {
+				return err
+			}
+			params.FromSize = root.TreeSize + 1
+		}
+		next = history.Next
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "anchor events closed")
+			}
+			if ev.Type != ANCHOR_EVENT_ANCHORED || ev.TreeSize < params.FromSize {
+				continue
+			}
+
+			err := stream.Send(&AnchorMessage{
+				Root:        hex.EncodeToString(ev.Root[:]),
+				Size:        ev.TreeSize,
+				Blockheight: ev.BlockHeight,
+				TxHash:      ev.TxHash.ToHexString(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func StartGrpcServer() error {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(DefConfig.GrpcPort))
+	if err != nil {
+		return fmt.Errorf("grpc Listen error:%s", err)
+	}
+
+	opts := make([]grpc.ServerOption, 0)
+	if DefTlsReloader != nil {
+		opts = append(opts,
+			grpc.Creds(credentials.NewTLS(DefTlsReloader.Config())),
+			grpc.UnaryInterceptor(grpcTlsUnaryInterceptor),
+			grpc.StreamInterceptor(grpcTlsStreamInterceptor),
+		)
+	}
+
+	server := grpc.NewServer(opts...)
+	RegisterWitnessServer(server, &witnessGrpcServer{})
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil {
+			log.Errorf("grpc Serve error:%s", err)
+		}
+	}()
+
+	log.Infof("Grpc init success on %d", DefConfig.GrpcPort)
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestGrpcClient(t *testing.T) (WitnessClient, func()) {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterWitnessServer(server, &witnessGrpcServer{})
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewWitnessClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestGrpcHandlersAuth(t *testing.T) {
+	client, stop := newTestGrpcClient(t)
+	defer stop()
+	ctx := context.Background()
+
+	_, err := client.GetRoot(ctx, &SignedQuery{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetRoot without pubKey %v", err)
+	}
+	_, err = client.Verify(ctx, &VerifyRequest{Hashes: []string{"00"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Verify without pubKey %v", err)
+	}
+
+	submit, err := client.SubmitHashes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	submit.Send(&BatchAddRequest{Hashes: []string{"00"}})
+	_, err = submit.CloseAndRecv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SubmitHashes without pubKey %v", err)
+	}
+
+	watch, err := client.WatchAnchors(ctx, &WatchAnchorsRequest{FromSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = watch.Recv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("WatchAnchors without pubKey %v", err)
+	}
+}
+
+func TestGrpcWatchAnchorsSigned(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore := DefStore
+	defer func() { DefStore = oldStore }()
+	DefStore = store
+	store.NewBatch()
+	putRootInfo(store, common.Uint256{1}, 4, 10, common.Uint256{2})
+	store.BatchCommit()
+
+	acc, pubKey, restore := newTestAccount(ROLE_AUDITOR)
+	defer restore()
+	client, stop := newTestGrpcClient(t)
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// pubKey only not enough.
+	watch, err := client.WatchAnchors(ctx, &WatchAnchorsRequest{PubKey: pubKey, FromSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = watch.Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("WatchAnchors not signed %v", err)
+	}
+
+	params := &WatchAnchorsParam{PubKey: pubKey, Timestamp: time.Now().Unix(), Nonce: "watch", FromSize: 1}
+	req := &WatchAnchorsRequest{
+		PubKey:    pubKey,
+		Signature: signTestRequest(t, acc, "WatchAnchors", params),
+		Timestamp: params.Timestamp,
+		Nonce:     params.Nonce,
+		FromSize:  params.FromSize,
+	}
+	watch, err = client.WatchAnchors(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchor, err := watch.Recv()
+	if err != nil || anchor.Size != 4 || anchor.Blockheight != 10 {
+		t.Fatalf("replay anchor %v %v", anchor, err)
+	}
+
+	req.FromSize = 5
+	watch, err = client.WatchAnchors(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = watch.Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("WatchAnchors signed over other fromSize %v", err)
+	}
+}
+
+func TestGrpcMessages(t *testing.T) {
+	index := uint32(3)
+	receipt := receiptToGrpc(&ReceiptJson{
+		Id:         "id",
+		LeafCount:  2,
+		Promises:   []*PromiseJson{{Leaf: "aa", Timestamp: 10, Mmd: 60, Signature: "sig"}},
+		Duplicates: []*LeafStatusResult{{Hash: "bb", Stage: "anchored", Index: &index, Transitions: []LeafTransitionJson{{Stage: "batched", Time: 1}}}},
+	})
+	if receipt.ReceiptId != "id" || receipt.LeafCount != 2 || receipt.Promises[0].Hash != "aa" || receipt.Promises[0].Mmd != 60 {
+		t.Errorf("receipt %v", receipt)
+	}
+	if dup := receipt.Duplicates[0]; dup.Index != 3 || dup.Transitions[0].Stage != "batched" {
+		t.Errorf("receipt duplicates %v", receipt.Duplicates)
+	}
+
+	if reply := batchAddReplyToGrpc("Cached Success"); reply.Result != "Cached Success" || reply.Receipt != nil {
+		t.Errorf("legacy batchAdd reply %v", reply)
+	}
+
+	root, leaf := common.Uint256{1}, common.Uint256{2}
+	verify := verifyResultToGrpc(&VerifyResult{Root: root, TreeSize: 4, Proof: []common.Uint256{leaf}})
+	if verify.Root != common.ToHexString(root[:]) || verify.Size != 4 || verify.Proof[0] != common.ToHexString(leaf[:]) || verify.Metadata != nil {
+		t.Errorf("verify reply %v", verify)
+	}
+
+	found := findLeavesToGrpc(&FindLeavesResult{
+		Leaves: []*FoundLeafJson{
+			{Hash: "aa", LeafMetadataJson: &LeafMetadataJson{ExternalId: "doc", SubmitTime: 5}},
+			{Hash: "bb"},
+		},
+		Next: "cc",
+	})
+	if len(found.Leaves) != 2 || found.Leaves[0].ExternalId != "doc" || found.Leaves[0].SubmitTime != 5 || found.Leaves[1].Hash != "bb" || found.Next != "cc" {
+		t.Errorf("findLeaves reply %v", found)
+	}
+
+	// bytes of grpc sign the same as base64 of json rpc.
+	payloads := [][]byte{[]byte("a"), []byte("b")}
+	encoded := &DataParam{Algorithm: "sha256"}
+	for _, payload := range payloads {
+		encoded.Data = append(encoded.Data, base64.StdEncoding.EncodeToString(payload))
+	}
+	a, err := encoded.SignData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dataParamFromGrpc(&DataRequest{Algorithm: "sha256", Data: payloads}).SignData()
+	if err != nil || !bytes.Equal(a, b) {
+		t.Errorf("grpc data signed different data %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/common/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/netutil"
+)
+
+// defaults of the rpc http server. seconds.
+const (
+	DEFAULT_READ_HEADER_TIMEOUT uint32 = 10
+	DEFAULT_READ_TIMEOUT        uint32 = 30
+	DEFAULT_WRITE_TIMEOUT       uint32 = 60
+	DEFAULT_IDLE_TIMEOUT        uint32 = 120
+	DEFAULT_MAX_HEADER_BYTES    int    = 1 << 16
+)
+
+var (
+	defaultCorsMethods = []string{"GET", "POST", "OPTIONS"}
+	defaultCorsHeaders = []string{"Content-Type", "X-Witness-PubKey", "X-Witness-Signature", "X-Witness-Timestamp", "X-Witness-Nonce"}
+)
+
+func configSeconds(v uint32, def uint32) time.Duration {
+	if v == 0 {
+		v = def
+	}
+	return time.Duration(v) * time.Second
+}
+
+func maxRequestBodySize() int {
+	if DefConfig.MaxRequestBodySize == 0 {
+		return MAX_REQUEST_BODY_SIZE
+	}
+	return int(DefConfig.MaxRequestBodySize)
+}
+
+// corsAllowOrigin the Access-Control-Allow-Origin for origin. empty if not allowed. any origin if no origins configured.
+func corsAllowOrigin(origin string) string {
+	if len(DefConfig.CorsAllowOrigins) == 0 {
+		return "*"
+	}
+
+	for _, o := range DefConfig.CorsAllowOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if len(origin) != 0 && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
+
+func setCorsHeaders(header http.Header, origin string) {
+	allow := corsAllowOrigin(origin)
+	if len(allow) == 0 {
+		return
+	}
+
+	methods := DefConfig.CorsAllowMethods
+	if len(methods) == 0 {
+		methods = defaultCorsMethods
+	}
+	headers := DefConfig.CorsAllowHeaders
+	if len(headers) == 0 {
+		headers = defaultCorsHeaders
+	}
+
+	header.Set("Access-Control-Allow-Origin", allow)
+	if allow != "*" {
+		header.Add("Vary", "Origin")
+	}
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+}
+
+// CorsHandler set the cors headers of allowed origin. answer preflight without calling next.
+func CorsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCorsHeaders(w.Header(), r.Header.Get("Origin"))
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BodyLimitHandler limit the body of every request. handlers need not check themselves.
+func BodyLimitHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, int64(maxRequestBodySize()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wsCheckOrigin same origins as cors. requests without Origin are not from browser.
+func wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return len(origin) == 0 || len(corsAllowOrigin(origin)) != 0
+}
+
+func newRpcHttpServer(handler http.Handler) *http.Server {
+	maxHeaderBytes := DefConfig.MaxHeaderBytes
+	if maxHeaderBytes == 0 {
+		maxHeaderBytes = DEFAULT_MAX_HEADER_BYTES
+	}
+
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: configSeconds(DefConfig.ReadHeaderTimeout, DEFAULT_READ_HEADER_TIMEOUT),
+		ReadTimeout:       configSeconds(DefConfig.ReadTimeout, DEFAULT_READ_TIMEOUT),
+		WriteTimeout:      configSeconds(DefConfig.WriteTimeout, DEFAULT_WRITE_TIMEOUT),
+		IdleTimeout:       configSeconds(DefConfig.IdleTimeout, DEFAULT_IDLE_TIMEOUT),
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+}
+
+// listenRpc accept at most MaxConnections connections at the same time if set. others wait in backlog.
+func listenRpc(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if DefConfig.MaxConnections > 0 {
+		listener = netutil.LimitListener(listener, DefConfig.MaxConnections)
+	}
+
+	return listener, nil
+}
+
+// newRpcMux routes of the rpc port. /status and /metrics only if PublicStatus and PublicMetrics, else on the admin listener.
+func newRpcMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", RpcHandle)
+	mux.Handle("/v1/", NewRestRouter())
+	mux.HandleFunc("/ws", WsHandle)
+	mux.HandleFunc("/healthz", HealthzHandle)
+	if DefConfig.PublicStatus {
+		mux.HandleFunc("/status", StatusHandle)
+	}
+	if DefConfig.PublicMetrics {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
+	return mux
+}
+
+// newAdminMux routes of the admin listener. not exposed with the rpc port.
+func newAdminMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", HealthzHandle)
+	mux.HandleFunc("/status", StatusHandle)
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// StartAdminServer serve probes, status and metrics on AdminAddr. bind it to the internal network.
+func StartAdminServer() error {
+	listener, err := net.Listen("tcp", DefConfig.AdminAddr)
+	if err != nil {
+		return fmt.Errorf("admin listen error:%s", err)
+	}
+
+	go func() {
+		err := newRpcHttpServer(newAdminMux()).Serve(listener)
+		if err != nil {
+			log.Errorf("admin serve error:%s", err)
+		}
+	}()
+
+	log.Infof("admin server listen on %s", DefConfig.AdminAddr)
+	return nil
+}
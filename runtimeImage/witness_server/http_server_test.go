@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorsHandler(t *testing.T) {
+	DefConfig.CorsAllowOrigins = []string{"https://portal.example.com"}
+	defer func() { DefConfig.CorsAllowOrigins = nil }()
+
+	called := false
+	handler := CorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://portal.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || called {
+		t.Errorf("preflight status %d, called %v", rec.Code, called)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://portal.example.com" {
+		t.Errorf("allow origin %s", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !called || len(rec.Header().Get("Access-Control-Allow-Origin")) != 0 {
+		t.Errorf("not allowed origin called %v, header %s", called, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	DefConfig.CorsAllowOrigins = nil
+	if corsAllowOrigin("https://evil.example.com") != "*" {
+		t.Errorf("default allow origin should be any")
+	}
+}
+
+func TestBodyLimitHandler(t *testing.T) {
+	DefConfig.MaxRequestBodySize = 8
+	defer func() { DefConfig.MaxRequestBodySize = 0 }()
+
+	var readErr error
+	handler := BodyLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = ioutil.ReadAll(r.Body)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", bytes.NewBufferString("12345678")))
+	if readErr != nil {
+		t.Errorf("body in limit: %s", readErr)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", bytes.NewBufferString("123456789")))
+	if readErr == nil {
+		t.Errorf("body over limit read")
+	}
+}
+
+func TestStatusRoutes(t *testing.T) {
+	pattern := func(mux *http.ServeMux, path string) string {
+		_, p := mux.Handler(httptest.NewRequest("GET", path, nil))
+		return p
+	}
+
+	if p := pattern(newRpcMux(), "/status"); p == "/status" {
+		t.Errorf("status served on rpc port")
+	}
+	if p := pattern(newRpcMux(), "/healthz"); p != "/healthz" {
+		t.Errorf("healthz on rpc port routed to %s", p)
+	}
+	if p := pattern(newAdminMux(), "/status"); p != "/status" {
+		t.Errorf("status on admin listener routed to %s", p)
+	}
+
+	rec := httptest.NewRecorder()
+	newAdminMux().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("healthz on admin listener %d", rec.Code)
+	}
+
+	DefConfig.PublicStatus = true
+	defer func() { DefConfig.PublicStatus = false }()
+	if p := pattern(newRpcMux(), "/status"); p != "/status" {
+		t.Errorf("public status routed to %s", p)
+	}
+
+	if method := getRpcMethod("getServerStatus"); method.Auth != RPC_AUTH_REQUEST {
+		t.Errorf("getServerStatus auth %s", rpcAuthName[method.Auth])
+	}
+}
+
+func TestMetricsRoutes(t *testing.T) {
+	pattern := func(mux *http.ServeMux, path string) string {
+		_, p := mux.Handler(httptest.NewRequest("GET", path, nil))
+		return p
+	}
+
+	if p := pattern(newRpcMux(), "/metrics"); p == "/metrics" {
+		t.Errorf("metrics served on rpc port")
+	}
+
+	rec := httptest.NewRecorder()
+	newAdminMux().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte("witness_")) {
+		t.Errorf("metrics on admin listener %d", rec.Code)
+	}
+
+	DefConfig.PublicMetrics = true
+	defer func() { DefConfig.PublicMetrics = false }()
+	if p := pattern(newRpcMux(), "/metrics"); p != "/metrics" {
+		t.Errorf("public metrics routed to %s", p)
+	}
+}
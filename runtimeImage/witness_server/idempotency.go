@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+const (
+	// seconds the batchAdd response kept for retry with the same idempotency key.
+	DEFAULT_IDEMPOTENCY_KEY_TTL uint32 = 86400
+	MAX_IDEMPOTENCY_KEY_LENGTH         = 64
+)
+
+var (
+	idempotencyKeysLock     sync.Mutex
+	idempotencyKeysInFlight = make(map[string]bool)
+)
+
+func idempotencyKeyTtl() uint32 {
+	if DefConfig.IdempotencyKeyTtl == 0 {
+		return DEFAULT_IDEMPOTENCY_KEY_TTL
+	}
+	return DefConfig.IdempotencyKeyTtl
+}
+
+// IdempotencyRecord the batchAdd response of key. Expire first so expired with the nonces.
+type IdempotencyRecord struct {
+	Expire   uint64
+	Digest   common.Uint256
+	Response []byte
+}
+
+func (self *IdempotencyRecord) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(self.Expire)
+	sink.WriteHash(self.Digest)
+	sink.WriteVarBytes(self.Response)
+}
+
+func (self *IdempotencyRecord) Deserialization(source *common.ZeroCopySource) error {
+	expire, eof := source.NextUint64()
+	digest, eof := source.NextHash()
+	response, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.Expire = expire
+	self.Digest = digest
+	self.Response = response
+	return nil
+}
+
+func getIdempotencyKey(address common.Address, key string) []byte {
+	res := make([]byte, 0, 1+common.ADDR_LEN+len(key))
+	res = append(res, byte(PREFIX_IDEMPOTENCY_KEY))
+	res = append(res, address[:]...)
+	return append(res, []byte(key)...)
+}
+
+func checkIdempotencyKey(key string) error {
+	if len(key) > MAX_IDEMPOTENCY_KEY_LENGTH {
+		return fmt.Errorf("idempotency key most %d", MAX_IDEMPOTENCY_KEY_LENGTH)
+	}
+	return nil
+}
+
+// lockIdempotencyKey one request of the key at the same time. so the response recorded before retry handled.
+func lockIdempotencyKey(address common.Address, key string) (func(), error) {
+	k := string(getIdempotencyKey(address, key))
+
+	idempotencyKeysLock.Lock()
+	defer idempotencyKeysLock.Unlock()
+	if idempotencyKeysInFlight[k] {
+		return nil, errors.New("request of idempotency key in progress")
+	}
+	idempotencyKeysInFlight[k] = true
+
+	return func() {
+		idempotencyKeysLock.Lock()
+		delete(idempotencyKeysInFlight, k)
+		idempotencyKeysLock.Unlock()
+	}, nil
+}
+
+func getIdempotencyRecord(store *leveldbstore.LevelDBStore, address common.Address, key string) (*IdempotencyRecord, error) {
+	raw, err := store.Get(getIdempotencyKey(address, key))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &IdempotencyRecord{}
+	err = record.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil {
+		return nil, err
+	}
+	if record.Expire < uint64(time.Now().Unix()) {
+		return nil, errors.New("idempotency key expired")
+	}
+
+	return record, nil
+}
+
+func hasIdempotencyRecord(store *leveldbstore.LevelDBStore, address common.Address, key string) bool {
+	_, err := getIdempotencyRecord(store, address, key)
+	return err == nil
+}
+
+// idempotencyDigest digest of hashes. and of metadata if set. so key reused with other metadata not answered by the recorded response.
+func idempotencyDigest(hashes []common.Uint256, params []*LeafMetadataParam) common.Uint256 {
+	digest := leafsDigest(hashes)
+	if len(params) == 0 {
+		return digest
+	}
+
+	metadataDigest := leafMetadataDigest(params)
+	return sha256.Sum256(append(digest[:], metadataDigest[:]...))
+}
+
+// getIdempotentResponse the recorded response of key. nil if not recorded. error if key used by other hashes or metadata.
+func getIdempotentResponse(store *leveldbstore.LevelDBStore, address common.Address, key string, digest common.Uint256) (*ReceiptJson, error) {
+	record, err := getIdempotencyRecord(store, address, key)
+	if err != nil {
+		return nil, nil
+	}
+	if record.Digest != digest {
+		return nil, errors.New("idempotency key already used by other hashes or metadata")
+	}
+
+	res := &ReceiptJson{}
+	err = json.Unmarshal(record.Response, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// putIdempotentResponse after leafs committed. if lost by crash the retry handled again. duplicates of same key success in idempotent batchAdd.
+func putIdempotentResponse(store *leveldbstore.LevelDBStore, address common.Address, key string, digest common.Uint256, res *ReceiptJson) error {
+	response, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	record := &IdempotencyRecord{
+		Expire:   uint64(time.Now().Unix()) + uint64(idempotencyKeyTtl()),
+		Digest:   digest,
+		Response: response,
+	}
+	sink := common.NewZeroCopySink(nil)
+	record.Serialization(sink)
+	return store.Put(getIdempotencyKey(address, key), sink.Bytes())
+}
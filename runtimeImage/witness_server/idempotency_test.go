@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/sha256"
+	"math"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestSplitDuplicateLeafs(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitter, other := common.AddressFromVmCode([]byte("submitter")), common.AddressFromVmCode([]byte("other"))
+	mine, others, fresh := sha256.Sum256([]byte("mine")), sha256.Sum256([]byte("others")), sha256.Sum256([]byte("new"))
+
+	store.NewBatch()
+	putLeafIndex(store, mine, math.MaxUint32, 0, common.UINT256_EMPTY.ToHexString())
+	putLeafSubmitter(store, mine, submitter)
+	putLeafIndex(store, others, math.MaxUint32, 0, common.UINT256_EMPTY.ToHexString())
+	putLeafSubmitter(store, others, other)
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, dup, err := splitDuplicateLeafs(store, []common.Uint256{fresh, mine}, submitter)
+	if err == nil || len(dup) != 1 {
+		t.Errorf("duplicate accepted without idempotent batchAdd %v", dup)
+	}
+
+	DefConfig.IdempotentBatchAdd = true
+	defer func() { DefConfig.IdempotentBatchAdd = false }()
+
+	newLeafs, dup, err := splitDuplicateLeafs(store, []common.Uint256{fresh, mine, fresh}, submitter)
+	if err != nil || len(newLeafs) != 1 || newLeafs[0] != fresh || len(dup) != 1 {
+		t.Errorf("idempotent split %v %v %v", newLeafs, dup, err)
+	}
+
+	_, dup, err = splitDuplicateLeafs(store, []common.Uint256{fresh, mine, others}, submitter)
+	if err == nil || len(dup) != 1 || dup[0] != common.ToHexString(others[:]) {
+		t.Errorf("duplicate of other submitter accepted %v", dup)
+	}
+}
+
+func TestIdempotentResponse(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := common.AddressFromVmCode([]byte("submitter"))
+	digest := leafsDigest([]common.Uint256{sha256.Sum256([]byte("a"))})
+
+	res, err := getIdempotentResponse(store, address, "key", digest)
+	if res != nil || err != nil {
+		t.Fatalf("response of unknown key %v %v", res, err)
+	}
+
+	err = putIdempotentResponse(store, address, "key", digest, &ReceiptJson{Id: "receipt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = getIdempotentResponse(store, address, "key", digest)
+	if err != nil || res == nil || res.Id != "receipt" {
+		t.Errorf("recorded response %v %v", res, err)
+	}
+	if _, err = getIdempotentResponse(store, address, "key", common.UINT256_EMPTY); err == nil {
+		t.Errorf("key reused by other hashes")
+	}
+
+	hashes := []common.Uint256{sha256.Sum256([]byte("a"))}
+	if idempotencyDigest(hashes, nil) != digest {
+		t.Errorf("digest without metadata changed")
+	}
+	metadata := idempotencyDigest(hashes, []*LeafMetadataParam{{ExternalId: "doc-1"}})
+	if _, err = getIdempotentResponse(store, address, "key", metadata); err == nil {
+		t.Errorf("key reused by other metadata")
+	}
+	err = putIdempotentResponse(store, address, "other", metadata, &ReceiptJson{Id: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getIdempotentResponse(store, address, "other", idempotencyDigest(hashes, []*LeafMetadataParam{{ExternalId: "doc-2"}})); err == nil {
+		t.Errorf("key reused by other metadata")
+	}
+	if res, err = getIdempotentResponse(store, address, "other", metadata); err != nil || res == nil || res.Id != "other" {
+		t.Errorf("recorded response of metadata %v %v", res, err)
+	}
+
+	unlock, err := lockIdempotencyKey(address, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lockIdempotencyKey(address, "key"); err == nil {
+		t.Errorf("key locked twice")
+	}
+	unlock()
+	if unlock, err = lockIdempotencyKey(address, "key"); err != nil {
+		t.Errorf("key not unlocked %v", err)
+	} else {
+		unlock()
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+const (
+	MAX_EXTERNAL_ID_LENGTH  = 128
+	MAX_CONTENT_TYPE_LENGTH = 128
+	MAX_LABEL_LENGTH        = 64
+	MAX_LABELS              = 16
+
+	defaultFindLeavesLimit uint32 = 20
+	maxFindLeavesLimit     uint32 = 100
+)
+
+// kind of the leaf metadata index. key is kind, value, big endian submit time and leaf. so each kind paged in time order.
+const (
+	METADATA_INDEX_TIME        byte = 0
+	METADATA_INDEX_SUBMITTER   byte = 1
+	METADATA_INDEX_EXTERNAL_ID byte = 2
+	METADATA_INDEX_LABEL       byte = 3
+)
+
+// LeafMetadataParam metadata of one hash given by submitter. all optional.
+type LeafMetadataParam struct {
+	ExternalId  string   `json:"externalId,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+func (self *LeafMetadataParam) check() error {
+	if len(self.ExternalId) > MAX_EXTERNAL_ID_LENGTH {
+		return fmt.Errorf("externalId most %d", MAX_EXTERNAL_ID_LENGTH)
+	}
+	if len(self.ContentType) > MAX_CONTENT_TYPE_LENGTH {
+		return fmt.Errorf("contentType most %d", MAX_CONTENT_TYPE_LENGTH)
+	}
+	if len(self.Labels) > MAX_LABELS {
+		return fmt.Errorf("labels most %d", MAX_LABELS)
+	}
+	for _, label := range self.Labels {
+		if len(label) == 0 || len(label) > MAX_LABEL_LENGTH {
+			return fmt.Errorf("label should not empty and most %d", MAX_LABEL_LENGTH)
+		}
+	}
+	return nil
+}
+
+func (self *LeafMetadataParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(self.ExternalId)
+	sink.WriteString(self.ContentType)
+	sink.WriteVarUint(uint64(len(self.Labels)))
+	for _, label := range self.Labels {
+		sink.WriteString(label)
+	}
+}
+
+func (self *LeafMetadataParam) Deserialization(source *common.ZeroCopySource) error {
+	externalId, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+	contentType, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof || num > MAX_LABELS {
+		return io.ErrUnexpectedEOF
+	}
+	labels := make([]string, 0, num)
+	for i := uint64(0); i < num; i++ {
+		label, _, irregular, eof := source.NextString()
+		if irregular || eof {
+			return io.ErrUnexpectedEOF
+		}
+		labels = append(labels, label)
+	}
+
+	self.ExternalId = externalId
+	self.ContentType = contentType
+	if len(labels) != 0 {
+		self.Labels = labels
+	}
+	return nil
+}
+
+// checkLeafMetadataParams none or one of each hash.
+func checkLeafMetadataParams(hashes int, params []*LeafMetadataParam) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if len(params) != hashes {
+		return errors.New("metadata should be one of each hash")
+	}
+	for i, param := range params {
+		if param == nil {
+			return fmt.Errorf("metadata %d empty", i)
+		}
+		err := param.check()
+		if err != nil {
+			return fmt.Errorf("metadata %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func serializeLeafMetadataParams(params []*LeafMetadataParam) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(params)))
+	for _, param := range params {
+		param.Serialization(sink)
+	}
+	return sink.Bytes()
+}
+
+// leafMetadataDigest signed in the request instead of the metadata.
+func leafMetadataDigest(params []*LeafMetadataParam) [sha256.Size]byte {
+	return sha256.Sum256(serializeLeafMetadataParams(params))
+}
+
+func deserializeLeafMetadataParams(raw []byte) ([]*LeafMetadataParam, error) {
+	source := common.NewZeroCopySource(raw)
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof || num > uint64(len(raw)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	params := make([]*LeafMetadataParam, 0, num)
+	for i := uint64(0); i < num; i++ {
+		param := &LeafMetadataParam{}
+		err := param.Deserialization(source)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// LeafMetadata recorded for each new leaf. PubKey and SubmitTime from the request and receipt.
+type LeafMetadata struct {
+	LeafMetadataParam
+	PubKey     string
+	Submitter  common.Address
+	SubmitTime uint64
+}
+
+func (self *LeafMetadata) Serialization(sink *common.ZeroCopySink) {
+	self.LeafMetadataParam.Serialization(sink)
+	sink.WriteString(self.PubKey)
+	sink.WriteAddress(self.Submitter)
+	sink.WriteUint64(self.SubmitTime)
+}
+
+func (self *LeafMetadata) Deserialization(source *common.ZeroCopySource) error {
+	err := self.LeafMetadataParam.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	pubKey, _, irregular, eof := source.NextString()
+	submitter, eof := source.NextAddress()
+	submitTime, eof := source.NextUint64()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.PubKey = pubKey
+	self.Submitter = submitter
+	self.SubmitTime = submitTime
+	return nil
+}
+
+type LeafMetadataJson struct {
+	ExternalId  string   `json:"externalId,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	PubKey      string   `json:"pubKey"`
+	Submitter   string   `json:"submitter"`
+	SubmitTime  uint64   `json:"submitTime"`
+}
+
+func (self *LeafMetadata) toJson() *LeafMetadataJson {
+	return &LeafMetadataJson{
+		ExternalId:  self.ExternalId,
+		ContentType: self.ContentType,
+		Labels:      self.Labels,
+		PubKey:      self.PubKey,
+		Submitter:   self.Submitter.ToBase58(),
+		SubmitTime:  self.SubmitTime,
+	}
+}
+
+// newLeafMetadata of each hash. params empty if not given.
+func newLeafMetadata(pubKey string, submitter common.Address, params []*LeafMetadataParam, hashes []common.Uint256, submitTime uint64) []*LeafMetadata {
+	res := make([]*LeafMetadata, 0, len(hashes))
+	for i := range hashes {
+		metadata := &LeafMetadata{
+			PubKey:     pubKey,
+			Submitter:  submitter,
+			SubmitTime: submitTime,
+		}
+		if i < len(params) {
+			metadata.LeafMetadataParam = *params[i]
+		}
+		res = append(res, metadata)
+	}
+	return res
+}
+
+func metadataIndexPrefix(kind byte, value []byte) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(PREFIX_LEAF_METADATA_INDEX))
+	sink.WriteByte(kind)
+	switch kind {
+	case METADATA_INDEX_TIME:
+	case METADATA_INDEX_SUBMITTER:
+		sink.WriteBytes(value)
+	default:
+		// length first. so one value not the prefix of another.
+		sink.WriteVarBytes(value)
+	}
+	return sink.Bytes()
+}
+
+func metadataIndexKey(prefix []byte, submitTime uint64, leaf common.Uint256) []byte {
+	key := make([]byte, len(prefix)+8+common.UINT256_SIZE)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], submitTime)
+	copy(key[len(prefix)+8:], leaf[:])
+	return key
+}
+
+// metadataIndexKeys all index keys of the leaf.
+func metadataIndexKeys(leaf common.Uint256, metadata *LeafMetadata) [][]byte {
+	keys := make([][]byte, 0, 3+len(metadata.Labels))
+	keys = append(keys, metadataIndexKey(metadataIndexPrefix(METADATA_INDEX_TIME, nil), metadata.SubmitTime, leaf))
+	keys = append(keys, metadataIndexKey(metadataIndexPrefix(METADATA_INDEX_SUBMITTER, metadata.Submitter[:]), metadata.SubmitTime, leaf))
+	if len(metadata.ExternalId) != 0 {
+		keys = append(keys, metadataIndexKey(metadataIndexPrefix(METADATA_INDEX_EXTERNAL_ID, []byte(metadata.ExternalId)), metadata.SubmitTime, leaf))
+	}
+	for _, label := range metadata.Labels {
+		keys = append(keys, metadataIndexKey(metadataIndexPrefix(METADATA_INDEX_LABEL, []byte(label)), metadata.SubmitTime, leaf))
+	}
+	return keys
+}
+
+// putLeafMetadata to the store batch of batchAdd with the index of each kind.
+func putLeafMetadata(store *leveldbstore.LevelDBStore, leaf common.Uint256, metadata *LeafMetadata) {
+	sink := common.NewZeroCopySink(nil)
+	metadata.Serialization(sink)
+	store.BatchPut(GetKeyByHash(PREFIX_LEAF_METADATA, leaf), sink.Bytes())
+
+	for _, key := range metadataIndexKeys(leaf, metadata) {
+		store.BatchPut(key, []byte{})
+	}
+}
+
+func getLeafMetadata(store *leveldbstore.LevelDBStore, leaf common.Uint256) (*LeafMetadata, error) {
+	raw, err := store.Get(GetKeyByHash(PREFIX_LEAF_METADATA, leaf))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &LeafMetadata{}
+	err = metadata.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// leafMetadataJson nil if leaf added before metadata recorded.
+func leafMetadataJson(store *leveldbstore.LevelDBStore, leaf common.Uint256) *LeafMetadataJson {
+	metadata, err := getLeafMetadata(store, leaf)
+	if err != nil {
+		return nil
+	}
+	return metadata.toJson()
+}
+
+// not BatchDelete. same as delLeafSubmitter
+func delLeafMetadata(store *leveldbstore.LevelDBStore, leaf common.Uint256) {
+	metadata, err := getLeafMetadata(store, leaf)
+	if err != nil {
+		return
+	}
+
+	for _, key := range metadataIndexKeys(leaf, metadata) {
+		store.Delete(key)
+	}
+	store.Delete(GetKeyByHash(PREFIX_LEAF_METADATA, leaf))
+}
+
+// FindLeavesParam at most one of ExternalId, Label and Submitter. time range From to To of submit time, To zero if no end.
+type FindLeavesParam struct {
+	PubKey     string `json:"pubKey"`
+	Signature  string `json:"signature"`
+	Timestamp  int64  `json:"timestamp"`
+	Nonce      string `json:"nonce"`
+	ExternalId string `json:"externalId,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Submitter  string `json:"submitter,omitempty"`
+	From       uint64 `json:"from,omitempty"`
+	To         uint64 `json:"to,omitempty"`
+	// next of the last page.
+	Cursor string `json:"cursor,omitempty"`
+	Limit  uint32 `json:"limit,omitempty"`
+}
+
+func (self *FindLeavesParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *FindLeavesParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *FindLeavesParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *FindLeavesParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of the query and page.
+func (self *FindLeavesParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.ExternalId)
+	sink.WriteString(self.Label)
+	sink.WriteString(self.Submitter)
+	sink.WriteUint64(self.From)
+	sink.WriteUint64(self.To)
+	sink.WriteString(self.Cursor)
+	sink.WriteUint32(self.Limit)
+	return sink.Bytes(), nil
+}
+
+// indexPrefix of the kind queried.
+func (self *FindLeavesParam) indexPrefix() ([]byte, error) {
+	kinds := 0
+	prefix := metadataIndexPrefix(METADATA_INDEX_TIME, nil)
+	if len(self.ExternalId) != 0 {
+		kinds++
+		prefix = metadataIndexPrefix(METADATA_INDEX_EXTERNAL_ID, []byte(self.ExternalId))
+	}
+	if len(self.Label) != 0 {
+		kinds++
+		prefix = metadataIndexPrefix(METADATA_INDEX_LABEL, []byte(self.Label))
+	}
+	if len(self.Submitter) != 0 {
+		kinds++
+		submitter, err := common.AddressFromBase58(self.Submitter)
+		if err != nil {
+			return nil, fmt.Errorf("submitter should be base58 address: %s", err)
+		}
+		prefix = metadataIndexPrefix(METADATA_INDEX_SUBMITTER, submitter[:])
+	}
+	if kinds > 1 {
+		return nil, errors.New("at most one of externalId, label and submitter")
+	}
+	return prefix, nil
+}
+
+type FoundLeafJson struct {
+	Hash string `json:"hash"`
+	*LeafMetadataJson
+}
+
+type FindLeavesResult struct {
+	Leaves []*FoundLeafJson `json:"leaves"`
+	// cursor of the next page. empty if no more.
+	Next string `json:"next"`
+}
+
+// findLeaves in submit time order from the cursor. cursor is the hex of submit time and leaf. only leafs submitted by owner if not nil.
+func findLeaves(store *leveldbstore.LevelDBStore, prefix []byte, from uint64, to uint64, cursor []byte, limit uint32, owner *common.Address) (*FindLeavesResult, error) {
+	res := &FindLeavesResult{
+		Leaves: make([]*FoundLeafJson, 0, limit),
+	}
+
+	start := metadataIndexKey(prefix, from, common.UINT256_EMPTY)
+	if len(cursor) != 0 {
+		start = append(append([]byte{}, prefix...), cursor...)
+	}
+
+	iter := store.NewIterator(prefix)
+	defer iter.Release()
+
+	for ok := iter.Seek(start); ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+8+common.UINT256_SIZE {
+			continue
+		}
+		submitTime := binary.BigEndian.Uint64(key[len(prefix):])
+		if to != 0 && submitTime > to {
+			break
+		}
+		if uint32(len(res.Leaves)) == limit {
+			res.Next = hex.EncodeToString(key[len(prefix):])
+			break
+		}
+
+		var leaf common.Uint256
+		copy(leaf[:], key[len(prefix)+8:])
+		metadata, err := getLeafMetadata(store, leaf)
+		if owner != nil && (err != nil || metadata.Submitter != *owner) {
+			continue
+		}
+		var metadataJson *LeafMetadataJson
+		if err == nil {
+			metadataJson = metadata.toJson()
+		}
+		res.Leaves = append(res.Leaves, &FoundLeafJson{
+			Hash:             hex.EncodeToString(leaf[:]),
+			LeafMetadataJson: metadataJson,
+		})
+	}
+
+	return res, iter.Error()
+}
+
+// signed request checked by rpc dispatch. own leafs only unless auditor.
+func rpcFindLeaves(fargs *FindLeavesParam) map[string]interface{} {
+	limit := fargs.Limit
+	if limit == 0 {
+		limit = defaultFindLeavesLimit
+	}
+	if limit > maxFindLeavesLimit {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("limit most %d", maxFindLeavesLimit))
+	}
+	if fargs.To != 0 && fargs.To < fargs.From {
+		return responsePack(INVALID_PARAM, "to should not less than from")
+	}
+
+	var owner *common.Address
+	caller := addressOfPubKey(fargs.PubKey)
+	if !checkRoleOfAddress(caller, ROLE_AUDITOR) {
+		if len(fargs.Submitter) != 0 && fargs.Submitter != caller.ToBase58() {
+			return responsePack(NO_AUTH, "leafs of other submitter only for auditor.")
+		}
+		if len(fargs.ExternalId) == 0 && len(fargs.Label) == 0 {
+			fargs.Submitter = caller.ToBase58()
+		}
+		owner = &caller
+	}
+
+	prefix, err := fargs.indexPrefix()
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	var cursor []byte
+	if len(fargs.Cursor) != 0 {
+		cursor, err = hex.DecodeString(fargs.Cursor)
+		if err != nil || len(cursor) != 8+common.UINT256_SIZE {
+			return responsePack(INVALID_PARAM, "invalid cursor")
+		}
+	}
+
+	res, err := findLeaves(DefStore, prefix, fargs.From, fargs.To, cursor, limit, owner)
+	if err != nil {
+		log.Errorf("findLeaves: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "findLeaves",
+		Desc:       "find the leafs by externalId, label or submitter and submit time range from to. paged by cursor. own leafs only unless auditor.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_SUBMITTER | ROLE_VERIFIER | ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &FindLeavesParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcFindLeaves(params.(*FindLeavesParam))
+		},
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestFindLeaves(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	submitter, other := common.AddressFromVmCode([]byte("submitter")), common.AddressFromVmCode([]byte("other"))
+	a, b, c := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("c"))
+
+	store.NewBatch()
+	putLeafMetadata(store, a, &LeafMetadata{
+		LeafMetadataParam: LeafMetadataParam{ExternalId: "doc-1", Labels: []string{"invoice"}},
+		Submitter:         submitter,
+		SubmitTime:        100,
+	})
+	putLeafMetadata(store, b, &LeafMetadata{
+		LeafMetadataParam: LeafMetadataParam{ExternalId: "doc-10", Labels: []string{"invoice", "2024"}},
+		Submitter:         submitter,
+		SubmitTime:        200,
+	})
+	putLeafMetadata(store, c, &LeafMetadata{Submitter: other, SubmitTime: 300})
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// id not matched by the prefix of another.
+	res, err := findLeaves(store, metadataIndexPrefix(METADATA_INDEX_EXTERNAL_ID, []byte("doc-1")), 0, 0, nil, 10, nil)
+	if err != nil || len(res.Leaves) != 1 || res.Leaves[0].Hash != common.ToHexString(a[:]) {
+		t.Errorf("find by externalId %+v %v", res, err)
+	}
+
+	prefix := metadataIndexPrefix(METADATA_INDEX_LABEL, []byte("invoice"))
+	res, err = findLeaves(store, prefix, 0, 0, nil, 1, nil)
+	if err != nil || len(res.Leaves) != 1 || len(res.Next) == 0 || res.Leaves[0].SubmitTime != 100 {
+		t.Fatalf("first page of label %+v %v", res, err)
+	}
+	cursor, _ := common.HexToBytes(res.Next)
+	res, err = findLeaves(store, prefix, 0, 0, cursor, 1, nil)
+	if err != nil || len(res.Leaves) != 1 || len(res.Next) != 0 || res.Leaves[0].ExternalId != "doc-10" {
+		t.Errorf("next page of label %+v %v", res, err)
+	}
+
+	res, err = findLeaves(store, metadataIndexPrefix(METADATA_INDEX_SUBMITTER, submitter[:]), 150, 0, nil, 10, nil)
+	if err != nil || len(res.Leaves) != 1 || res.Leaves[0].Hash != common.ToHexString(b[:]) {
+		t.Errorf("find by submitter from time %+v %v", res, err)
+	}
+
+	res, err = findLeaves(store, metadataIndexPrefix(METADATA_INDEX_TIME, nil), 100, 200, nil, 10, nil)
+	if err != nil || len(res.Leaves) != 2 {
+		t.Errorf("find by time range %+v %v", res, err)
+	}
+
+	delLeafMetadata(store, b)
+	res, err = findLeaves(store, prefix, 0, 0, nil, 10, nil)
+	if err != nil || len(res.Leaves) != 1 {
+		t.Errorf("index of deleted metadata left %+v %v", res, err)
+	}
+}
+
+func TestFindLeavesOwn(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore := DefStore
+	defer func() { DefStore = oldStore }()
+	DefStore = store
+
+	acc, pubKey, restore := newTestAccount(ROLE_VERIFIER)
+	defer restore()
+	other := common.AddressFromVmCode([]byte("other"))
+	a, b := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b"))
+
+	store.NewBatch()
+	putLeafMetadata(store, a, &LeafMetadata{LeafMetadataParam: LeafMetadataParam{Labels: []string{"invoice"}}, Submitter: acc.Address, SubmitTime: 100})
+	putLeafMetadata(store, b, &LeafMetadata{LeafMetadataParam: LeafMetadataParam{Labels: []string{"invoice"}}, Submitter: other, SubmitTime: 200})
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	found := func(res map[string]interface{}) []*FoundLeafJson {
+		if res["error"] != SUCCESS {
+			t.Fatalf("findLeaves %v", res)
+		}
+		return res["result"].(*FindLeavesResult).Leaves
+	}
+
+	for _, param := range []*FindLeavesParam{{PubKey: pubKey}, {PubKey: pubKey, Label: "invoice"}, {PubKey: pubKey, Submitter: acc.Address.ToBase58()}} {
+		leaves := found(rpcFindLeaves(param))
+		if len(leaves) != 1 || leaves[0].Hash != common.ToHexString(a[:]) {
+			t.Errorf("own leafs of %+v: %+v", param, leaves)
+		}
+	}
+
+	if res := rpcFindLeaves(&FindLeavesParam{PubKey: pubKey, Submitter: other.ToBase58()}); res["error"] != NO_AUTH {
+		t.Errorf("leafs of other submitter %v", res)
+	}
+
+	authorizedKeysLock.Lock()
+	authorizedKeys[acc.Address].Roles = ROLE_AUDITOR
+	authorizedKeysLock.Unlock()
+	if leaves := found(rpcFindLeaves(&FindLeavesParam{PubKey: pubKey, Label: "invoice"})); len(leaves) != 2 {
+		t.Errorf("auditor found %d leafs, expect 2", len(leaves))
+	}
+	if leaves := found(rpcFindLeaves(&FindLeavesParam{PubKey: pubKey, Submitter: other.ToBase58()})); len(leaves) != 1 {
+		t.Errorf("auditor found %d leafs of other, expect 1", len(leaves))
+	}
+}
+
+func TestFindLeavesSubmitter(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore := DefStore
+	defer func() { DefStore = oldStore }()
+	DefStore = store
+
+	acc, pubKey, restore := newTestAccount(ROLE_SUBMITTER)
+	defer restore()
+	own, other := sha256.Sum256([]byte("own")), sha256.Sum256([]byte("other"))
+	store.NewBatch()
+	putLeafMetadata(store, own, &LeafMetadata{LeafMetadataParam: LeafMetadataParam{ExternalId: "doc"}, Submitter: acc.Address, SubmitTime: 100})
+	putLeafMetadata(store, other, &LeafMetadata{LeafMetadataParam: LeafMetadataParam{ExternalId: "doc"}, Submitter: common.AddressFromVmCode([]byte("other")), SubmitTime: 200})
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	param := &FindLeavesParam{PubKey: pubKey, Timestamp: time.Now().Unix(), Nonce: "submitter", ExternalId: "doc"}
+	param.Signature = signTestRequest(t, acc, "findLeaves", param)
+	method := getRpcMethod("findLeaves")
+	res := callRpcMethod(context.Background(), method, param)
+	if res["error"] != SUCCESS {
+		t.Fatalf("findLeaves of submitter %v", res)
+	}
+	leaves := res["result"].(*FindLeavesResult).Leaves
+	if len(leaves) != 1 || leaves[0].Hash != common.ToHexString(own[:]) {
+		t.Errorf("submitter found %+v, expect own leaf only", leaves)
+	}
+}
+
+func TestLeafMetadataParams(t *testing.T) {
+	params := []*LeafMetadataParam{
+		{ExternalId: "doc", ContentType: "application/pdf", Labels: []string{"a", "b"}},
+		{},
+	}
+	if err := checkLeafMetadataParams(1, params); err == nil {
+		t.Errorf("metadata not one of each hash accepted")
+	}
+	if err := checkLeafMetadataParams(2, []*LeafMetadataParam{params[0], {Labels: []string{""}}}); err == nil {
+		t.Errorf("empty label accepted")
+	}
+
+	res, err := deserializeLeafMetadataParams(serializeLeafMetadataParams(params))
+	if err != nil || len(res) != 2 || res[0].ContentType != "application/pdf" || len(res[0].Labels) != 2 || res[1].Labels != nil {
+		t.Errorf("metadata decoded %+v %v", res, err)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/merkle"
+)
+
+type LeafStage byte
+
+const (
+	LEAF_STAGE_UNKNOWN   LeafStage = 0
+	LEAF_STAGE_QUEUED    LeafStage = 1
+	LEAF_STAGE_BATCHED   LeafStage = 2
+	LEAF_STAGE_SUBMITTED LeafStage = 3
+	LEAF_STAGE_ANCHORED  LeafStage = 4
+	LEAF_STAGE_FAILED    LeafStage = 5
+)
+
+var leafStageName = map[LeafStage]string{
+	LEAF_STAGE_UNKNOWN:   "unknown",
+	LEAF_STAGE_QUEUED:    "queued",
+	LEAF_STAGE_BATCHED:   "batched",
+	LEAF_STAGE_SUBMITTED: "submitted",
+	LEAF_STAGE_ANCHORED:  "anchored",
+	LEAF_STAGE_FAILED:    "failed",
+}
+
+// keep only the latest transitions. a leaf may fail many times.
+const maxLeafTransitions = 32
+
+type LeafTransition struct {
+	Stage  LeafStage
+	Time   uint64
+	TxHash common.Uint256
+}
+
+type LeafStatus struct {
+	Stage       LeafStage
+	TxHash      common.Uint256
+	BlockHeight uint32
+	Transitions []LeafTransition
+}
+
+func (self *LeafStatus) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteByte(byte(self.Stage))
+	sink.WriteHash(self.TxHash)
+	sink.WriteUint32(self.BlockHeight)
+	sink.WriteVarUint(uint64(len(self.Transitions)))
+	for _, t := range self.Transitions {
+		sink.WriteByte(byte(t.Stage))
+		sink.WriteUint64(t.Time)
+		sink.WriteHash(t.TxHash)
+	}
+}
+
+func (self *LeafStatus) Deserialization(source *common.ZeroCopySource) error {
+	stage, eof := source.NextByte()
+	txHash, eof := source.NextHash()
+	height, eof := source.NextUint32()
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	transitions := make([]LeafTransition, 0, num)
+	for i := uint64(0); i < num; i++ {
+		tstage, eof := source.NextByte()
+		ttime, eof := source.NextUint64()
+		thash, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		transitions = append(transitions, LeafTransition{Stage: LeafStage(tstage), Time: ttime, TxHash: thash})
+	}
+
+	self.Stage = LeafStage(stage)
+	self.TxHash = txHash
+	self.BlockHeight = height
+	self.Transitions = transitions
+	return nil
+}
+
+// apply anchored is terminal. never changed by late submitted or failed of other tx.
+func (self *LeafStatus) apply(t LeafTransition, height uint32) bool {
+	if self.Stage == LEAF_STAGE_ANCHORED {
+		return false
+	}
+	if self.Stage == t.Stage && self.TxHash == t.TxHash {
+		return false
+	}
+
+	self.Stage = t.Stage
+	self.TxHash = t.TxHash
+	self.BlockHeight = height
+	self.Transitions = append(self.Transitions, t)
+	if len(self.Transitions) > maxLeafTransitions {
+		self.Transitions = self.Transitions[len(self.Transitions)-maxLeafTransitions:]
+	}
+
+	return true
+}
+
+func getLeafStatus(store *leveldbstore.LevelDBStore, leaf common.Uint256) (*LeafStatus, error) {
+	raw, err := store.Get(GetKeyByHash(PREFIX_LEAF_STATUS, leaf))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &LeafStatus{}
+	err = status.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// putLeafStage record the transitions of leafs to store batch. the transitions applied in order. height only used by anchored.
+func putLeafStage(store *leveldbstore.LevelDBStore, leafs []common.Uint256, height uint32, transitions ...LeafTransition) {
+	now := uint64(time.Now().Unix())
+	for _, leaf := range leafs {
+		status, err := getLeafStatus(store, leaf)
+		if err != nil {
+			status = &LeafStatus{}
+		}
+
+		changed := false
+		for _, t := range transitions {
+			if t.Time == 0 {
+				t.Time = now
+			}
+			if status.apply(t, height) {
+				changed = true
+			}
+		}
+
+		if changed {
+			sink := common.NewZeroCopySink(nil)
+			status.Serialization(sink)
+			store.BatchPut(GetKeyByHash(PREFIX_LEAF_STATUS, leaf), sink.Bytes())
+		}
+	}
+}
+
+// not BatchDelete. same as delLeafIndex
+func delLeafStatus(store *leveldbstore.LevelDBStore, leaf common.Uint256) {
+	store.Delete(GetKeyByHash(PREFIX_LEAF_STATUS, leaf))
+}
+
+func stageOf(stage LeafStage, txh common.Uint256) LeafTransition {
+	return LeafTransition{Stage: stage, TxHash: txh}
+}
+
+// tx already marked submitted since start.
+var submittedTx sync.Map
+
+// markTxSubmitted record leafs of tx submitted the first time tx send. under MTlock same as the ledger commit of the anchored block.
+func markTxSubmitted(txh common.Uint256, leafv []common.Uint256) {
+	if _, ok := submittedTx.Load(txh); ok {
+		return
+	}
+	submittedTx.Store(txh, true)
+
+	if len(leafv) == 0 {
+		return
+	}
+
+	MTlock.Lock()
+	defer MTlock.Unlock()
+
+	// tx anchored. the ledger del the tx before commit.
+	if !TxStore.CheckHashExist(txh) {
+		return
+	}
+
+	// sent before restart.
+	status, err := getLeafStatus(DefStore, leafv[0])
+	if err == nil && status.TxHash == txh && status.Stage != LEAF_STAGE_BATCHED {
+		return
+	}
+
+	var store leveldbstore.LevelDBStore
+	store = *DefStore
+	store.NewBatch()
+	putLeafStage(&store, leafv, 0, stageOf(LEAF_STAGE_SUBMITTED, txh))
+	err = store.BatchCommit()
+	if err != nil {
+		log.Errorf("markTxSubmitted: %s", err)
+	}
+}
+
+type LeafTransitionJson struct {
+	Stage  string `json:"stage"`
+	Time   uint64 `json:"time"`
+	TxHash string `json:"txHash,omitempty"`
+}
+
+type LeafStatusResult struct {
+	Hash        string               `json:"hash"`
+	Stage       string               `json:"stage"`
+	TxHash      string               `json:"txHash,omitempty"`
+	BlockHeight uint32               `json:"blockHeight,omitempty"`
+	Index       *uint32              `json:"index,omitempty"`
+	Transitions []LeafTransitionJson `json:"transitions"`
+	// hash algorithm of addData. empty if hashed by client.
+	Algorithm string `json:"algorithm,omitempty"`
+}
+
+func txHashString(txh common.Uint256) string {
+	if txh == merkle.EMPTY_HASH {
+		return ""
+	}
+	return txh.ToHexString()
+}
+
+func queryLeafStatus(hash string, leaf common.Uint256) *LeafStatusResult {
+	res := &LeafStatusResult{
+		Hash:        hash,
+		Stage:       LEAF_STATUS_NOT_FOUND,
+		Transitions: make([]LeafTransitionJson, 0),
+	}
+
+	index, leafHeight, leafTxHash, err := getLeafInfo(DefStore, leaf)
+	if err != nil && err != LEAF_HEIGHT_EMPTY_ERR {
+		return res
+	}
+	if algorithm := getLeafHashAlgorithm(DefStore, leaf); algorithm != HASH_CLIENT {
+		res.Algorithm = algorithm.String()
+	}
+
+	status, err := getLeafStatus(DefStore, leaf)
+	if err != nil {
+		// leaf added before status recorded.
+		if index == math.MaxUint32 {
+			res.Stage = leafStageName[LEAF_STAGE_UNKNOWN]
+			return res
+		}
+		res.Stage = leafStageName[LEAF_STAGE_ANCHORED]
+		res.TxHash = leafTxHash
+		res.BlockHeight = leafHeight
+		res.Index = &index
+		return res
+	}
+
+	res.Stage = leafStageName[status.Stage]
+	res.TxHash = txHashString(status.TxHash)
+	res.BlockHeight = status.BlockHeight
+	if status.Stage == LEAF_STAGE_ANCHORED && index != math.MaxUint32 {
+		res.Index = &index
+	}
+	for _, t := range status.Transitions {
+		res.Transitions = append(res.Transitions, LeafTransitionJson{
+			Stage:  leafStageName[t.Stage],
+			Time:   t.Time,
+			TxHash: txHashString(t.TxHash),
+		})
+	}
+
+	return res
+}
+
+// pubKey authorize checked by rpc dispatch.
+func rpcGetLeafStatus(vargs *VerifyParam) map[string]interface{} {
+	if uint32(len(vargs.Hashes)) > maxBatchVerifyNum() || len(vargs.Hashes) == 0 {
+		return responsePack(INVALID_PARAM, "too much or empty hashes")
+	}
+
+	leafs, _, err := convertParamsToLeafs(vargs.Hashes)
+	if err != nil {
+		log.Infof("getLeafStatus convert params err: %s\n", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	res := make([]*LeafStatusResult, 0, len(leafs))
+	for i, leaf := range leafs {
+		res = append(res, queryLeafStatus(vargs.Hashes[i], leaf))
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getLeafStatus",
+		Desc:       "get the lifecycle stage and transitions of leaf hashes.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_VERIFIER,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &VerifyParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetLeafStatus(params.(*VerifyParam)) },
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestLeafStageTransitions(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := sha256.Sum256([]byte("leaf"))
+	tx, other := common.Uint256{1}, common.Uint256{2}
+
+	store.NewBatch()
+	putLeafStage(store, []common.Uint256{leaf}, 0, stageOf(LEAF_STAGE_QUEUED, common.UINT256_EMPTY), stageOf(LEAF_STAGE_BATCHED, tx))
+	store.BatchCommit()
+	store.NewBatch()
+	putLeafStage(store, []common.Uint256{leaf}, 0, stageOf(LEAF_STAGE_BATCHED, tx))
+	putLeafStage(store, []common.Uint256{leaf}, 0, stageOf(LEAF_STAGE_SUBMITTED, tx))
+	store.BatchCommit()
+	store.NewBatch()
+	putLeafStage(store, []common.Uint256{leaf}, 10, stageOf(LEAF_STAGE_ANCHORED, tx))
+	store.BatchCommit()
+
+	status, err := getLeafStatus(store, leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Stage != LEAF_STAGE_ANCHORED || status.BlockHeight != 10 || len(status.Transitions) != 4 {
+		t.Fatalf("status %+v", status)
+	}
+
+	// late submitted of the same or resent tx. failed of the resent tx.
+	store.NewBatch()
+	putLeafStage(store, []common.Uint256{leaf}, 0, stageOf(LEAF_STAGE_SUBMITTED, tx), stageOf(LEAF_STAGE_BATCHED, other))
+	putLeafStage(store, []common.Uint256{leaf}, 20, stageOf(LEAF_STAGE_FAILED, other), stageOf(LEAF_STAGE_BATCHED, common.Uint256{3}))
+	store.BatchCommit()
+	status, _ = getLeafStatus(store, leaf)
+	if status.Stage != LEAF_STAGE_ANCHORED || status.TxHash != tx || status.BlockHeight != 10 || len(status.Transitions) != 4 {
+		t.Errorf("anchored downgraded %+v", status)
+	}
+	if status.apply(stageOf(LEAF_STAGE_FAILED, other), 20) {
+		t.Errorf("failed applied to anchored leaf")
+	}
+
+	status = &LeafStatus{}
+	for i := 0; i < maxLeafTransitions+3; i++ {
+		status.apply(stageOf(LEAF_STAGE_FAILED, common.Uint256{byte(i)}), 0)
+	}
+	if len(status.Transitions) != maxLeafTransitions || status.Transitions[0].TxHash != (common.Uint256{3}) {
+		t.Errorf("transitions not trimmed to the latest %d", len(status.Transitions))
+	}
+}
+
+func TestMarkTxSubmitted(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldTxStore, oldLock := DefStore, TxStore, MTlock
+	defer func() { DefStore, TxStore, MTlock = oldStore, oldTxStore, oldLock }()
+	DefStore, TxStore, MTlock = store, &TransactionStore{}, new(sync.RWMutex)
+
+	pending, anchored, deleted := common.Uint256{10}, common.Uint256{11}, common.Uint256{12}
+	leafs := map[common.Uint256]common.Uint256{
+		pending:  sha256.Sum256([]byte("pending")),
+		anchored: sha256.Sum256([]byte("anchored")),
+		deleted:  sha256.Sum256([]byte("deleted")),
+	}
+	store.NewBatch()
+	for txh, leaf := range leafs {
+		putLeafStage(store, []common.Uint256{leaf}, 0, stageOf(LEAF_STAGE_BATCHED, txh))
+	}
+	putLeafStage(store, []common.Uint256{leafs[anchored]}, 10, stageOf(LEAF_STAGE_ANCHORED, anchored))
+	store.BatchCommit()
+	TxStore.PublishAddHashes([]common.Uint256{pending, anchored})
+
+	// anchored by the ledger between the tx sent and marked.
+	for txh, leaf := range leafs {
+		markTxSubmitted(txh, []common.Uint256{leaf})
+	}
+
+	expect := map[common.Uint256]LeafStage{
+		pending:  LEAF_STAGE_SUBMITTED,
+		anchored: LEAF_STAGE_ANCHORED,
+		deleted:  LEAF_STAGE_BATCHED,
+	}
+	for txh, leaf := range leafs {
+		status, err := getLeafStatus(store, leaf)
+		if err != nil || status.Stage != expect[txh] {
+			t.Errorf("tx %x leaf stage %+v %v, expect %s", txh[:1], status, err, leafStageName[expect[txh]])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const METRICS_NAMESPACE = "witness"
+
+var (
+	metricBatchAddRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "batchadd_requests_total",
+		Help:      "batchAdd requests passed auth.",
+	})
+	metricBatchAddLeafs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "batchadd_leaves_total",
+		Help:      "leaf hashes added by batchAdd.",
+	})
+	metricBatchAddDuplicates = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "batchadd_duplicates_total",
+		Help:      "duplicate leaf hashes of batchAdd. rejected, or accepted as retry of the same key if idempotentbatchadd set.",
+	})
+	metricAuthFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "auth_failures_total",
+		Help:      "rpc calls rejected by pubKey or signature check.",
+	}, []string{"method"})
+	metricVerify = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "verify_total",
+		Help:      "verified leafs. hit if proof found.",
+	}, []string{"result"})
+	metricTxConstructed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "tx_constructed_total",
+		Help:      "batch_add txs constructed.",
+	})
+	metricTxSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "tx_sent_total",
+		Help:      "batch_add txs sent the first time.",
+	})
+	metricTxResent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "tx_resent_total",
+		Help:      "batch_add txs sent again because not anchored yet.",
+	})
+	metricTxFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "tx_failed_total",
+		Help:      "batch_add txs executed failed on chain and replaced.",
+	})
+	metricAnchorLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "anchor_latency_seconds",
+		Help:      "time from leaf submitted to anchored.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	})
+	metricVerifyLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "verify_latency_seconds",
+		Help:      "time to handle verify and batchVerify.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	metricHeightLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "height_lag",
+		Help:      "chain height minus local sync height.",
+	})
+	metricPromisesOutstanding = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "promises_outstanding",
+		Help:      "leafs promised at batchAdd not anchored and not passed mmd yet.",
+	})
+	metricPromisesBroken = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "promises_broken_total",
+		Help:      "leafs not anchored within the mmd promised at batchAdd.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricBatchAddRequests,
+		metricBatchAddLeafs,
+		metricBatchAddDuplicates,
+		metricAuthFailures,
+		metricVerify,
+		metricTxConstructed,
+		metricTxSent,
+		metricTxResent,
+		metricTxFailed,
+		metricAnchorLatency,
+		metricVerifyLatency,
+		metricHeightLag,
+		metricPromisesOutstanding,
+		metricPromisesBroken,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: METRICS_NAMESPACE,
+			Name:      "cached_leaves",
+			Help:      "leafs buffered in cacheLeafs not construct to tx yet.",
+		}, func() float64 { return float64(atomic.LoadInt64(&cachedLeafNum)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: METRICS_NAMESPACE,
+			Name:      "pending_txs",
+			Help:      "batch_add txs in TxStore not anchored yet.",
+		}, func() float64 {
+			if TxStore == nil {
+				return 0
+			}
+			return float64(pendingTxNum())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: METRICS_NAMESPACE,
+			Name:      "tree_size",
+			Help:      "local merkle tree size.",
+		}, func() float64 {
+			if DefMerkleTree == nil {
+				return 0
+			}
+			MTlock.RLock()
+			defer MTlock.RUnlock()
+			return float64(DefMerkleTree.TreeSize())
+		}),
+	)
+}
+
+func observeVerifyLatency(start time.Time) {
+	metricVerifyLatency.Observe(time.Since(start).Seconds())
+}
+
+// leafSubmitTimes the first transition time of leafs. read before the anchored stage put.
+func leafSubmitTimes(store *leveldbstore.LevelDBStore, leafv []common.Uint256) []uint64 {
+	times := make([]uint64, 0, len(leafv))
+	for _, leaf := range leafv {
+		status, err := getLeafStatus(store, leaf)
+		if err != nil || len(status.Transitions) == 0 {
+			continue
+		}
+		times = append(times, status.Transitions[0].Time)
+	}
+
+	return times
+}
+
+// observeAnchorLatency called after the block committed. so retry of block not observed twice.
+func observeAnchorLatency(submitTimes []uint64) {
+	now := uint64(time.Now().Unix())
+	for _, t := range submitTimes {
+		if now < t {
+			continue
+		}
+		metricAnchorLatency.Observe(float64(now - t))
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+const (
+	PROMISE_SIG_DOMAIN = "ontology-witness-sst"
+
+	// seconds a leaf promised to be anchored within after accepted.
+	DEFAULT_MAX_MERGE_DELAY uint32 = 3600
+	maxPromiseCheckInterval uint32 = 60
+
+	defaultBrokenPromiseLimit uint32 = 20
+	maxBrokenPromiseLimit     uint32 = 100
+)
+
+func maxMergeDelay() uint32 {
+	if DefConfig.MaxMergeDelay == 0 {
+		return DEFAULT_MAX_MERGE_DELAY
+	}
+	return DefConfig.MaxMergeDelay
+}
+
+// Promise signed timestamp of leaf. accepted at Timestamp and will be anchored within Mmd seconds.
+type Promise struct {
+	Leaf      common.Uint256
+	Timestamp uint64
+	Mmd       uint32
+	Signature []byte
+	// not zero if deadline passed before anchored.
+	BrokenAt uint64
+}
+
+func (self *Promise) Deadline() uint64 {
+	return self.Timestamp + uint64(self.Mmd)
+}
+
+// SignData domain, contract, tenant, leaf, timestamp and mmd.
+func (self *Promise) SignData() []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(PROMISE_SIG_DOMAIN)
+	sink.WriteAddress(contractAddress)
+	sink.WriteString(DefConfig.TenantId)
+	sink.WriteHash(self.Leaf)
+	sink.WriteUint64(self.Timestamp)
+	sink.WriteUint32(self.Mmd)
+	return sink.Bytes()
+}
+
+func (self *Promise) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(self.Leaf)
+	sink.WriteUint64(self.Timestamp)
+	sink.WriteUint32(self.Mmd)
+	sink.WriteVarBytes(self.Signature)
+	sink.WriteUint64(self.BrokenAt)
+}
+
+func (self *Promise) Deserialization(source *common.ZeroCopySource) error {
+	leaf, eof := source.NextHash()
+	timestamp, eof := source.NextUint64()
+	mmd, eof := source.NextUint32()
+	sig, _, irregular, eof := source.NextVarBytes()
+	brokenAt, eof := source.NextUint64()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.Leaf = leaf
+	self.Timestamp = timestamp
+	self.Mmd = mmd
+	self.Signature = sig
+	self.BrokenAt = brokenAt
+	return nil
+}
+
+type PromiseJson struct {
+	Leaf      string `json:"hash"`
+	Timestamp uint64 `json:"timestamp"`
+	Mmd       uint32 `json:"mmd"`
+	Signature string `json:"signature"`
+}
+
+func (self *Promise) toJson() *PromiseJson {
+	return &PromiseJson{
+		Leaf:      hex.EncodeToString(self.Leaf[:]),
+		Timestamp: self.Timestamp,
+		Mmd:       self.Mmd,
+		Signature: hex.EncodeToString(self.Signature),
+	}
+}
+
+// BrokenPromiseJson anchored late if Anchored. or still pending.
+type BrokenPromiseJson struct {
+	*PromiseJson
+	Deadline    uint64 `json:"deadline"`
+	BrokenAt    uint64 `json:"brokenAt"`
+	Anchored    bool   `json:"anchored"`
+	BlockHeight uint32 `json:"blockheight,omitempty"`
+	TxHash      string `json:"txhash,omitempty"`
+}
+
+// toBrokenJson anchored state read from the leaf index. so not lost if anchored while checked.
+func (self *Promise) toBrokenJson(store *leveldbstore.LevelDBStore) *BrokenPromiseJson {
+	res := &BrokenPromiseJson{
+		PromiseJson: self.toJson(),
+		Deadline:    self.Deadline(),
+		BrokenAt:    self.BrokenAt,
+	}
+
+	index, height, txHash, err := getLeafInfo(store, self.Leaf)
+	if err == nil && index != math.MaxUint32 {
+		res.Anchored = true
+		res.BlockHeight = height
+		res.TxHash = txHash
+	}
+
+	return res
+}
+
+// newPromises sign the promise of every leaf at the receipt timestamp.
+func newPromises(leafs []common.Uint256, timestamp uint64) ([]*Promise, error) {
+	mmd := maxMergeDelay()
+	promises := make([]*Promise, 0, len(leafs))
+	for _, leaf := range leafs {
+		promise := &Promise{
+			Leaf:      leaf,
+			Timestamp: timestamp,
+			Mmd:       mmd,
+		}
+		sig, err := DefSigner.Sign(promise.SignData())
+		if err != nil {
+			return nil, err
+		}
+		promise.Signature = sig
+		promises = append(promises, promise)
+	}
+
+	return promises, nil
+}
+
+// promisesExcept the promises of leafs not in duplicateLeafs. one of each leaf.
+func promisesExcept(promises []*Promise, duplicateLeafs []string) []*Promise {
+	skip := make(map[string]bool, len(duplicateLeafs))
+	for _, dup := range duplicateLeafs {
+		skip[dup] = true
+	}
+
+	res := make([]*Promise, 0, len(promises))
+	for _, promise := range promises {
+		hash := common.ToHexString(promise.Leaf[:])
+		if skip[hash] {
+			continue
+		}
+		skip[hash] = true
+		res = append(res, promise)
+	}
+
+	return res
+}
+
+func putPromise(store *leveldbstore.LevelDBStore, prefix DataPrefix, promise *Promise) {
+	sink := common.NewZeroCopySink(nil)
+	promise.Serialization(sink)
+	store.BatchPut(GetKeyByHash(prefix, promise.Leaf), sink.Bytes())
+}
+
+// putPromises to the store batch of batchAdd. outstanding until anchored.
+func putPromises(store *leveldbstore.LevelDBStore, promises []*Promise) {
+	for _, promise := range promises {
+		putPromise(store, PREFIX_PROMISE, promise)
+	}
+}
+
+// resolvePromises in the block batch of anchored leafs. broken ones kept for report.
+func resolvePromises(store *leveldbstore.LevelDBStore, leafv []common.Uint256) {
+	for _, leaf := range leafv {
+		store.BatchDelete(GetKeyByHash(PREFIX_PROMISE, leaf))
+	}
+}
+
+// checkPromises move the outstanding promises passed deadline to broken. return the outstanding left and broken num.
+func checkPromises(store *leveldbstore.LevelDBStore, now uint64) (int, int, error) {
+	outstanding, broken := 0, 0
+	iter := store.NewIterator([]byte{byte(PREFIX_PROMISE)})
+	for iter.Next() {
+		promise := &Promise{}
+		err := promise.Deserialization(common.NewZeroCopySource(iter.Value()))
+		if err != nil {
+			iter.Release()
+			return 0, 0, err
+		}
+		if promise.Deadline() >= now {
+			outstanding++
+			continue
+		}
+		index, err := getLeafIndex(store, promise.Leaf)
+		if err == nil && index != math.MaxUint32 {
+			// anchored while checked. the block batch delete it too.
+			store.BatchDelete(iter.Key())
+			continue
+		}
+
+		promise.BrokenAt = now
+		store.BatchDelete(iter.Key())
+		putPromise(store, PREFIX_BROKEN_PROMISE, promise)
+		broken++
+		log.Errorf("checkPromises: promise broken. leaf %x accepted at %d not anchored within %ds", promise.Leaf, promise.Timestamp, promise.Mmd)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, 0, err
+	}
+
+	return outstanding, broken, nil
+}
+
+// RoutineOfPromiseCheck alert by log and metric when leafs not anchored within mmd.
+func RoutineOfPromiseCheck() {
+	interval := maxMergeDelay() / 10
+	if interval > maxPromiseCheckInterval {
+		interval = maxPromiseCheckInterval
+	}
+	if interval == 0 {
+		interval = 1
+	}
+
+	for {
+		if SystemOutOfService {
+			return
+		}
+
+		time.Sleep(time.Second * time.Duration(interval))
+
+		var store leveldbstore.LevelDBStore
+		store = *DefStore
+		store.NewBatch()
+
+		outstanding, broken, err := checkPromises(&store, uint64(time.Now().Unix()))
+		if err != nil {
+			log.Errorf("RoutineOfPromiseCheck: %s", err)
+			continue
+		}
+		metricPromisesOutstanding.Set(float64(outstanding))
+		if broken == 0 {
+			continue
+		}
+
+		err = store.BatchCommit()
+		if err != nil {
+			log.Errorf("RoutineOfPromiseCheck: %s", err)
+			continue
+		}
+		metricPromisesBroken.Add(float64(broken))
+	}
+}
+
+type BrokenPromiseParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	// leaf hash to continue from.
+	From  string `json:"from,omitempty"`
+	Limit uint32 `json:"limit,omitempty"`
+}
+
+func (self *BrokenPromiseParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *BrokenPromiseParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *BrokenPromiseParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *BrokenPromiseParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of from and limit.
+func (self *BrokenPromiseParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.From)
+	sink.WriteUint32(self.Limit)
+	return sink.Bytes(), nil
+}
+
+type BrokenPromiseResult struct {
+	Promises []*BrokenPromiseJson `json:"promises"`
+	// leaf hash to continue from. empty if no more.
+	Next string `json:"next"`
+}
+
+// listBrokenPromises in leaf hash order from the leaf.
+func listBrokenPromises(store *leveldbstore.LevelDBStore, from common.Uint256, limit uint32) (*BrokenPromiseResult, error) {
+	res := &BrokenPromiseResult{
+		Promises: make([]*BrokenPromiseJson, 0, limit),
+	}
+
+	iter := store.NewIterator([]byte{byte(PREFIX_BROKEN_PROMISE)})
+	defer iter.Release()
+
+	for ok := iter.Seek(GetKeyByHash(PREFIX_BROKEN_PROMISE, from)); ok; ok = iter.Next() {
+		promise := &Promise{}
+		err := promise.Deserialization(common.NewZeroCopySource(iter.Value()))
+		if err != nil {
+			return nil, err
+		}
+		if uint32(len(res.Promises)) == limit {
+			res.Next = hex.EncodeToString(promise.Leaf[:])
+			break
+		}
+		res.Promises = append(res.Promises, promise.toBrokenJson(store))
+	}
+
+	return res, iter.Error()
+}
+
+// signed request checked by rpc dispatch.
+func rpcGetBrokenPromises(pargs *BrokenPromiseParam) map[string]interface{} {
+	limit := pargs.Limit
+	if limit == 0 {
+		limit = defaultBrokenPromiseLimit
+	}
+	if limit > maxBrokenPromiseLimit {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("limit most %d", maxBrokenPromiseLimit))
+	}
+
+	from := common.UINT256_EMPTY
+	if len(pargs.From) != 0 {
+		var err error
+		from, err = HashFromHexString(pargs.From)
+		if err != nil {
+			return responsePack(INVALID_PARAM, err.Error())
+		}
+	}
+
+	res, err := listBrokenPromises(DefStore, from, limit)
+	if err != nil {
+		log.Errorf("getBrokenPromises: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getBrokenPromises",
+		Desc:       "list the leafs not anchored within the mmd promised at batchAdd. paged by leaf hash.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &BrokenPromiseParam{} },
+		Handler: func(params interface{}) map[string]interface{} {
+			return rpcGetBrokenPromises(params.(*BrokenPromiseParam))
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"math"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestCheckPromises(t *testing.T) {
+	mem, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	late, onTime, anchored := sha256.Sum256([]byte("late")), sha256.Sum256([]byte("on time")), sha256.Sum256([]byte("anchored"))
+
+	var store leveldbstore.LevelDBStore
+	store = *mem
+	store.NewBatch()
+	putPromises(&store, []*Promise{
+		{Leaf: late, Timestamp: 1000, Mmd: 60, Signature: []byte{1}},
+		{Leaf: onTime, Timestamp: 1100, Mmd: 60, Signature: []byte{2}},
+		{Leaf: anchored, Timestamp: 1000, Mmd: 60, Signature: []byte{3}},
+	})
+	putLeafIndex(&store, late, math.MaxUint32, 0, common.UINT256_EMPTY.ToHexString())
+	putLeafIndex(&store, anchored, 0, 7, "tx")
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	store.NewBatch()
+	outstanding, broken, err := checkPromises(&store, 1100)
+	if err != nil || outstanding != 1 || broken != 1 {
+		t.Fatalf("checkPromises %d %d %v", outstanding, broken, err)
+	}
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := listBrokenPromises(mem, common.UINT256_EMPTY, 10)
+	if err != nil || len(res.Promises) != 1 || len(res.Next) != 0 {
+		t.Fatalf("listBrokenPromises %+v %v", res, err)
+	}
+	if p := res.Promises[0]; p.Deadline != 1060 || p.BrokenAt != 1100 || p.Anchored {
+		t.Errorf("broken promise %+v", p)
+	}
+
+	// anchored late still reported.
+	store.NewBatch()
+	putLeafIndex(&store, late, 1, 9, "tx")
+	resolvePromises(&store, []common.Uint256{late})
+	if err = store.BatchCommit(); err != nil {
+		t.Fatal(err)
+	}
+	res, err = listBrokenPromises(mem, common.UINT256_EMPTY, 10)
+	if err != nil || len(res.Promises) != 1 || !res.Promises[0].Anchored || res.Promises[0].BlockHeight != 9 {
+		t.Errorf("late anchored promise %+v %v", res.Promises, err)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/merkle"
+)
+
+const RECEIPT_SIG_DOMAIN = "ontology-witness-receipt"
+
+// Receipt evidence of submission signed by the server. Id is sha256 of SignData.
+type Receipt struct {
+	Id        common.Uint256
+	Submitter common.Address
+	Leafs     []common.Uint256
+	Timestamp uint64
+	TreeSize  uint32
+	Signature []byte
+}
+
+// leafsDigest sha256 of the concatenated leafs.
+func leafsDigest(leafs []common.Uint256) common.Uint256 {
+	data := make([]byte, 0, len(leafs)*common.UINT256_SIZE)
+	for _, leaf := range leafs {
+		data = append(data, leaf[:]...)
+	}
+	return sha256.Sum256(data)
+}
+
+func (self *Receipt) leafsDigest() common.Uint256 {
+	return leafsDigest(self.Leafs)
+}
+
+// SignData domain, contract, tenant, submitter, leaf count, sha256 of leafs, timestamp and tree size when received.
+func (self *Receipt) SignData() []byte {
+	digest := self.leafsDigest()
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(RECEIPT_SIG_DOMAIN)
+	sink.WriteAddress(contractAddress)
+	sink.WriteString(DefConfig.TenantId)
+	sink.WriteAddress(self.Submitter)
+	sink.WriteUint32(uint32(len(self.Leafs)))
+	sink.WriteBytes(digest[:])
+	sink.WriteUint64(self.Timestamp)
+	sink.WriteUint32(self.TreeSize)
+	return sink.Bytes()
+}
+
+func (self *Receipt) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteAddress(self.Submitter)
+	sink.WriteUint64(self.Timestamp)
+	sink.WriteUint32(self.TreeSize)
+	sink.WriteVarBytes(self.Signature)
+	sink.WriteVarUint(uint64(len(self.Leafs)))
+	for _, leaf := range self.Leafs {
+		sink.WriteHash(leaf)
+	}
+}
+
+func (self *Receipt) Deserialization(source *common.ZeroCopySource) error {
+	submitter, eof := source.NextAddress()
+	timestamp, eof := source.NextUint64()
+	treeSize, eof := source.NextUint32()
+	sig, _, irregular, eof := source.NextVarBytes()
+	num, _, irregular, eof := source.NextVarUint()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	leafs := make([]common.Uint256, 0, num)
+	for i := uint64(0); i < num; i++ {
+		leaf, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		leafs = append(leafs, leaf)
+	}
+
+	self.Submitter = submitter
+	self.Timestamp = timestamp
+	self.TreeSize = treeSize
+	self.Signature = sig
+	self.Leafs = leafs
+	self.Id = sha256.Sum256(self.SignData())
+	return nil
+}
+
+type ReceiptJson struct {
+	Id          string `json:"receiptId"`
+	Contract    string `json:"contract"`
+	TenantId    string `json:"tenantId,omitempty"`
+	Submitter   string `json:"submitter"`
+	LeafCount   uint32 `json:"leafCount"`
+	LeafsDigest string `json:"leafsDigest"`
+	Timestamp   uint64 `json:"timestamp"`
+	TreeSize    uint32 `json:"treeSize"`
+	PubKey      string `json:"pubKey"`
+	Signature   string `json:"signature"`
+	// signed timestamp of every leaf added. only in batchAdd result.
+	Promises []*PromiseJson `json:"promises,omitempty"`
+	// leafs submitted before by the same key. only in idempotent batchAdd result.
+	Duplicates []*LeafStatusResult `json:"duplicates,omitempty"`
+	// leaf hashes computed by server. only in addData result.
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+func (self *Receipt) toJson() *ReceiptJson {
+	digest := self.leafsDigest()
+	return &ReceiptJson{
+		Id:          hex.EncodeToString(self.Id[:]),
+		Contract:    contractAddress.ToHexString(),
+		TenantId:    DefConfig.TenantId,
+		Submitter:   self.Submitter.ToBase58(),
+		LeafCount:   uint32(len(self.Leafs)),
+		LeafsDigest: hex.EncodeToString(digest[:]),
+		Timestamp:   self.Timestamp,
+		TreeSize:    self.TreeSize,
+		PubKey:      hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
+		Signature:   hex.EncodeToString(self.Signature),
+	}
+}
+
+// newReceipt sign the receipt of leafs with the current tree size.
+func newReceipt(submitter common.Address, leafs []common.Uint256) (*Receipt, error) {
+	MTlock.RLock()
+	treeSize := DefMerkleTree.TreeSize()
+	MTlock.RUnlock()
+
+	receipt := &Receipt{
+		Submitter: submitter,
+		Leafs:     leafs,
+		Timestamp: uint64(time.Now().Unix()),
+		TreeSize:  treeSize,
+	}
+
+	data := receipt.SignData()
+	sig, err := DefSigner.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Signature = sig
+	receipt.Id = sha256.Sum256(data)
+
+	return receipt, nil
+}
+
+// putReceipt to the store batch of batchAdd. so receipt only exist if leafs accepted.
+func putReceipt(store *leveldbstore.LevelDBStore, receipt *Receipt) {
+	sink := common.NewZeroCopySink(nil)
+	receipt.Serialization(sink)
+	store.BatchPut(GetKeyByHash(PREFIX_RECEIPT, receipt.Id), sink.Bytes())
+}
+
+func getReceipt(store *leveldbstore.LevelDBStore, id common.Uint256) (*Receipt, error) {
+	raw, err := store.Get(GetKeyByHash(PREFIX_RECEIPT, id))
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{}
+	err = receipt.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Id != id {
+		return nil, errors.New("receipt id not match. contract or tenant changed")
+	}
+
+	return receipt, nil
+}
+
+type ReceiptParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Id        string `json:"receiptId"`
+}
+
+func (self *ReceiptParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *ReceiptParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *ReceiptParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *ReceiptParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of receipt id.
+func (self *ReceiptParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.Id)
+	return sink.Bytes(), nil
+}
+
+// ReceiptResult the anchored indices and proofs against the current root. status anchored if all leafs anchored. pending otherwise.
+type ReceiptResult struct {
+	Receipt     *ReceiptJson        `json:"receipt"`
+	Status      string              `json:"status"`
+	Root        string              `json:"root"`
+	TreeSize    uint32              `json:"size"`
+	BlockHeight uint32              `json:"blockheight"`
+	Results     []*LeafVerifyResult `json:"results"`
+}
+
+// signed request checked by rpc dispatch. only the submitter or auditor.
+func rpcGetReceipt(rargs *ReceiptParam) map[string]interface{} {
+	id, err := HashFromHexString(rargs.Id)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	receipt, err := getReceipt(DefStore, id)
+	if err != nil {
+		log.Debugf("getReceipt %s: %s", rargs.Id, err)
+		return responsePack(VERIFY_FAILED, "receipt not found")
+	}
+
+	address := addressOfPubKey(rargs.PubKey)
+	if address != receipt.Submitter && !checkRoleOfAddress(address, ROLE_AUDITOR) {
+		return responsePack(NO_AUTH, "receipt of other submitter")
+	}
+
+	hashes := make([]string, 0, len(receipt.Leafs))
+	for _, leaf := range receipt.Leafs {
+		hashes = append(hashes, hex.EncodeToString(leaf[:]))
+	}
+
+	MTlock.RLock()
+	root, treeSize, blockheight, err := verifyTarget(&VerifyParam{})
+	if err != nil {
+		// nothing anchored yet. all pending.
+		log.Debugf("getReceipt get verify target failed, %s", err)
+		root, treeSize, blockheight = merkle.EMPTY_HASH, 0, 0
+	}
+	results, hits := verifyLeafsAt(receipt.Leafs, hashes, root, treeSize, blockheight)
+	MTlock.RUnlock()
+
+	res := &ReceiptResult{
+		Receipt:     receipt.toJson(),
+		Status:      LEAF_STATUS_PENDING,
+		Root:        hex.EncodeToString(root[:]),
+		TreeSize:    treeSize,
+		BlockHeight: blockheight,
+		Results:     results,
+	}
+	if hits == len(receipt.Leafs) {
+		res.Status = LEAF_STATUS_ANCHORED
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getReceipt",
+		Desc:       "get the batchAdd receipt with the anchored indices and proofs of its leafs against the current root.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_SUBMITTER | ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ReceiptParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetReceipt(params.(*ReceiptParam)) },
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+)
+
+func TestReceiptSerialization(t *testing.T) {
+	receipt := &Receipt{
+		Submitter: common.AddressFromVmCode([]byte("submitter")),
+		Leafs:     []common.Uint256{sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b"))},
+		Timestamp: 1700000000,
+		TreeSize:  42,
+		Signature: []byte{1, 2, 3},
+	}
+	receipt.Id = sha256.Sum256(receipt.SignData())
+
+	sink := common.NewZeroCopySink(nil)
+	receipt.Serialization(sink)
+
+	res := &Receipt{}
+	err := res.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Id != receipt.Id || len(res.Leafs) != 2 || res.Leafs[1] != receipt.Leafs[1] || res.TreeSize != 42 {
+		t.Errorf("receipt %+v", res)
+	}
+
+	// id bound to tenant.
+	DefConfig.TenantId = "tenant"
+	defer func() { DefConfig.TenantId = "" }()
+	err = res.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	if err != nil || res.Id == receipt.Id {
+		t.Errorf("receipt id not bound to tenant")
+	}
+}
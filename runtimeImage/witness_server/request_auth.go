@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+)
+
+const (
+	DEFAULT_REQUEST_SKEW uint32 = 300
+	MAX_NONCE_LENGTH            = 64
+)
+
+// RpcRequestAuthParam must be implemented by params of the method which Auth is RPC_AUTH_REQUEST.
+type RpcRequestAuthParam interface {
+	RpcAuthParam
+	// unix seconds the request signed.
+	GetTimestamp() int64
+	// unique of pubKey in the skew window.
+	GetNonce() string
+}
+
+func requestSkew() int64 {
+	if DefConfig.RequestSkew == 0 {
+		return int64(DEFAULT_REQUEST_SKEW)
+	}
+
+	return int64(DefConfig.RequestSkew)
+}
+
+// requestSignData the canonical request signed by the client. method, SignData of params, timestamp and nonce.
+func requestSignData(method string, param RpcRequestAuthParam) ([]byte, error) {
+	data, err := param.SignData()
+	if err != nil {
+		return nil, err
+	}
+
+	return canonicalRequest(method, data, param.GetTimestamp(), param.GetNonce()), nil
+}
+
+// canonicalRequest of method with the canonical of params. also rebuilt from sigDB records.
+func canonicalRequest(method string, data []byte, timestamp int64, nonce string) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(method)
+	sink.WriteVarBytes(data)
+	sink.WriteUint64(uint64(timestamp))
+	sink.WriteString(nonce)
+	return sink.Bytes()
+}
+
+func checkRequestTime(timestamp int64) error {
+	now := time.Now().Unix()
+	if timestamp < now-requestSkew() || timestamp > now+requestSkew() {
+		return fmt.Errorf("timestamp %d out of %d seconds from server time %d", timestamp, requestSkew(), now)
+	}
+
+	return nil
+}
+
+// NonceCache remember nonce seen in the skew window. a request with same pubKey and nonce is replay.
+type NonceCache struct {
+	lock      sync.Mutex
+	nonces    map[string]int64
+	lastSweep int64
+}
+
+func NewNonceCache() *NonceCache {
+	return &NonceCache{
+		nonces: make(map[string]int64),
+	}
+}
+
+// Check record the nonce of pubKey. error if seen. timestamp must checked before so the nonce older than window need not kept.
+func (self *NonceCache) Check(pubKey string, nonce string, timestamp int64) error {
+	if len(nonce) == 0 || len(nonce) > MAX_NONCE_LENGTH {
+		return fmt.Errorf("nonce should not empty and most %d", MAX_NONCE_LENGTH)
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := time.Now().Unix()
+	if now-self.lastSweep > requestSkew() {
+		for k, expire := range self.nonces {
+			if expire < now {
+				delete(self.nonces, k)
+			}
+		}
+		self.lastSweep = now
+	}
+
+	key := pubKey + ":" + nonce
+	if _, ok := self.nonces[key]; ok {
+		return errors.New("nonce already used")
+	}
+	self.nonces[key] = timestamp + requestSkew()
+
+	return nil
+}
+
+var DefNonceCache = NewNonceCache()
+
+// checkRequestAuth check the time and nonce of signed request. signature verified before so unsigned request can not fill the cache.
+func checkRequestAuth(param RpcRequestAuthParam) error {
+	err := checkRequestTime(param.GetTimestamp())
+	if err != nil {
+		return err
+	}
+
+	return DefNonceCache.Check(param.GetPubKey(), param.GetNonce(), param.GetTimestamp())
+}
+
+// ServerQueryParam params of the query has no args but need signed request. getRoot and GetContractAddress.
+type ServerQueryParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+}
+
+func (self *ServerQueryParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *ServerQueryParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *ServerQueryParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *ServerQueryParam) GetNonce() string {
+	return self.Nonce
+}
+
+func (self *ServerQueryParam) SignData() ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	sdk "github.com/ontio/ontology-go-sdk"
+	"github.com/ontio/ontology/common"
+)
+
+// newTestAccount authorized with roles until the returned restore called.
+func newTestAccount(roles KeyRole) (*sdk.Account, string, func()) {
+	acc := sdk.NewAccount()
+	authorizedKeysLock.Lock()
+	old := authorizedKeys
+	authorizedKeys = map[common.Address]*AuthorizedKey{acc.Address: {Address: acc.Address, Roles: roles}}
+	authorizedKeysLock.Unlock()
+
+	return acc, hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)), func() {
+		authorizedKeysLock.Lock()
+		authorizedKeys = old
+		authorizedKeysLock.Unlock()
+	}
+}
+
+// signTestRequest hex signature of the canonical request of method.
+func signTestRequest(t *testing.T, acc *sdk.Account, method string, param RpcRequestAuthParam) string {
+	data, err := requestSignData(method, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := acc.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(sig)
+}
+
+func TestNonceCacheReplay(t *testing.T) {
+	cache := NewNonceCache()
+	now := time.Now().Unix()
+
+	if err := cache.Check("pub", "n1", now); err != nil {
+		t.Fatalf("first nonce: %s", err)
+	}
+	if err := cache.Check("pub", "n1", now); err == nil {
+		t.Errorf("replay nonce accepted")
+	}
+	if err := cache.Check("other", "n1", now); err != nil {
+		t.Errorf("same nonce of other pubKey: %s", err)
+	}
+	if err := cache.Check("pub", "", now); err == nil {
+		t.Errorf("empty nonce accepted")
+	}
+}
+
+func TestCheckRequestTime(t *testing.T) {
+	now := time.Now().Unix()
+	skew := requestSkew()
+
+	if err := checkRequestTime(now); err != nil {
+		t.Errorf("now: %s", err)
+	}
+	if err := checkRequestTime(now - skew - 10); err == nil {
+		t.Errorf("old timestamp accepted")
+	}
+	if err := checkRequestTime(now + skew + 10); err == nil {
+		t.Errorf("future timestamp accepted")
+	}
+}
+
+func TestRequestSignDataBindMethod(t *testing.T) {
+	param := &VerifyParam{
+		Hashes:    []string{"00"},
+		Timestamp: 1,
+		Nonce:     "n",
+	}
+
+	verify, err := requestSignData("verify", param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := requestSignData("batchVerify", param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(verify, batch) {
+		t.Errorf("sign data of different method equal")
+	}
+
+	param.TreeSize = 1
+	sized, _ := requestSignData("verify", param)
+	if bytes.Equal(verify, sized) {
+		t.Errorf("sign data not bind treeSize")
+	}
+}
+
+func TestBatchAddSignDataVersion(t *testing.T) {
+	param := &RpcParam{
+		Hashes: []string{"0000000000000000000000000000000000000000000000000000000000000001"},
+	}
+
+	if _, err := param.SignData(); err == nil {
+		t.Errorf("legacy signature accepted without LegacyBatchAddSig")
+	}
+	DefConfig.LegacyBatchAddSig = true
+	_, err := param.SignData()
+	DefConfig.LegacyBatchAddSig = false
+	if err != nil {
+		t.Errorf("legacy signature with LegacyBatchAddSig: %s", err)
+	}
+
+	param.Version = BATCHADD_SIG_V1
+	param.Timestamp = 1
+	param.Nonce = "n"
+	v1, err := param.SignData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DefConfig.TenantId = "tenant"
+	tenant, _ := param.SignData()
+	DefConfig.TenantId = ""
+	if bytes.Equal(v1, tenant) {
+		t.Errorf("envelope not bind tenant")
+	}
+
+	param.Version = 2
+	if _, err := param.SignData(); err == nil {
+		t.Errorf("unknown version accepted")
+	}
+}
+
+func TestQueryMethodsSigned(t *testing.T) {
+	for _, name := range []string{"getLeafStatus", "getRootHistory", "getConsistencyProof", "getBatch", "listBatches", "getLeavesByTx", "findLeaves", "getReceipt", "getBrokenPromises"} {
+		if method := getRpcMethod(name); method.Auth != RPC_AUTH_REQUEST {
+			t.Errorf("%s auth %s", name, rpcAuthName[method.Auth])
+		}
+	}
+
+	acc, pubKey, restore := newTestAccount(ROLE_AUDITOR)
+	defer restore()
+	param := &RootHistoryParam{PubKey: pubKey, Timestamp: time.Now().Unix(), Nonce: "history", From: 1}
+	if res := checkRpcAuth(context.Background(), "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, param); res == nil {
+		t.Errorf("pubKey only accepted")
+	}
+	param.Signature = signTestRequest(t, acc, "getRootHistory", param)
+	param.Limit = 100
+	if res := checkRpcAuth(context.Background(), "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, param); res == nil {
+		t.Errorf("limit not signed")
+	}
+	param.Limit = 0
+	if res := checkRpcAuth(context.Background(), "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, param); res != nil {
+		t.Errorf("signed request %v", res)
+	}
+}
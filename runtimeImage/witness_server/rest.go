@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ontio/ontology/common/log"
+)
+
+var restStatusMap = map[int64]int{
+	SUCCESS:          http.StatusOK,
+	INVALID_PARAM:    http.StatusBadRequest,
+	ADDHASH_FAILED:   http.StatusInternalServerError,
+	VERIFY_FAILED:    http.StatusNotFound,
+	NODE_OUTSERVICE:  http.StatusServiceUnavailable,
+	NO_AUTH:          http.StatusForbidden,
+	DUP_HASH:         http.StatusConflict,
+	PARSE_ERROR:      http.StatusBadRequest,
+	INVALID_REQUEST:  http.StatusBadRequest,
+	METHOD_NOT_FOUND: http.StatusNotFound,
+	INVALID_PARAMS:   http.StatusBadRequest,
+	INTERNAL_ERROR:   http.StatusInternalServerError,
+}
+
+// NewRestRouter serve the resource style api. all call the rpc methods with the same auth.
+func NewRestRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	v1 := router.Group("/v1")
+	v1.POST("/leaves", restAddLeaves)
+	v1.POST("/data", restAddData)
+	v1.GET("/leaves", restFindLeaves)
+	v1.GET("/leaves/:hash", restGetLeaf)
+	v1.GET("/roots/latest", restLatestRoot)
+	v1.GET("/roots", restListRoots)
+	v1.GET("/contract", restContract)
+
+	return router
+}
+
+// pubKey of GET request from header or query.
+func restPubKey(c *gin.Context) string {
+	pubKey := c.GetHeader("X-Witness-PubKey")
+	if len(pubKey) == 0 {
+		pubKey = c.Query("pubKey")
+	}
+	return pubKey
+}
+
+// restRequestAuth signature, timestamp and nonce of signed request from header.
+func restRequestAuth(c *gin.Context) (string, int64, string, bool) {
+	timestamp := int64(0)
+	if s := c.GetHeader("X-Witness-Timestamp"); len(s) != 0 {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			restResponse(c, responsePack(INVALID_PARAM, "X-Witness-Timestamp should be int64"), http.StatusOK)
+			return "", 0, "", false
+		}
+		timestamp = v
+	}
+
+	return c.GetHeader("X-Witness-Signature"), timestamp, c.GetHeader("X-Witness-Nonce"), true
+}
+
+func restQueryUint64(c *gin.Context, name string) (uint64, bool) {
+	s := c.Query(name)
+	if len(s) == 0 {
+		return 0, true
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		restResponse(c, responsePack(INVALID_PARAM, name+" should be uint64"), http.StatusOK)
+		return 0, false
+	}
+
+	return v, true
+}
+
+func restQueryUint32(c *gin.Context, name string) (uint32, bool) {
+	s := c.Query(name)
+	if len(s) == 0 {
+		return 0, true
+	}
+
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		restResponse(c, responsePack(INVALID_PARAM, name+" should be uint32"), http.StatusOK)
+		return 0, false
+	}
+
+	return uint32(v), true
+}
+
+func restCall(c *gin.Context, name string, params interface{}) map[string]interface{} {
+	method := getRpcMethod(name)
+	if method == nil {
+		return responsePack(METHOD_NOT_FOUND, name)
+	}
+
+	return callRpcMethod(c.Request.Context(), method, params)
+}
+
+// restResponse write result on success with okStatus. or JsonRpcError with the http status of errcode.
+func restResponse(c *gin.Context, response map[string]interface{}, okStatus int) {
+	errcode, _ := response["error"].(int64)
+	if errcode == SUCCESS {
+		c.JSON(okStatus, response["result"])
+		return
+	}
+
+	status, ok := restStatusMap[errcode]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	desc, _ := response["desc"].(string)
+	c.JSON(status, &JsonRpcError{
+		Code:    errcode,
+		Message: desc,
+		Data:    response["result"],
+	})
+}
+
+func restAddLeaves(c *gin.Context) {
+	params := &RpcParam{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		log.Infof("restAddLeaves: %s", err)
+		restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+		return
+	}
+
+	restResponse(c, restCall(c, "batchAdd", params), http.StatusAccepted)
+}
+
+// restAddData json of base64 data. or multipart files of field file with the signed request in header. metadata field the json array of each file.
+func restAddData(c *gin.Context) {
+	params := &DataParam{}
+	if c.ContentType() != "multipart/form-data" {
+		err := c.ShouldBindJSON(params)
+		if err != nil {
+			log.Infof("restAddData: %s", err)
+			restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+			return
+		}
+		restResponse(c, restCall(c, "addData", params), http.StatusAccepted)
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		log.Infof("restAddData: %s", err)
+		restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+		return
+	}
+
+	var ok bool
+	params.PubKey = restPubKey(c)
+	params.Signature, params.Timestamp, params.Nonce, ok = restRequestAuth(c)
+	if !ok {
+		return
+	}
+	params.Algorithm = c.PostForm("algorithm")
+	params.IdempotencyKey = c.PostForm("idempotencyKey")
+	// json array of metadata in the order of files.
+	if metadata := c.PostForm("metadata"); len(metadata) != 0 {
+		err = json.Unmarshal([]byte(metadata), &params.Metadata)
+		if err != nil {
+			restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+			return
+		}
+	}
+
+	params.payloads = make([][]byte, 0, len(form.File["file"]))
+	for _, header := range form.File["file"] {
+		f, err := header.Open()
+		if err != nil {
+			restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+			return
+		}
+		payload, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			restResponse(c, responsePack(INVALID_PARAMS, err.Error()), http.StatusAccepted)
+			return
+		}
+		params.payloads = append(params.payloads, payload)
+	}
+
+	restResponse(c, restCall(c, "addData", params), http.StatusAccepted)
+}
+
+type RestLeafResult struct {
+	Status *LeafStatusResult `json:"status"`
+	Proof  *VerifyResult     `json:"proof"`
+}
+
+func restGetLeaf(c *gin.Context) {
+	hash := c.Param("hash")
+	params := &VerifyParam{
+		PubKey: restPubKey(c),
+		Hashes: []string{hash},
+		Root:   c.Query("root"),
+	}
+
+	treeSize, ok := restQueryUint32(c, "treeSize")
+	if !ok {
+		return
+	}
+	params.TreeSize = treeSize
+
+	// the signed request headers are of getLeafStatus. verify of the same pubKey and role not checked again.
+	params.Signature, params.Timestamp, params.Nonce, ok = restRequestAuth(c)
+	if !ok {
+		return
+	}
+
+	response := restCall(c, "getLeafStatus", params)
+	if errcode, _ := response["error"].(int64); errcode != SUCCESS {
+		restResponse(c, response, http.StatusOK)
+		return
+	}
+
+	status := response["result"].([]*LeafStatusResult)[0]
+	if status.Stage == LEAF_STATUS_NOT_FOUND {
+		restResponse(c, responseFailed(VERIFY_FAILED, "leaf not found", nil), http.StatusOK)
+		return
+	}
+
+	res := &RestLeafResult{
+		Status: status,
+	}
+
+	if status.Stage == leafStageName[LEAF_STAGE_ANCHORED] {
+		response = getRpcMethod("verify").Handler(params)
+		if errcode, _ := response["error"].(int64); errcode == SUCCESS {
+			proof := response["result"].(VerifyResult)
+			res.Proof = &proof
+		} else if errcode != VERIFY_FAILED {
+			restResponse(c, response, http.StatusOK)
+			return
+		}
+	}
+
+	restResponse(c, responseSuccess(res), http.StatusOK)
+}
+
+func restServerQuery(c *gin.Context) (*ServerQueryParam, bool) {
+	params := &ServerQueryParam{
+		PubKey: restPubKey(c),
+	}
+
+	var ok bool
+	params.Signature, params.Timestamp, params.Nonce, ok = restRequestAuth(c)
+	return params, ok
+}
+
+func restLatestRoot(c *gin.Context) {
+	params, ok := restServerQuery(c)
+	if !ok {
+		return
+	}
+
+	restResponse(c, restCall(c, "getRoot", params), http.StatusOK)
+}
+
+func restListRoots(c *gin.Context) {
+	params := &RootHistoryParam{
+		PubKey: restPubKey(c),
+	}
+
+	var ok bool
+	params.From, ok = restQueryUint32(c, "from")
+	if !ok {
+		return
+	}
+	params.Limit, ok = restQueryUint32(c, "limit")
+	if !ok {
+		return
+	}
+	params.Signature, params.Timestamp, params.Nonce, ok = restRequestAuth(c)
+	if !ok {
+		return
+	}
+
+	restResponse(c, restCall(c, "getRootHistory", params), http.StatusOK)
+}
+
+func restFindLeaves(c *gin.Context) {
+	params := &FindLeavesParam{
+		PubKey:     restPubKey(c),
+		ExternalId: c.Query("externalId"),
+		Label:      c.Query("label"),
+		Submitter:  c.Query("submitter"),
+		Cursor:     c.Query("cursor"),
+	}
+
+	var ok bool
+	params.From, ok = restQueryUint64(c, "from")
+	if !ok {
+		return
+	}
+	params.To, ok = restQueryUint64(c, "to")
+	if !ok {
+		return
+	}
+	params.Limit, ok = restQueryUint32(c, "limit")
+	if !ok {
+		return
+	}
+	params.Signature, params.Timestamp, params.Nonce, ok = restRequestAuth(c)
+	if !ok {
+		return
+	}
+
+	restResponse(c, restCall(c, "findLeaves", params), http.StatusOK)
+}
+
+func restContract(c *gin.Context) {
+	params, ok := restServerQuery(c)
+	if !ok {
+		return
+	}
+
+	restResponse(c, restCall(c, "GetContractAddress", params), http.StatusOK)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestInvalidQuery(t *testing.T) {
+	router := NewRestRouter()
+	req := httptest.NewRequest("GET", "/v1/roots?from=abc", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	rpcErr := &JsonRpcError{}
+	err := json.Unmarshal(rec.Body.Bytes(), rpcErr)
+	if err != nil || rpcErr.Code != INVALID_PARAM {
+		t.Errorf("body %s, err %v", rec.Body.String(), err)
+	}
+}
+
+func TestRestInvalidBody(t *testing.T) {
+	router := NewRestRouter()
+	req := httptest.NewRequest("POST", "/v1/leaves", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d", rec.Code)
+	}
+}
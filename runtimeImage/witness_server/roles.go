@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ontio/ontology/common"
+)
+
+// KeyRole mask of roles a key has. RpcMethod.Role is the roles any of which may call the method.
+type KeyRole uint32
+
+const (
+	// submit hashes and manage webhooks of own leafs.
+	ROLE_SUBMITTER KeyRole = 1 << 0
+	// verify leafs and read the root.
+	ROLE_VERIFIER KeyRole = 1 << 1
+	// read root history, consistency proofs and batches.
+	ROLE_AUDITOR KeyRole = 1 << 2
+	// key management. only by config adminauthorize.
+	ROLE_ADMIN KeyRole = 1 << 3
+
+	// keys of config authorize and keys added before roles could do everything but admin.
+	ROLE_LEGACY = ROLE_SUBMITTER | ROLE_VERIFIER | ROLE_AUDITOR
+	ROLE_ANY    = ROLE_LEGACY | ROLE_ADMIN
+)
+
+var roleNames = []struct {
+	Role KeyRole
+	Name string
+}{
+	{ROLE_SUBMITTER, "submitter"},
+	{ROLE_VERIFIER, "verifier"},
+	{ROLE_AUDITOR, "auditor"},
+	{ROLE_ADMIN, "admin"},
+}
+
+func (self KeyRole) Names() []string {
+	res := make([]string, 0, len(roleNames))
+	for _, r := range roleNames {
+		if self&r.Role != 0 {
+			res = append(res, r.Name)
+		}
+	}
+	return res
+}
+
+func parseRoles(names []string) (KeyRole, error) {
+	var roles KeyRole
+	for _, name := range names {
+		found := false
+		for _, r := range roleNames {
+			if r.Name == name {
+				roles |= r.Role
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown role %s", name)
+		}
+	}
+
+	return roles, nil
+}
+
+// rolesOfAddress roles of the key if not revoked or expired. admin from config adminauthorize.
+func rolesOfAddress(address common.Address) KeyRole {
+	authorizedKeysLock.RLock()
+	defer authorizedKeysLock.RUnlock()
+
+	var roles KeyRole
+	key, ok := authorizedKeys[address]
+	if ok && key.valid(uint64(time.Now().Unix())) {
+		roles = key.Roles
+	}
+	if adminKeys[address] {
+		roles |= ROLE_ADMIN
+	}
+
+	return roles
+}
+
+// checkRoleOfAddress address has any of roles.
+func checkRoleOfAddress(address common.Address, roles KeyRole) bool {
+	return rolesOfAddress(address)&roles != 0
+}
+
+type RolesResult struct {
+	Address string   `json:"address"`
+	Roles   []string `json:"roles"`
+	Methods []string `json:"methods"`
+}
+
+// pubKey and signature checked by rpc dispatch. methods the key may call.
+func rpcGetRoles(qargs *ServerQueryParam) map[string]interface{} {
+	address := addressOfPubKey(qargs.PubKey)
+	roles := rolesOfAddress(address)
+
+	res := &RolesResult{
+		Address: address.ToBase58(),
+		Roles:   roles.Names(),
+		Methods: make([]string, 0),
+	}
+
+	rpcMethodsLock.RLock()
+	for _, method := range rpcMethods {
+		if method.Auth == RPC_AUTH_NONE || method.Role&roles != 0 {
+			res.Methods = append(res.Methods, method.Name)
+		}
+	}
+	rpcMethodsLock.RUnlock()
+	sort.Strings(res.Methods)
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getRoles",
+		Desc:       "get the roles of the signing key and the methods it may call.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_ANY,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ServerQueryParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetRoles(params.(*ServerQueryParam)) },
+	})
+}
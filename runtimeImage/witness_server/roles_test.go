@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+)
+
+func TestParseRoles(t *testing.T) {
+	roles, err := parseRoles([]string{"verifier", "auditor"})
+	if err != nil || roles != ROLE_VERIFIER|ROLE_AUDITOR {
+		t.Fatalf("roles %v err %v", roles, err)
+	}
+	if names := roles.Names(); len(names) != 2 || names[0] != "verifier" || names[1] != "auditor" {
+		t.Errorf("names %v", names)
+	}
+
+	_, err = parseRoles([]string{"root"})
+	if err == nil {
+		t.Errorf("unknown role parsed")
+	}
+
+	param := &AuthorizedKeyParam{Roles: []string{"admin"}}
+	_, err = param.parseRoles()
+	if err == nil {
+		t.Errorf("admin role granted by request")
+	}
+}
+
+func TestAuthorizedKeyLegacyRoles(t *testing.T) {
+	key := &AuthorizedKey{Address: common.ADDRESS_EMPTY, Roles: ROLE_VERIFIER}
+	sink := common.NewZeroCopySink(nil)
+	key.Serialization(sink)
+
+	// record without roles.
+	raw := sink.Bytes()
+	old := &AuthorizedKey{}
+	err := old.Deserialization(common.NewZeroCopySource(raw[:len(raw)-4]))
+	if err != nil || old.Roles != ROLE_LEGACY {
+		t.Fatalf("legacy roles %v err %v", old.Roles, err)
+	}
+
+	res := &AuthorizedKey{}
+	err = res.Deserialization(common.NewZeroCopySource(raw))
+	if err != nil || res.Roles != ROLE_VERIFIER {
+		t.Fatalf("roles %v err %v", res.Roles, err)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	defaultRootHistoryLimit uint32 = 20
+	maxRootHistoryLimit     uint32 = 100
+)
+
+type RootInfo struct {
+	Root        common.Uint256
+	TreeSize    uint32
+	BlockHeight uint32
+	TxHash      common.Uint256
+}
+
+type RootInfoJson struct {
+	Root        string `json:"root"`
+	TreeSize    uint32 `json:"size"`
+	BlockHeight uint32 `json:"blockheight"`
+	TxHash      string `json:"txHash"`
+}
+
+func (self *RootInfo) toJson() *RootInfoJson {
+	return &RootInfoJson{
+		Root:        hex.EncodeToString(self.Root[:]),
+		TreeSize:    self.TreeSize,
+		BlockHeight: self.BlockHeight,
+		TxHash:      txHashString(self.TxHash),
+	}
+}
+
+// key of root history ordered by tree size.
+func getRootHistoryKey(treeSize uint32) []byte {
+	key := make([]byte, 5)
+	key[0] = byte(PREFIX_ROOT_HISTORY)
+	binary.BigEndian.PutUint32(key[1:], treeSize)
+	return key
+}
+
+// putRootInfo extend putRootBlockHeight value with tree size and tx hash. and index the root by tree size.
+func putRootInfo(store *leveldbstore.LevelDBStore, root common.Uint256, treeSize uint32, height uint32, txh common.Uint256) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(height)
+	sink.WriteUint32(treeSize)
+	sink.WriteHash(txh)
+	store.BatchPut(GetKeyByHash(PREFIX_ROOT_HEIGHT, root), sink.Bytes())
+	store.BatchPut(getRootHistoryKey(treeSize), root[:])
+}
+
+// roots anchored before putRootInfo only have block height. TreeSize 0 for them.
+func getRootInfo(store *leveldbstore.LevelDBStore, root common.Uint256) (*RootInfo, error) {
+	val, err := store.Get(GetKeyByHash(PREFIX_ROOT_HEIGHT, root))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RootInfo{Root: root}
+	source := common.NewZeroCopySource(val)
+	height, eof := source.NextUint32()
+	if eof {
+		return nil, errors.New("getRootInfo: error decode block height.")
+	}
+	info.BlockHeight = height
+
+	treeSize, eof := source.NextUint32()
+	txh, eof := source.NextHash()
+	if !eof {
+		info.TreeSize = treeSize
+		info.TxHash = txh
+	}
+
+	return info, nil
+}
+
+// backfillRootHistory index roots anchored before putRootInfo by the tree size after the last leaf of their tx. run once.
+func backfillRootHistory(store *leveldbstore.LevelDBStore) error {
+	done := GetKeyByHash(PREFIX_ROOT_HISTORY_BACKFILL, merkle.EMPTY_HASH)
+	_, err := store.Get(done)
+	if err == nil {
+		return nil
+	}
+
+	type txRoot struct {
+		treeSize uint32
+		height   uint32
+	}
+	txRoots := make(map[string]*txRoot)
+	skipped := 0
+
+	iter := store.NewIterator([]byte{byte(PREFIX_INDEX)})
+	for iter.Next() {
+		leaf, err := common.Uint256ParseFromBytes(iter.Key()[1:])
+		if err != nil {
+			continue
+		}
+		index, height, txHash, err := getLeafInfo(store, leaf)
+		if err != nil || len(txHash) == 0 {
+			skipped++
+			continue
+		}
+		r, ok := txRoots[txHash]
+		if !ok {
+			r = &txRoot{height: height}
+			txRoots[txHash] = r
+		}
+		if index+1 > r.treeSize {
+			r.treeSize = index + 1
+		}
+	}
+	iter.Release()
+	err = iter.Error()
+	if err != nil {
+		return err
+	}
+
+	store.NewBatch()
+	count := 0
+	for txHash, r := range txRoots {
+		_, err := store.Get(getRootHistoryKey(r.treeSize))
+		if err == nil {
+			continue
+		}
+		txh, err := common.Uint256FromHexString(txHash)
+		if err != nil {
+			continue
+		}
+		root, err := rootAtSize(r.treeSize)
+		if err != nil {
+			return err
+		}
+		info, err := getRootInfo(store, root)
+		if err != nil || info.TreeSize != 0 {
+			continue
+		}
+		putRootInfo(store, root, r.treeSize, r.height, txh)
+		count++
+	}
+	store.BatchPut(done, []byte{1})
+	err = store.BatchCommit()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("backfillRootHistory: %d roots indexed. %d leafs without tx skipped.", count, skipped)
+	return nil
+}
+
+// verifyTarget get the root, tree size and block height to verify against. the current root if no target set. caller must hold MTlock.
+func verifyTarget(vargs *VerifyParam) (common.Uint256, uint32, uint32, error) {
+	if len(vargs.Root) == 0 && vargs.TreeSize == 0 {
+		root := DefMerkleTree.Root()
+		treeSize := DefMerkleTree.TreeSize()
+		height, err := getRootBlockHeight(DefStore, root)
+		return root, treeSize, height, err
+	}
+
+	var root common.Uint256
+	var treeSize uint32
+	var err error
+	if vargs.TreeSize != 0 {
+		if vargs.TreeSize > DefMerkleTree.TreeSize() {
+			return merkle.EMPTY_HASH, 0, 0, fmt.Errorf("treeSize %d bigger than current %d", vargs.TreeSize, DefMerkleTree.TreeSize())
+		}
+		treeSize = vargs.TreeSize
+		root, err = rootAtSize(treeSize)
+		if err != nil {
+			return merkle.EMPTY_HASH, 0, 0, err
+		}
+	}
+
+	if len(vargs.Root) != 0 {
+		target, err := HashFromHexString(vargs.Root)
+		if err != nil {
+			return merkle.EMPTY_HASH, 0, 0, err
+		}
+		if vargs.TreeSize != 0 && target != root {
+			return merkle.EMPTY_HASH, 0, 0, fmt.Errorf("root %s not the root of treeSize %d", vargs.Root, vargs.TreeSize)
+		}
+
+		if vargs.TreeSize == 0 {
+			info, err := getRootInfo(DefStore, target)
+			if err != nil {
+				return merkle.EMPTY_HASH, 0, 0, fmt.Errorf("root %s not anchored", vargs.Root)
+			}
+			if info.TreeSize == 0 {
+				return merkle.EMPTY_HASH, 0, 0, fmt.Errorf("tree size of root %s not recorded. set treeSize", vargs.Root)
+			}
+			treeSize = info.TreeSize
+		}
+		root = target
+	}
+
+	height, err := getRootBlockHeight(DefStore, root)
+	if err != nil {
+		return merkle.EMPTY_HASH, 0, 0, fmt.Errorf("root %x not anchored", root)
+	}
+
+	return root, treeSize, height, nil
+}
+
+type RootHistoryParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	From      uint32 `json:"from,omitempty"`
+	Limit     uint32 `json:"limit,omitempty"`
+}
+
+func (self *RootHistoryParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *RootHistoryParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *RootHistoryParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *RootHistoryParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of from and limit.
+func (self *RootHistoryParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(self.From)
+	sink.WriteUint32(self.Limit)
+	return sink.Bytes(), nil
+}
+
+type RootHistoryResult struct {
+	Roots []*RootInfoJson `json:"roots"`
+	// tree size to continue from. 0 if no more.
+	Next uint32 `json:"next"`
+}
+
+// listRootHistory list anchored roots with tree size not less than from in tree size order.
+func listRootHistory(store *leveldbstore.LevelDBStore, from uint32, limit uint32) (*RootHistoryResult, error) {
+	res := &RootHistoryResult{
+		Roots: make([]*RootInfoJson, 0, limit),
+	}
+
+	iter := store.NewIterator([]byte{byte(PREFIX_ROOT_HISTORY)})
+	defer iter.Release()
+
+	for ok := iter.Seek(getRootHistoryKey(from)); ok; ok = iter.Next() {
+		if uint32(len(res.Roots)) == limit {
+			res.Next = binary.BigEndian.Uint32(iter.Key()[1:])
+			break
+		}
+
+		root, err := common.Uint256ParseFromBytes(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := getRootInfo(store, root)
+		if err != nil {
+			return nil, err
+		}
+		res.Roots = append(res.Roots, info.toJson())
+	}
+
+	return res, iter.Error()
+}
+
+// signed request checked by rpc dispatch.
+func rpcGetRootHistory(hargs *RootHistoryParam) map[string]interface{} {
+	limit := hargs.Limit
+	if limit == 0 {
+		limit = defaultRootHistoryLimit
+	}
+	if limit > maxRootHistoryLimit {
+		return responsePack(INVALID_PARAM, fmt.Sprintf("limit most %d", maxRootHistoryLimit))
+	}
+
+	res, err := listRootHistory(DefStore, hargs.From, limit)
+	if err != nil {
+		log.Errorf("getRootHistory: %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+
+	return responseSuccess(res)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getRootHistory",
+		Desc:       "list anchored roots with tree size, block height and tx hash. paged by tree size.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &RootHistoryParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetRootHistory(params.(*RootHistoryParam)) },
+	})
+}
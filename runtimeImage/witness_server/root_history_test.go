@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/merkle"
+)
+
+func TestRootHistoryBackfill(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldTree, oldHashStore := DefStore, DefMerkleTree, FileHashStore
+	defer func() { DefStore, DefMerkleTree, FileHashStore = oldStore, oldTree, oldHashStore }()
+	hashStore := NewMemHashStore()
+	DefStore, FileHashStore = store, hashStore
+	DefMerkleTree = merkle.NewTree(0, nil, hashStore)
+
+	// tx 1 and 2 anchored before root history. tx 3 after.
+	txs := []struct {
+		txh    common.Uint256
+		leafs  uint32
+		height uint32
+	}{{common.Uint256{1}, 2, 10}, {common.Uint256{2}, 3, 11}, {common.Uint256{3}, 1, 12}}
+	roots := make([]common.Uint256, 0)
+	sink := common.NewZeroCopySink(nil)
+	store.NewBatch()
+	for i, tx := range txs {
+		for j := uint32(0); j < tx.leafs; j++ {
+			sink.Reset()
+			sink.WriteUint32(DefMerkleTree.TreeSize())
+			leaf := hashLeaf(sink.Bytes())
+			putLeafIndex(store, leaf, DefMerkleTree.TreeSize(), tx.height, tx.txh.ToHexString())
+			DefMerkleTree.AppendHash(leaf)
+		}
+		root := DefMerkleTree.Root()
+		roots = append(roots, root)
+		if i < 2 {
+			putRootBlockHeight(store, root, tx.height)
+		} else {
+			putRootInfo(store, root, DefMerkleTree.TreeSize(), tx.height, tx.txh)
+		}
+	}
+	store.BatchCommit()
+
+	err = backfillRootHistory(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := listRootHistory(store, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Roots) != 2 || res.Next != 6 {
+		t.Fatalf("first page %d roots next %d", len(res.Roots), res.Next)
+	}
+	sizes := []uint32{2, 5}
+	for i, info := range res.Roots {
+		if info.Root != common.ToHexString(roots[i][:]) || info.TreeSize != sizes[i] || info.BlockHeight != txs[i].height || info.TxHash != txs[i].txh.ToHexString() {
+			t.Errorf("backfilled root %d %+v", i, info)
+		}
+	}
+
+	res, err = listRootHistory(store, res.Next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Roots) != 1 || res.Roots[0].TreeSize != 6 || res.Next != 0 {
+		t.Errorf("last page %+v next %d", res.Roots, res.Next)
+	}
+
+	res, _ = listRootHistory(store, 3, 10)
+	if len(res.Roots) != 2 || res.Roots[0].TreeSize != 5 {
+		t.Errorf("from 3 %+v", res.Roots)
+	}
+
+	// backfill runs once.
+	store.Delete(getRootHistoryKey(2))
+	err = backfillRootHistory(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(getRootHistoryKey(2)); err == nil {
+		t.Errorf("backfill run twice")
+	}
+}
@@ -20,87 +20,308 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	//"fmt"
+	"fmt"
 	"github.com/ontio/ontology/common/log"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 const MAX_REQUEST_BODY_SIZE = 1 << 20
 
-//JsonRpcRequest object in rpc
+const (
+	DEFAULT_MAX_BATCH_LENGTH uint32 = 64
+	MAX_BATCH_CONCURRENCY    int    = 8
+)
+
+//JsonRpc version
+const JSON_RPC_VERSION = "2.0"
+
+//JsonRpcRequest object in rpc. Id and Params are kept raw so that any id type can be echoed and params errors reported as INVALID_PARAMS.
 type JsonRpcRequest struct {
-	Version string   `json:"jsonrpc"`
-	Id      string   `json:"id"`
-	Method  string   `json:"method"`
-	Params  RpcParam `json:"params"`
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
 }
 
+//RpcParam params of batchAdd. Version 0 is the legacy signature over leafs. Timestamp and Nonce since version 1.
 type RpcParam struct {
-	PubKey   string   `json:"pubKey"`
-	Sigature string   `json:"signature"`
-	Hashes   []string `json:"hashes"`
+	PubKey         string   `json:"pubKey"`
+	Sigature       string   `json:"signature"`
+	Hashes         []string `json:"hashes"`
+	Version        uint32   `json:"version,omitempty"`
+	Timestamp      int64    `json:"timestamp,omitempty"`
+	Nonce          string   `json:"nonce,omitempty"`
+	IdempotencyKey string   `json:"idempotencyKey,omitempty"`
+	// none or one of each hash.
+	Metadata []*LeafMetadataParam `json:"metadata,omitempty"`
+}
+
+//JsonRpcError object in rpc response
+type JsonRpcError struct {
+	Code    int64       `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // this is the function that should be called in order to answer an rpc call
-// should be registered like "http.HandleFunc("/", httpjsonrpc.Handle)"
+// should be registered like "http.HandleFunc("/", httpjsonrpc.Handle)". cors and preflight by CorsHandler
 func RpcHandle(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "OPTIONS" {
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		return
-	}
 	//JSON RPC commands should be POSTs
 	if r.Method != "POST" {
 		log.Error("HTTP JSON RPC Handle - Method!=\"POST\"")
+		writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, "http method should be POST"))
 		return
 	}
 	//check if there is Request Body to read
 	if r.Body == nil {
 		log.Error("HTTP JSON RPC Handle - Request body is nil")
+		writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, "request body is nil"))
 		return
 	}
 
-	var request JsonRpcRequest
 	defer r.Body.Close()
-	decoder := json.NewDecoder(io.LimitReader(r.Body, MAX_REQUEST_BODY_SIZE))
-	err := decoder.Decode(&request)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxRequestBodySize())+1))
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - read body: ", err)
+		writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	if len(body) > maxRequestBodySize() {
+		log.Error("HTTP JSON RPC Handle - request body too large")
+		writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, "request body too large"))
+		return
+	}
+
+	if !json.Valid(body) {
+		log.Error("HTTP JSON RPC Handle - invalid json body")
+		writeRpcResponse(w, rpcErrorResponse(nil, PARSE_ERROR, nil))
+		return
+	}
+
+	if isRpcBatch(body) {
+		if DefConfig.MaxBatchBodySize != 0 && len(body) > int(DefConfig.MaxBatchBodySize) {
+			log.Error("HTTP JSON RPC Handle - batch body too large")
+			writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, "batch body too large"))
+			return
+		}
+
+		var batch []json.RawMessage
+		err = json.Unmarshal(body, &batch)
+		if err != nil {
+			log.Error("HTTP JSON RPC Handle - json.Unmarshal batch: ", err)
+			writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, err.Error()))
+			return
+		}
+
+		response := handleRpcBatch(r.Context(), batch)
+		if response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeRpcResponse(w, response)
+		return
+	}
+
+	var request JsonRpcRequest
+	err = json.Unmarshal(body, &request)
 	if err != nil {
 		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+		writeRpcResponse(w, rpcErrorResponse(nil, INVALID_REQUEST, err.Error()))
 		return
 	}
 
-	var response map[string]interface{}
+	writeRpcResponse(w, handleRpcRequest(r.Context(), &request))
+}
+
+func handleRpcRequest(ctx context.Context, request *JsonRpcRequest) map[string]interface{} {
+	if !checkRpcId(request.Id) {
+		return rpcErrorResponse(nil, INVALID_REQUEST, "id should be string, number or null")
+	}
+
+	if request.Version != JSON_RPC_VERSION || len(request.Method) == 0 {
+		return rpcErrorResponse(request.Id, INVALID_REQUEST, "jsonrpc should be 2.0 and method not empty")
+	}
 
-	if request.Method == "verify" {
-		response = rpcVerify(&request.Params)
-	} else if request.Method == "batchAdd" {
-		response = rpcBatchAdd(&request.Params)
-	} else if request.Method == "getRoot" {
-		response = rpcGetRoot()
-	} else if request.Method == "GetContractAddress" {
-		response = rpcGetContractAddress()
-	} else {
+	method := getRpcMethod(request.Method)
+	if method == nil {
 		log.Warn("HTTP JSON RPC Handle - No function to call for ", request.Method)
-		response = responsePack(INVALID_PARAM, "wrong Method name.only verify or batchAdd")
+		return rpcErrorResponse(request.Id, METHOD_NOT_FOUND, request.Method)
+	}
+
+	var params interface{}
+	if method.NewParams != nil {
+		params = method.NewParams()
+		if len(request.Params) != 0 && string(request.Params) != "null" {
+			err := json.Unmarshal(request.Params, params)
+			if err != nil {
+				log.Infof("HTTP JSON RPC Handle - params of %s: %s", request.Method, err)
+				return rpcErrorResponse(request.Id, INVALID_PARAMS, err.Error())
+			}
+		}
+	}
+
+	response := callRpcMethod(ctx, method, params)
+	return packRpcResponse(request.Id, response)
+}
+
+func isRpcBatch(body []byte) bool {
+	for _, c := range body {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		}
+		return false
+	}
+
+	return false
+}
+
+func maxRpcBatchLength() int {
+	if DefConfig.MaxBatchLength == 0 {
+		return int(DEFAULT_MAX_BATCH_LENGTH)
+	}
+
+	return int(DefConfig.MaxBatchLength)
+}
+
+// handleRpcBatch execute batch call. response in request order without the notifications. nil if all notifications.
+// consecutive read only methods run concurrently. others in order one by one.
+func handleRpcBatch(ctx context.Context, batch []json.RawMessage) interface{} {
+	if len(batch) == 0 {
+		return rpcErrorResponse(nil, INVALID_REQUEST, "empty batch")
+	}
+
+	if len(batch) > maxRpcBatchLength() {
+		return rpcErrorResponse(nil, INVALID_REQUEST, fmt.Sprintf("too much batch request. most %d.", maxRpcBatchLength()))
+	}
+
+	responses := make([]map[string]interface{}, len(batch))
+	requests := make([]*JsonRpcRequest, len(batch))
+	for i, raw := range batch {
+		request := &JsonRpcRequest{}
+		err := json.Unmarshal(raw, request)
+		if err != nil {
+			responses[i] = rpcErrorResponse(nil, INVALID_REQUEST, err.Error())
+			continue
+		}
+		requests[i] = request
+	}
+
+	var batchWg sync.WaitGroup
+	sem := make(chan bool, MAX_BATCH_CONCURRENCY)
+	for i, request := range requests {
+		if request == nil {
+			continue
+		}
+
+		// write waits the reads before it. and the reads after it wait it.
+		if !isRpcConcurrent(request.Method) {
+			batchWg.Wait()
+			responses[i] = handleRpcRequest(ctx, request)
+			continue
+		}
+
+		batchWg.Add(1)
+		sem <- true
+		go func(i int, request *JsonRpcRequest) {
+			defer batchWg.Done()
+			responses[i] = handleRpcRequest(ctx, request)
+			<-sem
+		}(i, request)
+	}
+	batchWg.Wait()
+
+	res := make([]map[string]interface{}, 0, len(responses))
+	for i, response := range responses {
+		if requests[i] != nil && isRpcNotification(requests[i]) {
+			continue
+		}
+		res = append(res, response)
 	}
 
-	data, err := json.Marshal(map[string]interface{}{
-		"jsonrpc": "2.0",
-		"error":   response["error"],
-		"desc":    response["desc"],
-		"result":  response["result"],
-		"id":      request.Id,
-	})
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
+}
+
+// isRpcNotification valid request without id. no response of it in batch.
+func isRpcNotification(request *JsonRpcRequest) bool {
+	return len(request.Id) == 0 && request.Version == JSON_RPC_VERSION && len(request.Method) != 0
+}
 
+// id may be string, number, null or absent. absent is answered as null.
+func checkRpcId(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+
+	return false
+}
+
+func rpcErrorResponse(id json.RawMessage, errcode int64, data interface{}) map[string]interface{} {
+	return packRpcResponse(id, responsePack(errcode, data))
+}
+
+// packRpcResponse convert the responsePack result to JSON-RPC 2.0 response. or the legacy {error, desc, result} if DefConfig.LegacyResponse set.
+func packRpcResponse(id json.RawMessage, response map[string]interface{}) map[string]interface{} {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	if DefConfig.LegacyResponse {
+		return map[string]interface{}{
+			"jsonrpc": JSON_RPC_VERSION,
+			"error":   response["error"],
+			"desc":    response["desc"],
+			"result":  response["result"],
+			"id":      id,
+		}
+	}
+
+	errcode, _ := response["error"].(int64)
+	if errcode == SUCCESS {
+		return map[string]interface{}{
+			"jsonrpc": JSON_RPC_VERSION,
+			"result":  response["result"],
+			"id":      id,
+		}
+	}
+
+	desc, _ := response["desc"].(string)
+	return map[string]interface{}{
+		"jsonrpc": JSON_RPC_VERSION,
+		"error": &JsonRpcError{
+			Code:    errcode,
+			Message: desc,
+			Data:    response["result"],
+		},
+		"id": id,
+	}
+}
+
+func writeRpcResponse(w http.ResponseWriter, response interface{}) {
+	data, err := json.Marshal(response)
 	if err != nil {
 		log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-		return
+		data, _ = json.Marshal(rpcErrorResponse(nil, INTERNAL_ERROR, nil))
 	}
-	w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
+
 	w.Header().Set("content-type", "application/json;charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Write(data)
 }
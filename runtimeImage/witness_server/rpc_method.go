@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+)
+
+type RpcAuth uint32
+
+const (
+	// no authorize needed.
+	RPC_AUTH_NONE RpcAuth = 0
+	// pubKey in params must be authorized.
+	RPC_AUTH_PUBKEY RpcAuth = 1
+	// pubKey authorized and signature over SignData must verify.
+	RPC_AUTH_SIGNATURE RpcAuth = 2
+	// pubKey authorized and signature over the canonical request must verify. timestamp in skew window and nonce not replayed.
+	RPC_AUTH_REQUEST RpcAuth = 3
+)
+
+var rpcAuthName = map[RpcAuth]string{
+	RPC_AUTH_NONE:      "none",
+	RPC_AUTH_PUBKEY:    "pubkey",
+	RPC_AUTH_SIGNATURE: "signature",
+	RPC_AUTH_REQUEST:   "request",
+}
+
+// RpcAuthParam must be implemented by params of the method which Auth is not RPC_AUTH_NONE.
+type RpcAuthParam interface {
+	GetPubKey() string
+	GetSignature() string
+	// data signed by the pubKey.
+	SignData() ([]byte, error)
+}
+
+// RpcMethod describe one method of rpc. NewParams return a pointer to the typed params struct of the method. nil if no params.
+// Role is the roles any of which the pubKey must have. needed if Auth is not RPC_AUTH_NONE.
+type RpcMethod struct {
+	Name       string
+	Desc       string
+	Auth       RpcAuth
+	Role       KeyRole
+	Concurrent bool
+	NewParams  func() interface{}
+	Handler    func(params interface{}) map[string]interface{}
+}
+
+var (
+	rpcMethods     = make(map[string]*RpcMethod)
+	rpcMethodsLock sync.RWMutex
+)
+
+func RegisterRpcMethod(method *RpcMethod) {
+	rpcMethodsLock.Lock()
+	defer rpcMethodsLock.Unlock()
+
+	if _, ok := rpcMethods[method.Name]; ok {
+		panic(fmt.Sprintf("RegisterRpcMethod: method %s already registered", method.Name))
+	}
+	if method.Handler == nil {
+		panic(fmt.Sprintf("RegisterRpcMethod: method %s handler nil", method.Name))
+	}
+	if method.Role != 0 && method.Auth == RPC_AUTH_NONE {
+		panic(fmt.Sprintf("RegisterRpcMethod: method %s has role but no auth", method.Name))
+	}
+	if method.Auth != RPC_AUTH_NONE {
+		if method.Role == 0 {
+			panic(fmt.Sprintf("RegisterRpcMethod: method %s need auth but no role", method.Name))
+		}
+		if method.NewParams == nil {
+			panic(fmt.Sprintf("RegisterRpcMethod: method %s need auth but no params", method.Name))
+		}
+		if _, ok := method.NewParams().(RpcAuthParam); !ok {
+			panic(fmt.Sprintf("RegisterRpcMethod: method %s params not RpcAuthParam", method.Name))
+		}
+		if _, ok := method.NewParams().(RpcRequestAuthParam); method.Auth == RPC_AUTH_REQUEST && !ok {
+			panic(fmt.Sprintf("RegisterRpcMethod: method %s params not RpcRequestAuthParam", method.Name))
+		}
+	}
+
+	rpcMethods[method.Name] = method
+}
+
+func getRpcMethod(name string) *RpcMethod {
+	rpcMethodsLock.RLock()
+	defer rpcMethodsLock.RUnlock()
+	return rpcMethods[name]
+}
+
+func isRpcConcurrent(name string) bool {
+	method := getRpcMethod(name)
+	return method != nil && method.Concurrent
+}
+
+// callRpcMethod check auth of method then call the handler. params must be the type returned by method.NewParams.
+func callRpcMethod(ctx context.Context, method *RpcMethod, params interface{}) map[string]interface{} {
+	response := checkRpcAuth(ctx, method.Name, method.Auth, method.Role, params)
+	if response != nil {
+		metricAuthFailures.WithLabelValues(method.Name).Inc()
+		return response
+	}
+
+	return method.Handler(params)
+}
+
+// checkRpcAuth name is the method signed in canonical request of RPC_AUTH_REQUEST. pubKey must have any of role. and be the tls identity of ctx if bound.
+func checkRpcAuth(ctx context.Context, name string, auth RpcAuth, role KeyRole, params interface{}) map[string]interface{} {
+	if auth == RPC_AUTH_NONE {
+		return nil
+	}
+
+	authParam, ok := params.(RpcAuthParam)
+	if !ok {
+		return responsePack(INTERNAL_ERROR, "params not support auth")
+	}
+
+	pubkey, sigData, err := getPublicSigData(authParam.GetPubKey(), authParam.GetSignature())
+	if err != nil {
+		log.Infof("%s", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	address := types.AddressFromPubKey(pubkey)
+	err = checkTlsBinding(ctx, address)
+	if err != nil {
+		return responsePack(NO_AUTH, err.Error())
+	}
+	if !checkRoleOfAddress(address, role) {
+		return responsePack(NO_AUTH, fmt.Sprintf("pubkey do not have role %s.", strings.Join(role.Names(), " or ")))
+	}
+
+	if auth == RPC_AUTH_PUBKEY {
+		return nil
+	}
+
+	// RegisterRpcMethod ensure params of RPC_AUTH_REQUEST is RpcRequestAuthParam.
+	var verifyData []byte
+	requestParam, _ := params.(RpcRequestAuthParam)
+	if auth == RPC_AUTH_REQUEST {
+		verifyData, err = requestSignData(name, requestParam)
+	} else {
+		verifyData, err = authParam.SignData()
+	}
+	if err != nil {
+		log.Infof("sign data of params err: %s", err)
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	err = signature.Verify(pubkey, verifyData, sigData)
+	if err != nil {
+		return responsePack(NO_AUTH, "Verify failed. sigData not right.")
+	}
+
+	// RpcReplayParam check the time and nonce itself.
+	replayParam, replayOk := params.(RpcReplayParam)
+	if auth == RPC_AUTH_REQUEST && !replayOk {
+		err = checkRequestAuth(requestParam)
+		if err != nil {
+			return responsePack(NO_AUTH, err.Error())
+		}
+	}
+
+	if replayOk {
+		err = replayParam.CheckReplay(address)
+		if err != nil {
+			return responsePack(NO_AUTH, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (self *RpcParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *RpcParam) GetSignature() string {
+	return self.Sigature
+}
+
+// VerifyParam params of verify. Root or TreeSize set to verify against the history anchored root. Signature, Timestamp and Nonce only needed by signed request methods.
+type VerifyParam struct {
+	PubKey    string   `json:"pubKey"`
+	Signature string   `json:"signature,omitempty"`
+	Timestamp int64    `json:"timestamp,omitempty"`
+	Nonce     string   `json:"nonce,omitempty"`
+	Hashes    []string `json:"hashes"`
+	Root      string   `json:"root,omitempty"`
+	TreeSize  uint32   `json:"treeSize,omitempty"`
+}
+
+func (self *VerifyParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *VerifyParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *VerifyParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *VerifyParam) GetNonce() string {
+	return self.Nonce
+}
+
+// canonical of hashes, root and treeSize.
+func (self *VerifyParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(self.Hashes)))
+	for _, h := range self.Hashes {
+		sink.WriteString(h)
+	}
+	sink.WriteString(self.Root)
+	sink.WriteUint32(self.TreeSize)
+	return sink.Bytes(), nil
+}
+
+// RpcMethodInfo is the result of rpc.discover for each method.
+type RpcMethodInfo struct {
+	Name       string      `json:"name"`
+	Desc       string      `json:"desc"`
+	Auth       string      `json:"auth"`
+	Roles      []string    `json:"roles,omitempty"`
+	Concurrent bool        `json:"concurrent"`
+	Params     interface{} `json:"params"`
+}
+
+func rpcDiscover(params interface{}) map[string]interface{} {
+	rpcMethodsLock.RLock()
+	defer rpcMethodsLock.RUnlock()
+
+	res := make([]*RpcMethodInfo, 0, len(rpcMethods))
+	for _, method := range rpcMethods {
+		info := &RpcMethodInfo{
+			Name:       method.Name,
+			Desc:       method.Desc,
+			Auth:       rpcAuthName[method.Auth],
+			Roles:      method.Role.Names(),
+			Concurrent: method.Concurrent,
+		}
+		if method.NewParams != nil {
+			info.Params = schemaOfType(reflect.TypeOf(method.NewParams()))
+		}
+		res = append(res, info)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return responseSuccess(res)
+}
+
+// schemaOfType generate a json schema like description of params type from json tags.
+func schemaOfType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOfType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOfType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name := field.Name
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				name = opts[0]
+			}
+			properties[name] = schemaOfType(field.Type)
+			omitempty := false
+			for _, o := range opts[1:] {
+				if o == "omitempty" {
+					omitempty = true
+				}
+			}
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+
+	return map[string]interface{}{}
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "verify",
+		Desc:       "get the inclusion proof of one leaf hash against current root. or history root/treeSize.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_VERIFIER,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &VerifyParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcVerify(params.(*VerifyParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "batchAdd",
+		Desc:      "add leaf hashes signed by authorized pubKey. version 1 signs the envelope of contract, tenant, timestamp, nonce, hashes digest, idempotency key and metadata digest. retry of idempotency key returns the recorded response. metadata of each hash recorded with the pubKey and submit time.",
+		Auth:      RPC_AUTH_SIGNATURE,
+		Role:      ROLE_SUBMITTER,
+		NewParams: func() interface{} { return &RpcParam{} },
+		Handler:   func(params interface{}) map[string]interface{} { return rpcBatchAdd(params.(*RpcParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getRoot",
+		Desc:       "get root and tree size from chain contract.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_SUBMITTER | ROLE_VERIFIER | ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ServerQueryParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetRoot() },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "GetContractAddress",
+		Desc:       "get the contract address of server.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_SUBMITTER | ROLE_VERIFIER | ROLE_AUDITOR,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ServerQueryParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetContractAddress() },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "rpc.discover",
+		Desc:       "list methods with params schema and auth.",
+		Concurrent: true,
+		Handler:    rpcDiscover,
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "listMethods",
+		Desc:       "same as rpc.discover.",
+		Concurrent: true,
+		Handler:    rpcDiscover,
+	})
+}
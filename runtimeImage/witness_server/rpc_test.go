@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// calls of test.read and test.write in batch. read end after a while.
+var (
+	testRpcCalls     []string
+	testRpcCallsLock sync.Mutex
+)
+
+func testRpcCall(call string) {
+	testRpcCallsLock.Lock()
+	defer testRpcCallsLock.Unlock()
+	testRpcCalls = append(testRpcCalls, call)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "test.read",
+		Concurrent: true,
+		Handler: func(params interface{}) map[string]interface{} {
+			time.Sleep(20 * time.Millisecond)
+			testRpcCall("read")
+			return responseSuccess(nil)
+		},
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name: "test.write",
+		Handler: func(params interface{}) map[string]interface{} {
+			testRpcCall("write")
+			return responseSuccess(nil)
+		},
+	})
+}
+
+func doRpcRequest(t *testing.T, method string, body string) map[string]interface{} {
+	req := httptest.NewRequest(method, "/", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	RpcHandle(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	res := make(map[string]interface{})
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatalf("response %s: %s", rec.Body.String(), err)
+	}
+
+	return res
+}
+
+func rpcErrorCode(t *testing.T, res map[string]interface{}) int64 {
+	rpcErr, ok := res["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no error object in %v", res)
+	}
+
+	return int64(rpcErr["code"].(float64))
+}
+
+func TestRpcHandleErrors(t *testing.T) {
+	cases := []struct {
+		method string
+		body   string
+		code   int64
+	}{
+		{"GET", "", INVALID_REQUEST},
+		{"POST", "{", PARSE_ERROR},
+		{"POST", `"verify"`, INVALID_REQUEST},
+		{"POST", `{"jsonrpc":"1.0","id":"1","method":"verify"}`, INVALID_REQUEST},
+		{"POST", `{"jsonrpc":"2.0","id":{},"method":"verify"}`, INVALID_REQUEST},
+		{"POST", `{"jsonrpc":"2.0","id":"1","method":"verify","params":[1]}`, INVALID_PARAMS},
+		{"POST", `{"jsonrpc":"2.0","id":"1","method":"nothing"}`, METHOD_NOT_FOUND},
+	}
+
+	for _, c := range cases {
+		res := doRpcRequest(t, c.method, c.body)
+		if code := rpcErrorCode(t, res); code != c.code {
+			t.Errorf("%s %s: code %d, expect %d", c.method, c.body, code, c.code)
+		}
+		if _, ok := res["result"]; ok {
+			t.Errorf("%s %s: result should not set with error", c.method, c.body)
+		}
+	}
+}
+
+func TestRpcHandleEchoId(t *testing.T) {
+	for _, id := range []string{`"abc"`, `12`, `null`} {
+		res := doRpcRequest(t, "POST", `{"jsonrpc":"2.0","id":`+id+`,"method":"nothing"}`)
+		raw, _ := json.Marshal(res["id"])
+		if string(raw) != id {
+			t.Errorf("id %s echo as %s", id, raw)
+		}
+	}
+
+	res := doRpcRequest(t, "POST", `{"jsonrpc":"2.0","method":"nothing"}`)
+	if v, ok := res["id"]; !ok || v != nil {
+		t.Errorf("absent id should echo null. %v", res)
+	}
+}
+
+func TestRpcHandleLegacy(t *testing.T) {
+	DefConfig.LegacyResponse = true
+	defer func() { DefConfig.LegacyResponse = false }()
+
+	res := doRpcRequest(t, "POST", `{"jsonrpc":"2.0","id":"1","method":"nothing"}`)
+	if int64(res["error"].(float64)) != METHOD_NOT_FOUND || res["desc"] != ErrMap[METHOD_NOT_FOUND] {
+		t.Errorf("legacy response error %v", res)
+	}
+}
+
+func TestRpcHandleBatch(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(` [{"jsonrpc":"2.0","id":1,"method":"nothing"}, 3, {"jsonrpc":"2.0","id":"2","method":"nothing"}]`))
+	rec := httptest.NewRecorder()
+	RpcHandle(rec, req)
+
+	res := make([]map[string]interface{}, 0)
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatalf("response %s: %s", rec.Body.String(), err)
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("batch response len %d", len(res))
+	}
+
+	if res[0]["id"] != float64(1) || rpcErrorCode(t, res[0]) != METHOD_NOT_FOUND {
+		t.Errorf("batch response 0: %v", res[0])
+	}
+	if res[1]["id"] != nil || rpcErrorCode(t, res[1]) != INVALID_REQUEST {
+		t.Errorf("batch response 1: %v", res[1])
+	}
+	if res[2]["id"] != "2" || rpcErrorCode(t, res[2]) != METHOD_NOT_FOUND {
+		t.Errorf("batch response 2: %v", res[2])
+	}
+}
+
+func TestRpcHandleBatchOrder(t *testing.T) {
+	testRpcCalls = nil
+	body := `[{"jsonrpc":"2.0","id":1,"method":"test.read"},{"jsonrpc":"2.0","method":"test.write"},` +
+		`{"jsonrpc":"2.0","id":3,"method":"test.read"},{"jsonrpc":"2.0","id":4,"method":"test.read"},{"jsonrpc":"2.0","id":5,"method":"test.write"}]`
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	RpcHandle(rec, req)
+
+	if calls := strings.Join(testRpcCalls, ","); calls != "read,write,read,read,write" {
+		t.Errorf("batch calls in order %s", calls)
+	}
+
+	res := make([]map[string]interface{}, 0)
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatalf("response %s: %s", rec.Body.String(), err)
+	}
+	if len(res) != 4 || res[0]["id"] != float64(1) || res[1]["id"] != float64(3) || res[3]["id"] != float64(5) {
+		t.Errorf("notification response not omitted %v", res)
+	}
+
+	req = httptest.NewRequest("POST", "/", bytes.NewBufferString(`[{"jsonrpc":"2.0","method":"test.write"},{"jsonrpc":"2.0","method":"nothing"}]`))
+	rec = httptest.NewRecorder()
+	RpcHandle(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("batch of notifications responded %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRpcHandleBatchLimit(t *testing.T) {
+	DefConfig.MaxBatchLength = 2
+	defer func() { DefConfig.MaxBatchLength = 0 }()
+
+	for _, body := range []string{`[]`, `[{},{},{}]`} {
+		res := doRpcRequest(t, "POST", body)
+		if code := rpcErrorCode(t, res); code != INVALID_REQUEST {
+			t.Errorf("%s: code %d", body, code)
+		}
+	}
+}
+
+func TestRpcDiscover(t *testing.T) {
+	res := doRpcRequest(t, "POST", `{"jsonrpc":"2.0","id":1,"method":"rpc.discover"}`)
+	methods, ok := res["result"].([]interface{})
+	if !ok {
+		t.Fatalf("discover result %v", res)
+	}
+
+	found := false
+	for _, m := range methods {
+		info := m.(map[string]interface{})
+		if info["name"] != "batchAdd" {
+			continue
+		}
+		found = true
+		if info["auth"] != "signature" {
+			t.Errorf("batchAdd auth %v", info["auth"])
+		}
+		if roles, _ := info["roles"].([]interface{}); len(roles) != 1 || roles[0] != "submitter" {
+			t.Errorf("batchAdd roles %v", info["roles"])
+		}
+		props := info["params"].(map[string]interface{})["properties"].(map[string]interface{})
+		if props["hashes"].(map[string]interface{})["type"] != "array" {
+			t.Errorf("batchAdd hashes schema %v", props["hashes"])
+		}
+	}
+
+	if !found {
+		t.Errorf("batchAdd not in discover %v", methods)
+	}
+}
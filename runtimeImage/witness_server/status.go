@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ontio/ontology/common/log"
+)
+
+var (
+	outOfServiceReason string
+	outOfServiceLock   sync.Mutex
+	// leafs buffered in cacheLeafs not construct to tx yet.
+	cachedLeafNum int64
+)
+
+// setOutOfService stop the service. only the first reason kept. it is the one triggered.
+func setOutOfService(reason string) {
+	outOfServiceLock.Lock()
+	defer outOfServiceLock.Unlock()
+
+	if !SystemOutOfService {
+		outOfServiceReason = reason
+	}
+	SystemOutOfService = true
+}
+
+func getOutOfServiceReason() string {
+	outOfServiceLock.Lock()
+	defer outOfServiceLock.Unlock()
+	return outOfServiceReason
+}
+
+type ServerStatus struct {
+	OutOfService         bool     `json:"outOfService"`
+	OutOfServiceReason   string   `json:"outOfServiceReason,omitempty"`
+	LocalHeight          uint32   `json:"localHeight"`
+	ChainHeight          uint32   `json:"chainHeight"`
+	PendingTxNum         uint32   `json:"pendingTxNum"`
+	CachedLeafNum        uint32   `json:"cachedLeafNum"`
+	LocalRoot            string   `json:"localRoot"`
+	LocalTreeSize        uint32   `json:"localTreeSize"`
+	ChainRoot            string   `json:"chainRoot"`
+	ChainTreeSize        uint32   `json:"chainTreeSize"`
+	FileHashAppendFailed bool     `json:"fileHashAppendFailed"`
+	SignerAddress        string   `json:"signerAddress"`
+	Errors               []string `json:"errors,omitempty"`
+}
+
+// ready to serve. not out of service and chain reachable.
+func (self *ServerStatus) Ready() bool {
+	return !self.OutOfService && len(self.Errors) == 0
+}
+
+func pendingTxNum() uint32 {
+	num := uint32(0)
+	TxStore.Txhashes.Range(func(k, v interface{}) bool {
+		num++
+		return true
+	})
+	return num
+}
+
+// getServerStatus query chain once. not retry as getRoot so probes not blocked.
+func getServerStatus() *ServerStatus {
+	status := &ServerStatus{
+		OutOfService:         SystemOutOfService,
+		OutOfServiceReason:   getOutOfServiceReason(),
+		PendingTxNum:         pendingTxNum(),
+		CachedLeafNum:        uint32(atomic.LoadInt64(&cachedLeafNum)),
+		FileHashAppendFailed: lastFileHashAppendFailed,
+		SignerAddress:        DefConfig.SignerAddress,
+		Errors:               make([]string, 0),
+	}
+
+	MTlock.RLock()
+	root := DefMerkleTree.Root()
+	status.LocalRoot = hex.EncodeToString(root[:])
+	status.LocalTreeSize = DefMerkleTree.TreeSize()
+	MTlock.RUnlock()
+
+	localHeight, err := getCurrentLocalBlockHeight(DefStore)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("local height: %s", err))
+	}
+	status.LocalHeight = localHeight
+
+	chainHeight, err := DefSdk.GetCurrentBlockHeight()
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("chain height: %s", err))
+	}
+	status.ChainHeight = chainHeight
+
+	chainRoot, chainTreeSize, err := preExecRoot(DefSdk, DefVerifyTx)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("chain root: %s", err))
+	} else {
+		status.ChainRoot = hex.EncodeToString(chainRoot[:])
+		status.ChainTreeSize = chainTreeSize
+	}
+
+	return status
+}
+
+func rpcGetServerStatus() map[string]interface{} {
+	return responseSuccess(getServerStatus())
+}
+
+func writeStatusResponse(w http.ResponseWriter, ok bool, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("writeStatusResponse: %s", err)
+		ok = false
+	}
+
+	w.Header().Set("content-type", "application/json;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data)
+}
+
+// HealthzHandle liveness probe. not query chain. 503 if out of service.
+func HealthzHandle(w http.ResponseWriter, r *http.Request) {
+	res := map[string]interface{}{
+		"outOfService": SystemOutOfService,
+	}
+	if SystemOutOfService {
+		res["outOfServiceReason"] = getOutOfServiceReason()
+	}
+
+	writeStatusResponse(w, !SystemOutOfService, res)
+}
+
+// StatusHandle readiness probe. full status. 503 if out of service or chain not reachable.
+func StatusHandle(w http.ResponseWriter, r *http.Request) {
+	status := getServerStatus()
+	writeStatusResponse(w, status.Ready(), status)
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:       "getServerStatus",
+		Desc:       "get out of service reason, sync height, pending txs, cached leafs, local and chain root.",
+		Auth:       RPC_AUTH_REQUEST,
+		Role:       ROLE_AUDITOR | ROLE_ADMIN,
+		Concurrent: true,
+		NewParams:  func() interface{} { return &ServerQueryParam{} },
+		Handler:    func(params interface{}) map[string]interface{} { return rpcGetServerStatus() },
+	})
+}
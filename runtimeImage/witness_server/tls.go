@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// client certificate verified if sent.
+	TLS_CLIENT_AUTH_OPTIONAL = "optional"
+	// client certificate must be sent.
+	TLS_CLIENT_AUTH_REQUIRE = "require"
+
+	tlsReloadInterval = 10 * time.Second
+)
+
+// TlsReloader keep the server certificate and client CAs loaded from files. reload when files changed. keep the old ones if reload failed.
+type TlsReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   string
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamp     string
+}
+
+var DefTlsReloader *TlsReloader
+
+// client certificate sha256 fingerprint to identity address.
+var tlsClientIdentities map[string]common.Address
+
+func NewTlsReloader(certFile, keyFile, clientCAFile, clientAuth string) (*TlsReloader, error) {
+	switch clientAuth {
+	case "", TLS_CLIENT_AUTH_OPTIONAL, TLS_CLIENT_AUTH_REQUIRE:
+	default:
+		return nil, fmt.Errorf("tls client auth %s should be empty, %s or %s", clientAuth, TLS_CLIENT_AUTH_OPTIONAL, TLS_CLIENT_AUTH_REQUIRE)
+	}
+	if len(clientAuth) != 0 && len(clientCAFile) == 0 {
+		return nil, errors.New("tls client auth need client CA file")
+	}
+
+	self := &TlsReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		clientAuth:   clientAuth,
+	}
+
+	_, err := self.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return self, nil
+}
+
+// fileStamp changed if any file modified.
+func (self *TlsReloader) fileStamp() (string, error) {
+	stamp := ""
+	for _, f := range []string{self.certFile, self.keyFile, self.clientCAFile} {
+		if len(f) == 0 {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+
+	return stamp, nil
+}
+
+// Reload load the files if changed since last load. return true if reloaded.
+func (self *TlsReloader) Reload() (bool, error) {
+	stamp, err := self.fileStamp()
+	if err != nil {
+		return false, err
+	}
+
+	self.lock.RLock()
+	same := stamp == self.stamp
+	self.lock.RUnlock()
+	if same {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(self.certFile, self.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	var clientCAs *x509.CertPool
+	if len(self.clientCAFile) != 0 {
+		pem, err := ioutil.ReadFile(self.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no certificate in client CA file %s", self.clientCAFile)
+		}
+	}
+
+	self.lock.Lock()
+	self.cert = &cert
+	self.clientCAs = clientCAs
+	self.stamp = stamp
+	self.lock.Unlock()
+
+	return true, nil
+}
+
+func (self *TlsReloader) Run(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		reloaded, err := self.Reload()
+		if err != nil {
+			log.Errorf("TlsReloader: %s. keep the old certificate", err)
+			continue
+		}
+		if reloaded {
+			log.Infof("TlsReloader: certificate reloaded from %s", self.certFile)
+		}
+	}
+}
+
+func (self *TlsReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.cert, nil
+}
+
+// verifyClientCertificate verify by the current client CAs. so CA file reload without restart.
+func (self *TlsReloader) verifyClientCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	self.lock.RLock()
+	opts := x509.VerifyOptions{
+		Roots:         self.clientCAs,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	self.lock.RUnlock()
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// Config of both rpc and grpc listener.
+func (self *TlsReloader) Config() *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: self.GetCertificate,
+	}
+
+	switch self.clientAuth {
+	case TLS_CLIENT_AUTH_OPTIONAL:
+		config.ClientAuth = tls.RequestClientCert
+		config.VerifyPeerCertificate = self.verifyClientCertificate
+	case TLS_CLIENT_AUTH_REQUIRE:
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyPeerCertificate = self.verifyClientCertificate
+	}
+
+	return config
+}
+
+func parseTlsClientIdentities(identities map[string]string) (map[string]common.Address, error) {
+	res := make(map[string]common.Address)
+	for fp, s := range identities {
+		addr, err := common.AddressFromBase58(s)
+		if err != nil {
+			return nil, fmt.Errorf("tls client identity %s: %s", s, err)
+		}
+		res[strings.ToLower(fp)] = addr
+	}
+
+	return res, nil
+}
+
+// InitTls start the reloader if tls cert set.
+func InitTls() error {
+	if len(DefConfig.TlsCertFile) == 0 {
+		if len(DefConfig.TlsKeyFile) != 0 || len(DefConfig.TlsClientAuth) != 0 {
+			return errors.New("tls config set without tlscertfile")
+		}
+		return nil
+	}
+
+	identities, err := parseTlsClientIdentities(DefConfig.TlsClientIdentity)
+	if err != nil {
+		return err
+	}
+
+	reloader, err := NewTlsReloader(DefConfig.TlsCertFile, DefConfig.TlsKeyFile, DefConfig.TlsClientCAFile, DefConfig.TlsClientAuth)
+	if err != nil {
+		return err
+	}
+
+	tlsClientIdentities = identities
+	DefTlsReloader = reloader
+	go reloader.Run(tlsReloadInterval)
+
+	log.Infof("InitTls: cert %s, client auth %s", DefConfig.TlsCertFile, DefConfig.TlsClientAuth)
+	return nil
+}
+
+// tlsIdentity address of the client certificate. by configured fingerprint, or the subject common name as base58 address.
+func tlsIdentity(cert *x509.Certificate) (common.Address, error) {
+	fp := sha256.Sum256(cert.Raw)
+	if addr, ok := tlsClientIdentities[hex.EncodeToString(fp[:])]; ok {
+		return addr, nil
+	}
+
+	addr, err := common.AddressFromBase58(cert.Subject.CommonName)
+	if err != nil {
+		return common.ADDRESS_EMPTY, errors.New("client certificate not mapped to identity")
+	}
+
+	return addr, nil
+}
+
+// checkTlsIdentity the client certificate if sent must map to a key which has any role. false if not sent.
+func checkTlsIdentity(state *tls.ConnectionState) (common.Address, bool, error) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return common.ADDRESS_EMPTY, false, nil
+	}
+
+	address, err := tlsIdentity(state.PeerCertificates[0])
+	if err != nil {
+		return common.ADDRESS_EMPTY, false, err
+	}
+	if rolesOfAddress(address) == 0 {
+		return common.ADDRESS_EMPTY, false, fmt.Errorf("identity %s of client certificate not authorized", address.ToBase58())
+	}
+
+	return address, true, nil
+}
+
+type tlsIdentityKey struct{}
+
+func withTlsIdentity(ctx context.Context, address common.Address) context.Context {
+	return context.WithValue(ctx, tlsIdentityKey{}, address)
+}
+
+func tlsIdentityOf(ctx context.Context) (common.Address, bool) {
+	address, ok := ctx.Value(tlsIdentityKey{}).(common.Address)
+	return address, ok
+}
+
+// checkTlsBinding the pubKey of request must be the identity of client certificate if sent.
+func checkTlsBinding(ctx context.Context, address common.Address) error {
+	identity, ok := tlsIdentityOf(ctx)
+	if ok && identity != address {
+		return fmt.Errorf("pubKey %s is not the identity %s of client certificate", address.ToBase58(), identity.ToBase58())
+	}
+
+	return nil
+}
+
+// TlsIdentityHandler reject the client certificate not authorized. the identity bound to request context.
+func TlsIdentityHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address, ok, err := checkTlsIdentity(r.TLS)
+		if err != nil {
+			log.Infof("TlsIdentityHandler: %s from %s", err, r.RemoteAddr)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if ok {
+			r = r.WithContext(withTlsIdentity(r.Context(), address))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func grpcTlsIdentity(ctx context.Context) (context.Context, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx, nil
+	}
+
+	address, ok, err := checkTlsIdentity(&info.State)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if ok {
+		ctx = withTlsIdentity(ctx, address)
+	}
+
+	return ctx, nil
+}
+
+// grpcIdentityStream stream with the identity bound context.
+type grpcIdentityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (self *grpcIdentityStream) Context() context.Context {
+	return self.ctx
+}
+
+func grpcTlsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := grpcTlsIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func grpcTlsStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := grpcTlsIdentity(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpcIdentityStream{ServerStream: stream, ctx: ctx})
+}
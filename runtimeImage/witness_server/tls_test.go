@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/common"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, serial int64, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (self *testCert) write(t *testing.T, certFile, keyFile string) {
+	err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: self.der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyFile) == 0 {
+		return
+	}
+
+	raw, err := x509.MarshalECPrivateKey(self.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (self *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{self.der}, PrivateKey: self.key}
+}
+
+func TestTlsReloaderReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "witness_tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+	ca := newTestCert(t, 1, "ca", nil, 0)
+	newTestCert(t, 2, "server", ca, x509.ExtKeyUsageServerAuth).write(t, certFile, keyFile)
+
+	reloader, err := NewTlsReloader(certFile, keyFile, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := reloader.Reload()
+	if err != nil || reloaded {
+		t.Fatalf("reload unchanged files %v %v", reloaded, err)
+	}
+
+	newTestCert(t, 3, "server", ca, x509.ExtKeyUsageServerAuth).write(t, certFile, keyFile)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	reloaded, err = reloader.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("reload changed files %v %v", reloaded, err)
+	}
+
+	cert, _ := reloader.GetCertificate(nil)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil || leaf.SerialNumber.Int64() != 3 {
+		t.Fatalf("certificate not reloaded %v", err)
+	}
+
+	// broken file keep the old certificate.
+	ioutil.WriteFile(keyFile, []byte("broken"), 0600)
+	os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute))
+	_, err = reloader.Reload()
+	if err == nil {
+		t.Fatalf("broken key loaded")
+	}
+	if c, _ := reloader.GetCertificate(nil); c != cert {
+		t.Errorf("old certificate lost")
+	}
+}
+
+func TestTlsClientIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "witness_tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+
+	ca := newTestCert(t, 1, "ca", nil, 0)
+	ca.write(t, caFile, "")
+	newTestCert(t, 2, "server", ca, x509.ExtKeyUsageServerAuth).write(t, certFile, keyFile)
+
+	address := common.AddressFromVmCode([]byte("tls client"))
+	authorizedKeysLock.Lock()
+	old := authorizedKeys
+	authorizedKeys = map[common.Address]*AuthorizedKey{address: {Address: address, Roles: ROLE_VERIFIER}}
+	authorizedKeysLock.Unlock()
+	defer func() {
+		authorizedKeysLock.Lock()
+		authorizedKeys = old
+		authorizedKeysLock.Unlock()
+	}()
+
+	reloader, err := NewTlsReloader(certFile, keyFile, caFile, TLS_CLIENT_AUTH_REQUIRE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go http.Serve(listener, TlsIdentityHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCerts ...tls.Certificate) (int, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: clientCerts},
+		}}
+		resp, err := client.Get("https://" + listener.Addr().String() + "/")
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	code, err := get(newTestCert(t, 3, address.ToBase58(), ca, x509.ExtKeyUsageClientAuth).tlsCert())
+	if err != nil || code != http.StatusOK {
+		t.Errorf("authorized identity %d %v", code, err)
+	}
+
+	code, err = get(newTestCert(t, 4, "unknown", ca, x509.ExtKeyUsageClientAuth).tlsCert())
+	if err != nil || code != http.StatusForbidden {
+		t.Errorf("unmapped identity %d %v", code, err)
+	}
+
+	other := newTestCert(t, 5, "other ca", nil, 0)
+	if _, err = get(newTestCert(t, 6, address.ToBase58(), other, x509.ExtKeyUsageClientAuth).tlsCert()); err == nil {
+		t.Errorf("certificate of other CA accepted")
+	}
+
+	if _, err = get(); err == nil {
+		t.Errorf("no client certificate accepted")
+	}
+}
+
+func TestTlsIdentityBinding(t *testing.T) {
+	acc, pubKey, restore := newTestAccount(ROLE_AUDITOR)
+	defer restore()
+
+	newParam := func(nonce string) *RootHistoryParam {
+		param := &RootHistoryParam{PubKey: pubKey, Timestamp: time.Now().Unix(), Nonce: nonce}
+		param.Signature = signTestRequest(t, acc, "getRootHistory", param)
+		return param
+	}
+
+	ctx := withTlsIdentity(context.Background(), common.AddressFromVmCode([]byte("other client")))
+	if res := checkRpcAuth(ctx, "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, newParam("bind other")); res == nil || res["error"] != NO_AUTH {
+		t.Errorf("pubKey of other tls identity accepted %v", res)
+	}
+
+	ctx = withTlsIdentity(context.Background(), acc.Address)
+	if res := checkRpcAuth(ctx, "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, newParam("bind same")); res != nil {
+		t.Errorf("pubKey of tls identity %v", res)
+	}
+
+	if res := checkRpcAuth(context.Background(), "getRootHistory", RPC_AUTH_REQUEST, ROLE_AUDITOR, newParam("bind none")); res != nil {
+		t.Errorf("no client certificate %v", res)
+	}
+
+	sess := &wsSession{ctx: withTlsIdentity(context.Background(), common.AddressFromVmCode([]byte("other client"))), challenge: []byte("challenge")}
+	sig, err := acc.Sign(sess.challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := sess.auth(&WsRequest{PubKey: pubKey, Signature: hex.EncodeToString(sig)}); msg.Error != NO_AUTH {
+		t.Errorf("ws auth of other tls identity %+v", msg)
+	}
+}
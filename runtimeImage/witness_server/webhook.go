@@ -0,0 +1,654 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/merkle"
+)
+
+const (
+	webhookTimeout        = 10 * time.Second
+	webhookPollInterval   = 5 * time.Second
+	webhookBaseBackoff    = 5 * time.Second
+	webhookMaxBackoff     = time.Hour
+	webhookMaxAttempts    = 24
+	webhookQueueSize      = 64
+	webhookWorkerIdle     = time.Minute
+	webhookMaxUrlLength   = 2048
+	webhookMaxSecretBytes = 256
+)
+
+type Webhook struct {
+	Url     string
+	Secret  string
+	Created uint64
+}
+
+func (self *Webhook) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(self.Url)
+	sink.WriteString(self.Secret)
+	sink.WriteUint64(self.Created)
+}
+
+func (self *Webhook) Deserialization(source *common.ZeroCopySource) error {
+	u, _, irregular, eof := source.NextString()
+	secret, _, irregular, eof := source.NextString()
+	created, eof := source.NextUint64()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	self.Url = u
+	self.Secret = secret
+	self.Created = created
+	return nil
+}
+
+// WebhookOutboxEntry one pending delivery. removed after delivered or webhookMaxAttempts.
+type WebhookOutboxEntry struct {
+	Seq      uint64
+	Address  common.Address
+	Attempts uint32
+	NextTime uint64
+	Payload  []byte
+}
+
+func (self *WebhookOutboxEntry) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(self.Address[:])
+	sink.WriteUint32(self.Attempts)
+	sink.WriteUint64(self.NextTime)
+	sink.WriteVarBytes(self.Payload)
+}
+
+func (self *WebhookOutboxEntry) Deserialization(source *common.ZeroCopySource) error {
+	addr, _, irregular, eof := source.NextVarBytes()
+	attempts, eof := source.NextUint32()
+	nextTime, eof := source.NextUint64()
+	payload, _, irregular, eof := source.NextVarBytes()
+	if irregular || eof {
+		return io.ErrUnexpectedEOF
+	}
+
+	address, err := common.AddressParseFromBytes(addr)
+	if err != nil {
+		return err
+	}
+
+	self.Address = address
+	self.Attempts = attempts
+	self.NextTime = nextTime
+	self.Payload = payload
+	return nil
+}
+
+type WebhookLeaf struct {
+	Leaf  string `json:"leaf"`
+	Index uint32 `json:"index"`
+}
+
+type WebhookPayload struct {
+	Id          uint64        `json:"id"`
+	Event       string        `json:"event"`
+	Address     string        `json:"address"`
+	Leafs       []WebhookLeaf `json:"leafs"`
+	Root        string        `json:"root"`
+	TreeSize    uint32        `json:"size"`
+	BlockHeight uint32        `json:"blockheight"`
+	TxHash      string        `json:"txHash"`
+	Timestamp   uint64        `json:"timestamp"`
+}
+
+var (
+	webhooks         = make(map[common.Address]*Webhook)
+	webhooksLock     sync.RWMutex
+	webhookOutboxSeq uint64
+	webhookNotify    = make(chan bool, 1)
+	// dial checked again so the host resolved to internal address after registered not reached.
+	webhookClient = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: webhookTimeout,
+				Control: webhookDialControl,
+			}).DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+
+	webhookWorkers     = make(map[string]*webhookWorker)
+	webhookInflight    = make(map[uint64]bool)
+	webhookWorkersLock sync.Mutex
+)
+
+func getWebhookKey(address common.Address) []byte {
+	return append([]byte{byte(PREFIX_WEBHOOK)}, address[:]...)
+}
+
+func getWebhookOutboxKey(seq uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = byte(PREFIX_WEBHOOK_OUTBOX)
+	binary.BigEndian.PutUint64(key[1:], seq)
+	return key
+}
+
+func getWebhookOutboxSeqKey() []byte {
+	return GetKeyByHash(PREFIX_WEBHOOK_OUTBOX_SEQ, merkle.EMPTY_HASH)
+}
+
+// InitWebhook load the registered webhooks and the last outbox seq.
+func InitWebhook(store *leveldbstore.LevelDBStore) error {
+	iter := store.NewIterator([]byte{byte(PREFIX_WEBHOOK)})
+	for iter.Next() {
+		address, err := common.AddressParseFromBytes(iter.Key()[1:])
+		if err != nil {
+			iter.Release()
+			return err
+		}
+
+		hook := &Webhook{}
+		err = hook.Deserialization(common.NewZeroCopySource(iter.Value()))
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		webhooks[address] = hook
+	}
+	iter.Release()
+
+	// outbox may be empty after all delivered. seq of payload id never reused.
+	val, err := store.Get(getWebhookOutboxSeqKey())
+	if err == nil {
+		seq, eof := common.NewZeroCopySource(val).NextUint64()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		webhookOutboxSeq = seq
+	}
+
+	iterOut := store.NewIterator([]byte{byte(PREFIX_WEBHOOK_OUTBOX)})
+	if iterOut.Last() {
+		seq := binary.BigEndian.Uint64(iterOut.Key()[1:])
+		if seq > webhookOutboxSeq {
+			webhookOutboxSeq = seq
+		}
+	}
+	iterOut.Release()
+
+	log.Infof("InitWebhook: %d webhooks. outbox seq %d", len(webhooks), webhookOutboxSeq)
+	return nil
+}
+
+func getWebhook(address common.Address) *Webhook {
+	webhooksLock.RLock()
+	defer webhooksLock.RUnlock()
+	return webhooks[address]
+}
+
+// enqueueWebhooks put outbox entries of anchored event to store batch for each submitter of the leafs which registered webhook.
+func enqueueWebhooks(store *leveldbstore.LevelDBStore, ev *AnchorEvent) {
+	webhooksLock.RLock()
+	empty := len(webhooks) == 0
+	webhooksLock.RUnlock()
+	if empty {
+		return
+	}
+
+	leafsOf := make(map[common.Address][]WebhookLeaf)
+	for i, leaf := range ev.Leafs {
+		submitter, err := getLeafSubmitter(store, leaf)
+		if err != nil || getWebhook(submitter) == nil {
+			continue
+		}
+		leafsOf[submitter] = append(leafsOf[submitter], WebhookLeaf{
+			Leaf:  hex.EncodeToString(leaf[:]),
+			Index: ev.StartIndex + uint32(i),
+		})
+	}
+
+	now := uint64(time.Now().Unix())
+	var seq uint64
+	for address, leafs := range leafsOf {
+		seq = atomic.AddUint64(&webhookOutboxSeq, 1)
+		payload, err := json.Marshal(&WebhookPayload{
+			Id:          seq,
+			Event:       ANCHOR_EVENT_ANCHORED,
+			Address:     address.ToBase58(),
+			Leafs:       leafs,
+			Root:        hex.EncodeToString(ev.Root[:]),
+			TreeSize:    ev.TreeSize,
+			BlockHeight: ev.BlockHeight,
+			TxHash:      ev.TxHash.ToHexString(),
+			Timestamp:   now,
+		})
+		if err != nil {
+			log.Errorf("enqueueWebhooks: %s", err)
+			continue
+		}
+
+		entry := &WebhookOutboxEntry{
+			Seq:      seq,
+			Address:  address,
+			NextTime: now,
+			Payload:  payload,
+		}
+		sink := common.NewZeroCopySink(nil)
+		entry.Serialization(sink)
+		store.BatchPut(getWebhookOutboxKey(seq), sink.Bytes())
+	}
+
+	if seq != 0 {
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteUint64(seq)
+		store.BatchPut(getWebhookOutboxSeqKey(), sink.Bytes())
+	}
+}
+
+// notifyWebhook wake RoutineOfWebhook after outbox committed.
+func notifyWebhook() {
+	select {
+	case webhookNotify <- true:
+	default:
+	}
+}
+
+func webhookBackoff(attempts uint32) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := uint32(1); i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+// webhookRequest signed by server signer. and hmac of secret if webhook has.
+func webhookRequest(hook *Webhook, payload []byte) (*http.Request, error) {
+	sigData, err := DefSigner.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", hook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Witness-PubKey", hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())))
+	req.Header.Set("X-Witness-Signature", hex.EncodeToString(sigData))
+	if len(hook.Secret) != 0 {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(payload)
+		req.Header.Set("X-Witness-Hmac-Sha256", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return req, nil
+}
+
+func deliverWebhook(hook *Webhook, payload []byte) error {
+	req, err := webhookRequest(hook, payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, MAX_REQUEST_BODY_SIZE))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// handleWebhookEntry deliver entry. error only if should retry later.
+func handleWebhookEntry(entry *WebhookOutboxEntry) error {
+	hook := getWebhook(entry.Address)
+	if hook == nil {
+		log.Infof("RoutineOfWebhook: webhook of %s removed. drop %d", entry.Address.ToBase58(), entry.Seq)
+		DefStore.Delete(getWebhookOutboxKey(entry.Seq))
+		return nil
+	}
+
+	err := deliverWebhook(hook, entry.Payload)
+	if err == nil {
+		DefStore.Delete(getWebhookOutboxKey(entry.Seq))
+		return nil
+	}
+
+	entry.Attempts++
+	if entry.Attempts >= webhookMaxAttempts {
+		log.Errorf("RoutineOfWebhook: %d to %s failed %d times. drop. %s", entry.Seq, hook.Url, entry.Attempts, err)
+		DefStore.Delete(getWebhookOutboxKey(entry.Seq))
+		return err
+	}
+
+	entry.NextTime = uint64(time.Now().Add(webhookBackoff(entry.Attempts)).Unix())
+	log.Warnf("RoutineOfWebhook: %d to %s failed %d times. %s", entry.Seq, hook.Url, entry.Attempts, err)
+	sink := common.NewZeroCopySink(nil)
+	entry.Serialization(sink)
+	perr := DefStore.Put(getWebhookOutboxKey(entry.Seq), sink.Bytes())
+	if perr != nil {
+		log.Errorf("RoutineOfWebhook: update %d: %s", entry.Seq, perr)
+	}
+	return err
+}
+
+// webhookWorker deliver the entries of one url in order. a slow or failing endpoint only delay itself.
+type webhookWorker struct {
+	url      string
+	queue    chan *WebhookOutboxEntry
+	failures uint32
+}
+
+func (self *webhookWorker) run() {
+	for {
+		select {
+		case entry := <-self.queue:
+			self.deliver(entry)
+			webhookWorkersLock.Lock()
+			delete(webhookInflight, entry.Seq)
+			webhookWorkersLock.Unlock()
+		case <-time.After(webhookWorkerIdle):
+			webhookWorkersLock.Lock()
+			if len(self.queue) == 0 {
+				delete(webhookWorkers, self.url)
+				webhookWorkersLock.Unlock()
+				return
+			}
+			webhookWorkersLock.Unlock()
+		}
+	}
+}
+
+// deliver back off the endpoint after failed so the entries queued behind not fail at once.
+func (self *webhookWorker) deliver(entry *WebhookOutboxEntry) {
+	err := handleWebhookEntry(entry)
+	if err == nil {
+		self.failures = 0
+		return
+	}
+
+	self.failures++
+	time.Sleep(webhookBackoff(self.failures))
+}
+
+// dispatchWebhookEntry queue entry to the worker of url. false if queued before or the queue full, then dispatched again next poll.
+func dispatchWebhookEntry(url string, entry *WebhookOutboxEntry) bool {
+	webhookWorkersLock.Lock()
+	defer webhookWorkersLock.Unlock()
+	if webhookInflight[entry.Seq] {
+		return false
+	}
+
+	worker, ok := webhookWorkers[url]
+	if !ok {
+		worker = &webhookWorker{
+			url:   url,
+			queue: make(chan *WebhookOutboxEntry, webhookQueueSize),
+		}
+		webhookWorkers[url] = worker
+		go worker.run()
+	}
+
+	select {
+	case worker.queue <- entry:
+		webhookInflight[entry.Seq] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatchWebhookEntries dispatch the due entries of outbox to the worker of their webhook url.
+func dispatchWebhookEntries(store *leveldbstore.LevelDBStore, now uint64) error {
+	removed := make([]uint64, 0)
+	iter := store.NewIterator([]byte{byte(PREFIX_WEBHOOK_OUTBOX)})
+	for iter.Next() {
+		entry := &WebhookOutboxEntry{
+			Seq: binary.BigEndian.Uint64(iter.Key()[1:]),
+		}
+		err := entry.Deserialization(common.NewZeroCopySource(iter.Value()))
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		if entry.NextTime > now {
+			continue
+		}
+
+		hook := getWebhook(entry.Address)
+		if hook == nil {
+			removed = append(removed, entry.Seq)
+			continue
+		}
+		dispatchWebhookEntry(hook.Url, entry)
+	}
+	iter.Release()
+
+	for _, seq := range removed {
+		log.Infof("RoutineOfWebhook: webhook removed. drop %d", seq)
+		store.Delete(getWebhookOutboxKey(seq))
+	}
+
+	return iter.Error()
+}
+
+// RoutineOfWebhook deliver the outbox. entries stay in leveldb until delivered so restart not lose.
+func RoutineOfWebhook() {
+	for {
+		if SystemOutOfService {
+			return
+		}
+
+		err := dispatchWebhookEntries(DefStore, uint64(time.Now().Unix()))
+		if err != nil {
+			log.Errorf("RoutineOfWebhook: %s", err)
+		}
+
+		select {
+		case <-webhookNotify:
+		case <-time.After(webhookPollInterval):
+		}
+	}
+}
+
+type WebhookParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Url       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+func (self *WebhookParam) GetPubKey() string {
+	return self.PubKey
+}
+
+func (self *WebhookParam) GetSignature() string {
+	return self.Signature
+}
+
+func (self *WebhookParam) GetTimestamp() int64 {
+	return self.Timestamp
+}
+
+func (self *WebhookParam) GetNonce() string {
+	return self.Nonce
+}
+
+// url and secret signed in the canonical request. empty url to unregister.
+func (self *WebhookParam) SignData() ([]byte, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(self.Url)
+	sink.WriteString(self.Secret)
+	return sink.Bytes(), nil
+}
+
+// CheckReplay nonce persisted as batchAdd. so a removed url not registered again by replay after restart.
+func (self *WebhookParam) CheckReplay(address common.Address) error {
+	err := checkRequestTime(self.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	return checkBatchAddNonce(DefStore, address, self.Nonce, self.Timestamp)
+}
+
+func webhookAddress(pubKey string) (common.Address, error) {
+	pubkey, _, err := getPublicSigData(pubKey, "")
+	if err != nil {
+		return common.ADDRESS_EMPTY, err
+	}
+
+	return types.AddressFromPubKey(pubkey), nil
+}
+
+// webhookIpAllowed false for the address of server internal network.
+func webhookIpAllowed(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+// webhookDialControl check the resolved address when connect.
+func webhookDialControl(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookIpAllowed(ip) {
+		return fmt.Errorf("webhook to %s not allowed", host)
+	}
+
+	return nil
+}
+
+func checkWebhookUrl(s string) error {
+	if len(s) > webhookMaxUrlLength {
+		return errors.New("url too long")
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.New("url should be http or https")
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolve host %s: %s", u.Hostname(), err)
+	}
+	for _, ip := range ips {
+		if !webhookIpAllowed(ip) {
+			return fmt.Errorf("host %s resolved to internal address %s", u.Hostname(), ip)
+		}
+	}
+
+	return nil
+}
+
+// pubKey authorize and signed request checked by rpc dispatch.
+func rpcRegisterWebhook(wargs *WebhookParam) map[string]interface{} {
+	address, err := webhookAddress(wargs.PubKey)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	err = checkWebhookUrl(wargs.Url)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+	if len(wargs.Secret) > webhookMaxSecretBytes {
+		return responsePack(INVALID_PARAM, "secret too long")
+	}
+
+	hook := &Webhook{
+		Url:     wargs.Url,
+		Secret:  wargs.Secret,
+		Created: uint64(time.Now().Unix()),
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	hook.Serialization(sink)
+
+	webhooksLock.Lock()
+	defer webhooksLock.Unlock()
+	err = DefStore.Put(getWebhookKey(address), sink.Bytes())
+	if err != nil {
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+	webhooks[address] = hook
+
+	log.Infof("registerWebhook: %s to %s", address.ToBase58(), hook.Url)
+	return responseSuccess(address.ToBase58())
+}
+
+// pubKey authorize and signed request checked by rpc dispatch.
+func rpcUnregisterWebhook(wargs *WebhookParam) map[string]interface{} {
+	address, err := webhookAddress(wargs.PubKey)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	webhooksLock.Lock()
+	defer webhooksLock.Unlock()
+	err = DefStore.Delete(getWebhookKey(address))
+	if err != nil {
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil)
+	}
+	delete(webhooks, address)
+
+	log.Infof("unregisterWebhook: %s", address.ToBase58())
+	return responseSuccess(address.ToBase58())
+}
+
+func init() {
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "registerWebhook",
+		Desc:      "register the callback url of pubKey. called when leafs submitted by pubKey anchored. url and secret signed in request.",
+		Auth:      RPC_AUTH_REQUEST,
+		Role:      ROLE_SUBMITTER,
+		NewParams: func() interface{} { return &WebhookParam{} },
+		Handler:   func(params interface{}) map[string]interface{} { return rpcRegisterWebhook(params.(*WebhookParam)) },
+	})
+	RegisterRpcMethod(&RpcMethod{
+		Name:      "unregisterWebhook",
+		Desc:      "remove the callback url of pubKey. empty url and secret signed in request.",
+		Auth:      RPC_AUTH_REQUEST,
+		Role:      ROLE_SUBMITTER,
+		NewParams: func() interface{} { return &WebhookParam{} },
+		Handler:   func(params interface{}) map[string]interface{} { return rpcUnregisterWebhook(params.(*WebhookParam)) },
+	})
+}
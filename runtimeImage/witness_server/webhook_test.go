@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/ontio/ontology-go-sdk"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestCheckWebhookUrlInternal(t *testing.T) {
+	for _, u := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.1.2.3/hook",
+		"https://192.168.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+		"ftp://93.184.216.34/hook",
+	} {
+		if err := checkWebhookUrl(u); err == nil {
+			t.Errorf("%s accepted", u)
+		}
+	}
+	if err := checkWebhookUrl("https://93.184.216.34/hook"); err != nil {
+		t.Errorf("public address: %s", err)
+	}
+
+	for _, address := range []string{"127.0.0.1:80", "172.16.0.1:443", "[::ffff:10.0.0.1]:80", "169.254.169.254:80"} {
+		if err := webhookDialControl("tcp", address, nil); err == nil {
+			t.Errorf("dial %s allowed", address)
+		}
+	}
+	if err := webhookDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dial public address: %s", err)
+	}
+}
+
+func TestWebhookRequestReplay(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldCache := DefStore, DefNonceCache
+	defer func() { DefStore, DefNonceCache = oldStore, oldCache }()
+	DefStore = store
+	acc, pubKey, restore := newTestAccount(ROLE_SUBMITTER)
+	defer restore()
+
+	param := &WebhookParam{
+		PubKey:    pubKey,
+		Timestamp: time.Now().Unix(),
+		Nonce:     "webhook replay",
+		Url:       "https://93.184.216.34/hook",
+	}
+	param.Signature = signTestRequest(t, acc, "registerWebhook", param)
+
+	if res := checkRpcAuth(context.Background(), "unregisterWebhook", RPC_AUTH_REQUEST, ROLE_SUBMITTER, param); res == nil {
+		t.Errorf("request of registerWebhook accepted by unregisterWebhook")
+	}
+	if res := checkRpcAuth(context.Background(), "registerWebhook", RPC_AUTH_REQUEST, ROLE_SUBMITTER, param); res != nil {
+		t.Fatalf("signed request %v", res)
+	}
+	if res := checkRpcAuth(context.Background(), "registerWebhook", RPC_AUTH_REQUEST, ROLE_SUBMITTER, param); res == nil || res["error"] != NO_AUTH {
+		t.Errorf("replay accepted %v", res)
+	}
+	// nonce cache lost by restart.
+	DefNonceCache = NewNonceCache()
+	if res := checkRpcAuth(context.Background(), "registerWebhook", RPC_AUTH_REQUEST, ROLE_SUBMITTER, param); res == nil || res["error"] != NO_AUTH {
+		t.Errorf("replay after restart accepted %v", res)
+	}
+
+	param.Nonce = "webhook old"
+	param.Timestamp = time.Now().Unix() - requestSkew() - 10
+	param.Signature = signTestRequest(t, acc, "registerWebhook", param)
+	if res := checkRpcAuth(context.Background(), "registerWebhook", RPC_AUTH_REQUEST, ROLE_SUBMITTER, param); res == nil {
+		t.Errorf("old request accepted")
+	}
+}
+
+func TestWebhookOutboxSeq(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSeq := webhookOutboxSeq
+	defer func() { webhookOutboxSeq = oldSeq }()
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(7)
+	store.Put(getWebhookOutboxSeqKey(), sink.Bytes())
+
+	// all delivered. outbox empty.
+	webhookOutboxSeq = 0
+	if err = InitWebhook(store); err != nil {
+		t.Fatal(err)
+	}
+	if webhookOutboxSeq != 7 {
+		t.Errorf("seq %d after restart, expect 7", webhookOutboxSeq)
+	}
+
+	store.Put(getWebhookOutboxKey(9), nil)
+	webhookOutboxSeq = 0
+	InitWebhook(store)
+	if webhookOutboxSeq != 9 {
+		t.Errorf("seq %d after restart, expect 9 of outbox", webhookOutboxSeq)
+	}
+}
+
+func TestWebhookDeliverPerEndpoint(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStore, oldSigner, oldClient := DefStore, DefSigner, webhookClient
+	webhooksLock.Lock()
+	oldHooks := webhooks
+	webhooksLock.Unlock()
+	defer func() {
+		DefStore, DefSigner, webhookClient = oldStore, oldSigner, oldClient
+		webhooksLock.Lock()
+		webhooks = oldHooks
+		webhooksLock.Unlock()
+	}()
+	// test servers listen on loopback.
+	DefStore, DefSigner, webhookClient = store, sdk.NewAccount(), &http.Client{Timeout: webhookTimeout}
+
+	release := make(chan bool)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer slow.Close()
+	// workers done before the globals restored.
+	defer func() {
+		close(release)
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			webhookWorkersLock.Lock()
+			n := len(webhookInflight)
+			webhookWorkersLock.Unlock()
+			if n == 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Errorf("webhook workers not done")
+	}()
+	var delivered int32
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { atomic.AddInt32(&delivered, 1) }))
+	defer fast.Close()
+
+	slowAddr, fastAddr := common.AddressFromVmCode([]byte("slow")), common.AddressFromVmCode([]byte("fast"))
+	webhooksLock.Lock()
+	webhooks = map[common.Address]*Webhook{
+		slowAddr: {Url: slow.URL},
+		fastAddr: {Url: fast.URL},
+	}
+	webhooksLock.Unlock()
+
+	for seq, address := range []common.Address{slowAddr, slowAddr, fastAddr, fastAddr, common.AddressFromVmCode([]byte("removed"))} {
+		sink := common.NewZeroCopySink(nil)
+		(&WebhookOutboxEntry{Address: address, Payload: []byte("{}")}).Serialization(sink)
+		store.Put(getWebhookOutboxKey(uint64(seq+1)), sink.Bytes())
+	}
+
+	if err = dispatchWebhookEntries(store, uint64(time.Now().Unix())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Get(getWebhookOutboxKey(5)); err == nil {
+		t.Errorf("entry of removed webhook not dropped")
+	}
+	// in flight entries not queued again.
+	dispatchWebhookEntries(store, uint64(time.Now().Unix()))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&delivered) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&delivered); n != 2 {
+		t.Fatalf("fast endpoint delivered %d behind slow endpoint, expect 2", n)
+	}
+	for seq := uint64(3); seq <= 4; seq++ {
+		if _, err = store.Get(getWebhookOutboxKey(seq)); err == nil {
+			t.Errorf("delivered entry %d not removed", seq)
+		}
+	}
+	if _, err = store.Get(getWebhookOutboxKey(1)); err != nil {
+		t.Errorf("entry of slow endpoint removed before delivered")
+	}
+}
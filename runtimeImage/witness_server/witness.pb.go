@@ -0,0 +1,2120 @@
+// gRPC surface of the witness server. witness.pb.go and witness_grpc.pb.go
+// generated by
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative witness.proto
+// messages named as the server types are prefixed by Grpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.27.0
+// source: witness.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_witness_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{0}
+}
+
+// signed request. signature over the canonical request of method, params,
+// timestamp and nonce.
+type SignedQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PubKey        string                 `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Signature     string                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce         string                 `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedQuery) Reset() {
+	*x = SignedQuery{}
+	mi := &file_witness_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedQuery) ProtoMessage() {}
+
+func (x *SignedQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedQuery.ProtoReflect.Descriptor instead.
+func (*SignedQuery) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignedQuery) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *SignedQuery) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *SignedQuery) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SignedQuery) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+// version 1 signature is over the envelope of domain, method, contract
+// address, tenant, timestamp, nonce, sha256 of hashes, then idempotencyKey
+// (empty if only metadata) and sha256 of metadata if set. version 0 is the
+// legacy signature over hashes only.
+// retry with the same idempotencyKey returns the recorded reply.
+// metadata none or one of each hash.
+type BatchAddRequest struct {
+	state          protoimpl.MessageState   `protogen:"open.v1"`
+	PubKey         string                   `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Signature      string                   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	Hashes         []string                 `protobuf:"bytes,3,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	Version        uint32                   `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp      int64                    `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce          string                   `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	IdempotencyKey string                   `protobuf:"bytes,7,opt,name=idempotencyKey,proto3" json:"idempotencyKey,omitempty"`
+	Metadata       []*GrpcLeafMetadataParam `protobuf:"bytes,8,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchAddRequest) Reset() {
+	*x = BatchAddRequest{}
+	mi := &file_witness_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAddRequest) ProtoMessage() {}
+
+func (x *BatchAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAddRequest.ProtoReflect.Descriptor instead.
+func (*BatchAddRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchAddRequest) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *BatchAddRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *BatchAddRequest) GetHashes() []string {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+func (x *BatchAddRequest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *BatchAddRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *BatchAddRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *BatchAddRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *BatchAddRequest) GetMetadata() []*GrpcLeafMetadataParam {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GrpcLeafMetadataParam struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExternalId    string                 `protobuf:"bytes,1,opt,name=externalId,proto3" json:"externalId,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	Labels        []string               `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcLeafMetadataParam) Reset() {
+	*x = GrpcLeafMetadataParam{}
+	mi := &file_witness_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcLeafMetadataParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcLeafMetadataParam) ProtoMessage() {}
+
+func (x *GrpcLeafMetadataParam) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcLeafMetadataParam.ProtoReflect.Descriptor instead.
+func (*GrpcLeafMetadataParam) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GrpcLeafMetadataParam) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadataParam) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadataParam) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// metadata recorded by the server. pubKey, submitter and submitTime of the
+// batchAdd the hash first added by.
+type GrpcLeafMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExternalId    string                 `protobuf:"bytes,1,opt,name=externalId,proto3" json:"externalId,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	Labels        []string               `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty"`
+	PubKey        string                 `protobuf:"bytes,4,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Submitter     string                 `protobuf:"bytes,5,opt,name=submitter,proto3" json:"submitter,omitempty"`
+	SubmitTime    uint64                 `protobuf:"varint,6,opt,name=submitTime,proto3" json:"submitTime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcLeafMetadata) Reset() {
+	*x = GrpcLeafMetadata{}
+	mi := &file_witness_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcLeafMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcLeafMetadata) ProtoMessage() {}
+
+func (x *GrpcLeafMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcLeafMetadata.ProtoReflect.Descriptor instead.
+func (*GrpcLeafMetadata) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GrpcLeafMetadata) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadata) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadata) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *GrpcLeafMetadata) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadata) GetSubmitter() string {
+	if x != nil {
+		return x.Submitter
+	}
+	return ""
+}
+
+func (x *GrpcLeafMetadata) GetSubmitTime() uint64 {
+	if x != nil {
+		return x.SubmitTime
+	}
+	return 0
+}
+
+// receipt signed by the server. result only for legacy response.
+type BatchAddReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Receipt       *GrpcReceipt           `protobuf:"bytes,2,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchAddReply) Reset() {
+	*x = BatchAddReply{}
+	mi := &file_witness_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchAddReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchAddReply) ProtoMessage() {}
+
+func (x *BatchAddReply) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchAddReply.ProtoReflect.Descriptor instead.
+func (*BatchAddReply) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchAddReply) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *BatchAddReply) GetReceipt() *GrpcReceipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+// signature of pubKey over domain "ontology-witness-receipt", contract,
+// tenant, submitter, leafCount, leafsDigest, timestamp and treeSize.
+type GrpcReceipt struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ReceiptId   string                 `protobuf:"bytes,1,opt,name=receiptId,proto3" json:"receiptId,omitempty"`
+	Contract    string                 `protobuf:"bytes,2,opt,name=contract,proto3" json:"contract,omitempty"`
+	TenantId    string                 `protobuf:"bytes,3,opt,name=tenantId,proto3" json:"tenantId,omitempty"`
+	Submitter   string                 `protobuf:"bytes,4,opt,name=submitter,proto3" json:"submitter,omitempty"`
+	LeafCount   uint32                 `protobuf:"varint,5,opt,name=leafCount,proto3" json:"leafCount,omitempty"`
+	LeafsDigest string                 `protobuf:"bytes,6,opt,name=leafsDigest,proto3" json:"leafsDigest,omitempty"`
+	Timestamp   uint64                 `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	TreeSize    uint32                 `protobuf:"varint,8,opt,name=treeSize,proto3" json:"treeSize,omitempty"`
+	PubKey      string                 `protobuf:"bytes,9,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Signature   string                 `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	Promises    []*GrpcPromise         `protobuf:"bytes,11,rep,name=promises,proto3" json:"promises,omitempty"`
+	// hashes submitted before by the same key. idempotent batchAdd only.
+	Duplicates []*GrpcLeafStatus `protobuf:"bytes,12,rep,name=duplicates,proto3" json:"duplicates,omitempty"`
+	// leaf hashes computed by the server. AddData only.
+	Hashes        []string `protobuf:"bytes,13,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcReceipt) Reset() {
+	*x = GrpcReceipt{}
+	mi := &file_witness_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcReceipt) ProtoMessage() {}
+
+func (x *GrpcReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcReceipt.ProtoReflect.Descriptor instead.
+func (*GrpcReceipt) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GrpcReceipt) GetReceiptId() string {
+	if x != nil {
+		return x.ReceiptId
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetContract() string {
+	if x != nil {
+		return x.Contract
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetSubmitter() string {
+	if x != nil {
+		return x.Submitter
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetLeafCount() uint32 {
+	if x != nil {
+		return x.LeafCount
+	}
+	return 0
+}
+
+func (x *GrpcReceipt) GetLeafsDigest() string {
+	if x != nil {
+		return x.LeafsDigest
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GrpcReceipt) GetTreeSize() uint32 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *GrpcReceipt) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *GrpcReceipt) GetPromises() []*GrpcPromise {
+	if x != nil {
+		return x.Promises
+	}
+	return nil
+}
+
+func (x *GrpcReceipt) GetDuplicates() []*GrpcLeafStatus {
+	if x != nil {
+		return x.Duplicates
+	}
+	return nil
+}
+
+func (x *GrpcReceipt) GetHashes() []string {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+type GrpcLeafStatus struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Hash        string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Stage       string                 `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	TxHash      string                 `protobuf:"bytes,3,opt,name=txHash,proto3" json:"txHash,omitempty"`
+	BlockHeight uint32                 `protobuf:"varint,4,opt,name=blockHeight,proto3" json:"blockHeight,omitempty"`
+	Index       uint32                 `protobuf:"varint,5,opt,name=index,proto3" json:"index,omitempty"`
+	Transitions []*GrpcLeafTransition  `protobuf:"bytes,6,rep,name=transitions,proto3" json:"transitions,omitempty"`
+	// hash algorithm of AddData. empty if hashed by client.
+	Algorithm     string `protobuf:"bytes,7,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcLeafStatus) Reset() {
+	*x = GrpcLeafStatus{}
+	mi := &file_witness_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcLeafStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcLeafStatus) ProtoMessage() {}
+
+func (x *GrpcLeafStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcLeafStatus.ProtoReflect.Descriptor instead.
+func (*GrpcLeafStatus) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GrpcLeafStatus) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GrpcLeafStatus) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *GrpcLeafStatus) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *GrpcLeafStatus) GetBlockHeight() uint32 {
+	if x != nil {
+		return x.BlockHeight
+	}
+	return 0
+}
+
+func (x *GrpcLeafStatus) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *GrpcLeafStatus) GetTransitions() []*GrpcLeafTransition {
+	if x != nil {
+		return x.Transitions
+	}
+	return nil
+}
+
+func (x *GrpcLeafStatus) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+type GrpcLeafTransition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`
+	Time          uint64                 `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	TxHash        string                 `protobuf:"bytes,3,opt,name=txHash,proto3" json:"txHash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcLeafTransition) Reset() {
+	*x = GrpcLeafTransition{}
+	mi := &file_witness_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcLeafTransition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcLeafTransition) ProtoMessage() {}
+
+func (x *GrpcLeafTransition) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcLeafTransition.ProtoReflect.Descriptor instead.
+func (*GrpcLeafTransition) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GrpcLeafTransition) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *GrpcLeafTransition) GetTime() uint64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *GrpcLeafTransition) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+// signature of the receipt pubKey over domain "ontology-witness-sst",
+// contract, tenant, hash, timestamp and mmd. the hash will be anchored
+// within mmd seconds after timestamp.
+type GrpcPromise struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Timestamp     uint64                 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Mmd           uint32                 `protobuf:"varint,3,opt,name=mmd,proto3" json:"mmd,omitempty"`
+	Signature     string                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcPromise) Reset() {
+	*x = GrpcPromise{}
+	mi := &file_witness_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcPromise) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcPromise) ProtoMessage() {}
+
+func (x *GrpcPromise) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcPromise.ProtoReflect.Descriptor instead.
+func (*GrpcPromise) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GrpcPromise) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GrpcPromise) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GrpcPromise) GetMmd() uint32 {
+	if x != nil {
+		return x.Mmd
+	}
+	return 0
+}
+
+func (x *GrpcPromise) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+// raw content hashed by the server with algorithm sha256 (rfc 6962 leaf,
+// default), sha3-256 or blake2b-256. signature over the canonical request of
+// method, algorithm, count, sha256 of each data, root, treeSize and
+// idempotencyKey, sha256 of metadata if set, timestamp and nonce. root and
+// treeSize for VerifyData only, idempotencyKey and metadata for AddData only.
+type DataRequest struct {
+	state          protoimpl.MessageState   `protogen:"open.v1"`
+	PubKey         string                   `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Signature      string                   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp      int64                    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce          string                   `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Algorithm      string                   `protobuf:"bytes,5,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	Data           [][]byte                 `protobuf:"bytes,6,rep,name=data,proto3" json:"data,omitempty"`
+	Root           string                   `protobuf:"bytes,7,opt,name=root,proto3" json:"root,omitempty"`
+	TreeSize       uint32                   `protobuf:"varint,8,opt,name=treeSize,proto3" json:"treeSize,omitempty"`
+	IdempotencyKey string                   `protobuf:"bytes,9,opt,name=idempotencyKey,proto3" json:"idempotencyKey,omitempty"`
+	Metadata       []*GrpcLeafMetadataParam `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DataRequest) Reset() {
+	*x = DataRequest{}
+	mi := &file_witness_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataRequest) ProtoMessage() {}
+
+func (x *DataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataRequest.ProtoReflect.Descriptor instead.
+func (*DataRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DataRequest) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *DataRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *DataRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *DataRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *DataRequest) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *DataRequest) GetData() [][]byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DataRequest) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *DataRequest) GetTreeSize() uint32 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *DataRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *DataRequest) GetMetadata() []*GrpcLeafMetadataParam {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PubKey        string                 `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Hashes        []string               `protobuf:"bytes,2,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	Root          string                 `protobuf:"bytes,3,opt,name=root,proto3" json:"root,omitempty"`
+	TreeSize      uint32                 `protobuf:"varint,4,opt,name=treeSize,proto3" json:"treeSize,omitempty"`
+	Signature     string                 `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce         string                 `protobuf:"bytes,7,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_witness_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VerifyRequest) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetHashes() []string {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetTreeSize() uint32 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *VerifyRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *VerifyRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+type VerifyReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          string                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Size          uint32                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Blockheight   uint32                 `protobuf:"varint,3,opt,name=blockheight,proto3" json:"blockheight,omitempty"`
+	Index         uint32                 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	TxHash        string                 `protobuf:"bytes,5,opt,name=txHash,proto3" json:"txHash,omitempty"`
+	LeafHeight    uint32                 `protobuf:"varint,6,opt,name=leafHeight,proto3" json:"leafHeight,omitempty"`
+	Proof         []string               `protobuf:"bytes,7,rep,name=proof,proto3" json:"proof,omitempty"`
+	Metadata      *GrpcLeafMetadata      `protobuf:"bytes,8,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyReply) Reset() {
+	*x = VerifyReply{}
+	mi := &file_witness_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyReply) ProtoMessage() {}
+
+func (x *VerifyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyReply.ProtoReflect.Descriptor instead.
+func (*VerifyReply) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *VerifyReply) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *VerifyReply) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *VerifyReply) GetBlockheight() uint32 {
+	if x != nil {
+		return x.Blockheight
+	}
+	return 0
+}
+
+func (x *VerifyReply) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *VerifyReply) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *VerifyReply) GetLeafHeight() uint32 {
+	if x != nil {
+		return x.LeafHeight
+	}
+	return 0
+}
+
+func (x *VerifyReply) GetProof() []string {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *VerifyReply) GetMetadata() *GrpcLeafMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// at most one of externalId, label and submitter. submit time from to, to 0
+// if no end. cursor is the next of the last page.
+type FindLeavesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PubKey        string                 `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	ExternalId    string                 `protobuf:"bytes,2,opt,name=externalId,proto3" json:"externalId,omitempty"`
+	Label         string                 `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	Submitter     string                 `protobuf:"bytes,4,opt,name=submitter,proto3" json:"submitter,omitempty"`
+	From          uint64                 `protobuf:"varint,5,opt,name=from,proto3" json:"from,omitempty"`
+	To            uint64                 `protobuf:"varint,6,opt,name=to,proto3" json:"to,omitempty"`
+	Cursor        string                 `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit         uint32                 `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`
+	Signature     string                 `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce         string                 `protobuf:"bytes,11,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindLeavesRequest) Reset() {
+	*x = FindLeavesRequest{}
+	mi := &file_witness_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindLeavesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindLeavesRequest) ProtoMessage() {}
+
+func (x *FindLeavesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindLeavesRequest.ProtoReflect.Descriptor instead.
+func (*FindLeavesRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FindLeavesRequest) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetSubmitter() string {
+	if x != nil {
+		return x.Submitter
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *FindLeavesRequest) GetTo() uint64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *FindLeavesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FindLeavesRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *FindLeavesRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *FindLeavesRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+type FoundLeaf struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	ExternalId    string                 `protobuf:"bytes,2,opt,name=externalId,proto3" json:"externalId,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	Labels        []string               `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty"`
+	PubKey        string                 `protobuf:"bytes,5,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Submitter     string                 `protobuf:"bytes,6,opt,name=submitter,proto3" json:"submitter,omitempty"`
+	SubmitTime    uint64                 `protobuf:"varint,7,opt,name=submitTime,proto3" json:"submitTime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FoundLeaf) Reset() {
+	*x = FoundLeaf{}
+	mi := &file_witness_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FoundLeaf) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FoundLeaf) ProtoMessage() {}
+
+func (x *FoundLeaf) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FoundLeaf.ProtoReflect.Descriptor instead.
+func (*FoundLeaf) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FoundLeaf) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *FoundLeaf) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *FoundLeaf) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *FoundLeaf) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *FoundLeaf) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *FoundLeaf) GetSubmitter() string {
+	if x != nil {
+		return x.Submitter
+	}
+	return ""
+}
+
+func (x *FoundLeaf) GetSubmitTime() uint64 {
+	if x != nil {
+		return x.SubmitTime
+	}
+	return 0
+}
+
+type FindLeavesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Leaves        []*FoundLeaf           `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	Next          string                 `protobuf:"bytes,2,opt,name=next,proto3" json:"next,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindLeavesReply) Reset() {
+	*x = FindLeavesReply{}
+	mi := &file_witness_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindLeavesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindLeavesReply) ProtoMessage() {}
+
+func (x *FindLeavesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindLeavesReply.ProtoReflect.Descriptor instead.
+func (*FindLeavesReply) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FindLeavesReply) GetLeaves() []*FoundLeaf {
+	if x != nil {
+		return x.Leaves
+	}
+	return nil
+}
+
+func (x *FindLeavesReply) GetNext() string {
+	if x != nil {
+		return x.Next
+	}
+	return ""
+}
+
+// every result against the same root snapshot. status anchored, pending,
+// notfound or failed. result only if anchored.
+type BatchVerifyReply struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Root          string                  `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Size          uint32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Blockheight   uint32                  `protobuf:"varint,3,opt,name=blockheight,proto3" json:"blockheight,omitempty"`
+	Results       []*GrpcLeafVerifyResult `protobuf:"bytes,4,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchVerifyReply) Reset() {
+	*x = BatchVerifyReply{}
+	mi := &file_witness_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchVerifyReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifyReply) ProtoMessage() {}
+
+func (x *BatchVerifyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifyReply.ProtoReflect.Descriptor instead.
+func (*BatchVerifyReply) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchVerifyReply) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *BatchVerifyReply) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *BatchVerifyReply) GetBlockheight() uint32 {
+	if x != nil {
+		return x.Blockheight
+	}
+	return 0
+}
+
+func (x *BatchVerifyReply) GetResults() []*GrpcLeafVerifyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type GrpcLeafVerifyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Result        *VerifyReply           `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	Metadata      *GrpcLeafMetadata      `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcLeafVerifyResult) Reset() {
+	*x = GrpcLeafVerifyResult{}
+	mi := &file_witness_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcLeafVerifyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcLeafVerifyResult) ProtoMessage() {}
+
+func (x *GrpcLeafVerifyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcLeafVerifyResult.ProtoReflect.Descriptor instead.
+func (*GrpcLeafVerifyResult) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GrpcLeafVerifyResult) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *GrpcLeafVerifyResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GrpcLeafVerifyResult) GetResult() *VerifyReply {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *GrpcLeafVerifyResult) GetMetadata() *GrpcLeafMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GrpcRootSize struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          string                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Size          uint32                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrpcRootSize) Reset() {
+	*x = GrpcRootSize{}
+	mi := &file_witness_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrpcRootSize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrpcRootSize) ProtoMessage() {}
+
+func (x *GrpcRootSize) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrpcRootSize.ProtoReflect.Descriptor instead.
+func (*GrpcRootSize) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GrpcRootSize) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *GrpcRootSize) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type ContractAddress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContractAddress) Reset() {
+	*x = ContractAddress{}
+	mi := &file_witness_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContractAddress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContractAddress) ProtoMessage() {}
+
+func (x *ContractAddress) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContractAddress.ProtoReflect.Descriptor instead.
+func (*ContractAddress) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ContractAddress) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type SubmitHashesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      uint32                 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Messages      uint32                 `protobuf:"varint,2,opt,name=messages,proto3" json:"messages,omitempty"`
+	Duplicates    []string               `protobuf:"bytes,3,rep,name=duplicates,proto3" json:"duplicates,omitempty"`
+	ReceiptIds    []string               `protobuf:"bytes,4,rep,name=receiptIds,proto3" json:"receiptIds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitHashesReply) Reset() {
+	*x = SubmitHashesReply{}
+	mi := &file_witness_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitHashesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitHashesReply) ProtoMessage() {}
+
+func (x *SubmitHashesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitHashesReply.ProtoReflect.Descriptor instead.
+func (*SubmitHashesReply) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SubmitHashesReply) GetAccepted() uint32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *SubmitHashesReply) GetMessages() uint32 {
+	if x != nil {
+		return x.Messages
+	}
+	return 0
+}
+
+func (x *SubmitHashesReply) GetDuplicates() []string {
+	if x != nil {
+		return x.Duplicates
+	}
+	return nil
+}
+
+func (x *SubmitHashesReply) GetReceiptIds() []string {
+	if x != nil {
+		return x.ReceiptIds
+	}
+	return nil
+}
+
+type WatchAnchorsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	PubKey string                 `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	// replay anchored roots with tree size not less than fromSize before live.
+	// 0 for live only.
+	FromSize      uint32 `protobuf:"varint,2,opt,name=fromSize,proto3" json:"fromSize,omitempty"`
+	Signature     string `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	Timestamp     int64  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce         string `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchAnchorsRequest) Reset() {
+	*x = WatchAnchorsRequest{}
+	mi := &file_witness_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchAnchorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchAnchorsRequest) ProtoMessage() {}
+
+func (x *WatchAnchorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchAnchorsRequest.ProtoReflect.Descriptor instead.
+func (*WatchAnchorsRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchAnchorsRequest) GetPubKey() string {
+	if x != nil {
+		return x.PubKey
+	}
+	return ""
+}
+
+func (x *WatchAnchorsRequest) GetFromSize() uint32 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *WatchAnchorsRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *WatchAnchorsRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *WatchAnchorsRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+type AnchorMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          string                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Size          uint32                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Blockheight   uint32                 `protobuf:"varint,3,opt,name=blockheight,proto3" json:"blockheight,omitempty"`
+	TxHash        string                 `protobuf:"bytes,4,opt,name=txHash,proto3" json:"txHash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnchorMessage) Reset() {
+	*x = AnchorMessage{}
+	mi := &file_witness_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnchorMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnchorMessage) ProtoMessage() {}
+
+func (x *AnchorMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnchorMessage.ProtoReflect.Descriptor instead.
+func (*AnchorMessage) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AnchorMessage) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *AnchorMessage) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AnchorMessage) GetBlockheight() uint32 {
+	if x != nil {
+		return x.Blockheight
+	}
+	return 0
+}
+
+func (x *AnchorMessage) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+var File_witness_proto protoreflect.FileDescriptor
+
+const file_witness_proto_rawDesc = "" +
+	"\n" +
+	"\rwitness.proto\x12\awitness\"\a\n" +
+	"\x05Empty\"w\n" +
+	"\vSignedQuery\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\tR\tsignature\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\tR\x05nonce\"\x91\x02\n" +
+	"\x0fBatchAddRequest\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\tR\tsignature\x12\x16\n" +
+	"\x06hashes\x18\x03 \x03(\tR\x06hashes\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\x06 \x01(\tR\x05nonce\x12&\n" +
+	"\x0eidempotencyKey\x18\a \x01(\tR\x0eidempotencyKey\x12:\n" +
+	"\bmetadata\x18\b \x03(\v2\x1e.witness.GrpcLeafMetadataParamR\bmetadata\"q\n" +
+	"\x15GrpcLeafMetadataParam\x12\x1e\n" +
+	"\n" +
+	"externalId\x18\x01 \x01(\tR\n" +
+	"externalId\x12 \n" +
+	"\vcontentType\x18\x02 \x01(\tR\vcontentType\x12\x16\n" +
+	"\x06labels\x18\x03 \x03(\tR\x06labels\"\xc2\x01\n" +
+	"\x10GrpcLeafMetadata\x12\x1e\n" +
+	"\n" +
+	"externalId\x18\x01 \x01(\tR\n" +
+	"externalId\x12 \n" +
+	"\vcontentType\x18\x02 \x01(\tR\vcontentType\x12\x16\n" +
+	"\x06labels\x18\x03 \x03(\tR\x06labels\x12\x16\n" +
+	"\x06pubKey\x18\x04 \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsubmitter\x18\x05 \x01(\tR\tsubmitter\x12\x1e\n" +
+	"\n" +
+	"submitTime\x18\x06 \x01(\x04R\n" +
+	"submitTime\"W\n" +
+	"\rBatchAddReply\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\x12.\n" +
+	"\areceipt\x18\x02 \x01(\v2\x14.witness.GrpcReceiptR\areceipt\"\xb4\x03\n" +
+	"\vGrpcReceipt\x12\x1c\n" +
+	"\treceiptId\x18\x01 \x01(\tR\treceiptId\x12\x1a\n" +
+	"\bcontract\x18\x02 \x01(\tR\bcontract\x12\x1a\n" +
+	"\btenantId\x18\x03 \x01(\tR\btenantId\x12\x1c\n" +
+	"\tsubmitter\x18\x04 \x01(\tR\tsubmitter\x12\x1c\n" +
+	"\tleafCount\x18\x05 \x01(\rR\tleafCount\x12 \n" +
+	"\vleafsDigest\x18\x06 \x01(\tR\vleafsDigest\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x04R\ttimestamp\x12\x1a\n" +
+	"\btreeSize\x18\b \x01(\rR\btreeSize\x12\x16\n" +
+	"\x06pubKey\x18\t \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsignature\x18\n" +
+	" \x01(\tR\tsignature\x120\n" +
+	"\bpromises\x18\v \x03(\v2\x14.witness.GrpcPromiseR\bpromises\x127\n" +
+	"\n" +
+	"duplicates\x18\f \x03(\v2\x17.witness.GrpcLeafStatusR\n" +
+	"duplicates\x12\x16\n" +
+	"\x06hashes\x18\r \x03(\tR\x06hashes\"\xe7\x01\n" +
+	"\x0eGrpcLeafStatus\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\tR\x05stage\x12\x16\n" +
+	"\x06txHash\x18\x03 \x01(\tR\x06txHash\x12 \n" +
+	"\vblockHeight\x18\x04 \x01(\rR\vblockHeight\x12\x14\n" +
+	"\x05index\x18\x05 \x01(\rR\x05index\x12=\n" +
+	"\vtransitions\x18\x06 \x03(\v2\x1b.witness.GrpcLeafTransitionR\vtransitions\x12\x1c\n" +
+	"\talgorithm\x18\a \x01(\tR\talgorithm\"V\n" +
+	"\x12GrpcLeafTransition\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\x04R\x04time\x12\x16\n" +
+	"\x06txHash\x18\x03 \x01(\tR\x06txHash\"o\n" +
+	"\vGrpcPromise\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x04R\ttimestamp\x12\x10\n" +
+	"\x03mmd\x18\x03 \x01(\rR\x03mmd\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\"\xbd\x02\n" +
+	"\vDataRequest\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\tR\tsignature\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\tR\x05nonce\x12\x1c\n" +
+	"\talgorithm\x18\x05 \x01(\tR\talgorithm\x12\x12\n" +
+	"\x04data\x18\x06 \x03(\fR\x04data\x12\x12\n" +
+	"\x04root\x18\a \x01(\tR\x04root\x12\x1a\n" +
+	"\btreeSize\x18\b \x01(\rR\btreeSize\x12&\n" +
+	"\x0eidempotencyKey\x18\t \x01(\tR\x0eidempotencyKey\x12:\n" +
+	"\bmetadata\x18\n" +
+	" \x03(\v2\x1e.witness.GrpcLeafMetadataParamR\bmetadata\"\xc1\x01\n" +
+	"\rVerifyRequest\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x16\n" +
+	"\x06hashes\x18\x02 \x03(\tR\x06hashes\x12\x12\n" +
+	"\x04root\x18\x03 \x01(\tR\x04root\x12\x1a\n" +
+	"\btreeSize\x18\x04 \x01(\rR\btreeSize\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\a \x01(\tR\x05nonce\"\xf2\x01\n" +
+	"\vVerifyReply\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\rR\x04size\x12 \n" +
+	"\vblockheight\x18\x03 \x01(\rR\vblockheight\x12\x14\n" +
+	"\x05index\x18\x04 \x01(\rR\x05index\x12\x16\n" +
+	"\x06txHash\x18\x05 \x01(\tR\x06txHash\x12\x1e\n" +
+	"\n" +
+	"leafHeight\x18\x06 \x01(\rR\n" +
+	"leafHeight\x12\x14\n" +
+	"\x05proof\x18\a \x03(\tR\x05proof\x125\n" +
+	"\bmetadata\x18\b \x01(\v2\x19.witness.GrpcLeafMetadataR\bmetadata\"\xa3\x02\n" +
+	"\x11FindLeavesRequest\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x1e\n" +
+	"\n" +
+	"externalId\x18\x02 \x01(\tR\n" +
+	"externalId\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\x12\x1c\n" +
+	"\tsubmitter\x18\x04 \x01(\tR\tsubmitter\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\x04R\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\x04R\x02to\x12\x16\n" +
+	"\x06cursor\x18\a \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\b \x01(\rR\x05limit\x12\x1c\n" +
+	"\tsignature\x18\t \x01(\tR\tsignature\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\v \x01(\tR\x05nonce\"\xcf\x01\n" +
+	"\tFoundLeaf\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x1e\n" +
+	"\n" +
+	"externalId\x18\x02 \x01(\tR\n" +
+	"externalId\x12 \n" +
+	"\vcontentType\x18\x03 \x01(\tR\vcontentType\x12\x16\n" +
+	"\x06labels\x18\x04 \x03(\tR\x06labels\x12\x16\n" +
+	"\x06pubKey\x18\x05 \x01(\tR\x06pubKey\x12\x1c\n" +
+	"\tsubmitter\x18\x06 \x01(\tR\tsubmitter\x12\x1e\n" +
+	"\n" +
+	"submitTime\x18\a \x01(\x04R\n" +
+	"submitTime\"Q\n" +
+	"\x0fFindLeavesReply\x12*\n" +
+	"\x06leaves\x18\x01 \x03(\v2\x12.witness.FoundLeafR\x06leaves\x12\x12\n" +
+	"\x04next\x18\x02 \x01(\tR\x04next\"\x95\x01\n" +
+	"\x10BatchVerifyReply\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\rR\x04size\x12 \n" +
+	"\vblockheight\x18\x03 \x01(\rR\vblockheight\x127\n" +
+	"\aresults\x18\x04 \x03(\v2\x1d.witness.GrpcLeafVerifyResultR\aresults\"\xa7\x01\n" +
+	"\x14GrpcLeafVerifyResult\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12,\n" +
+	"\x06result\x18\x03 \x01(\v2\x14.witness.VerifyReplyR\x06result\x125\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x19.witness.GrpcLeafMetadataR\bmetadata\"6\n" +
+	"\fGrpcRootSize\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\rR\x04size\"+\n" +
+	"\x0fContractAddress\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"\x8b\x01\n" +
+	"\x11SubmitHashesReply\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\rR\baccepted\x12\x1a\n" +
+	"\bmessages\x18\x02 \x01(\rR\bmessages\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x03 \x03(\tR\n" +
+	"duplicates\x12\x1e\n" +
+	"\n" +
+	"receiptIds\x18\x04 \x03(\tR\n" +
+	"receiptIds\"\x9b\x01\n" +
+	"\x13WatchAnchorsRequest\x12\x16\n" +
+	"\x06pubKey\x18\x01 \x01(\tR\x06pubKey\x12\x1a\n" +
+	"\bfromSize\x18\x02 \x01(\rR\bfromSize\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05nonce\x18\x05 \x01(\tR\x05nonce\"q\n" +
+	"\rAnchorMessage\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\rR\x04size\x12 \n" +
+	"\vblockheight\x18\x03 \x01(\rR\vblockheight\x12\x16\n" +
+	"\x06txHash\x18\x04 \x01(\tR\x06txHash2\xc9\x04\n" +
+	"\aWitness\x12<\n" +
+	"\bBatchAdd\x12\x18.witness.BatchAddRequest\x1a\x16.witness.BatchAddReply\x126\n" +
+	"\x06Verify\x12\x16.witness.VerifyRequest\x1a\x14.witness.VerifyReply\x127\n" +
+	"\aAddData\x12\x14.witness.DataRequest\x1a\x16.witness.BatchAddReply\x12=\n" +
+	"\n" +
+	"VerifyData\x12\x14.witness.DataRequest\x1a\x19.witness.BatchVerifyReply\x12B\n" +
+	"\n" +
+	"FindLeaves\x12\x1a.witness.FindLeavesRequest\x1a\x18.witness.FindLeavesReply\x126\n" +
+	"\aGetRoot\x12\x14.witness.SignedQuery\x1a\x15.witness.GrpcRootSize\x12D\n" +
+	"\x12GetContractAddress\x12\x14.witness.SignedQuery\x1a\x18.witness.ContractAddress\x12F\n" +
+	"\fSubmitHashes\x12\x18.witness.BatchAddRequest\x1a\x1a.witness.SubmitHashesReply(\x01\x12F\n" +
+	"\fWatchAnchors\x12\x1c.witness.WatchAnchorsRequest\x1a\x16.witness.AnchorMessage0\x01B\tZ\a./;mainb\x06proto3"
+
+var (
+	file_witness_proto_rawDescOnce sync.Once
+	file_witness_proto_rawDescData []byte
+)
+
+func file_witness_proto_rawDescGZIP() []byte {
+	file_witness_proto_rawDescOnce.Do(func() {
+		file_witness_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_witness_proto_rawDesc), len(file_witness_proto_rawDesc)))
+	})
+	return file_witness_proto_rawDescData
+}
+
+var file_witness_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_witness_proto_goTypes = []any{
+	(*Empty)(nil),                 // 0: witness.Empty
+	(*SignedQuery)(nil),           // 1: witness.SignedQuery
+	(*BatchAddRequest)(nil),       // 2: witness.BatchAddRequest
+	(*GrpcLeafMetadataParam)(nil), // 3: witness.GrpcLeafMetadataParam
+	(*GrpcLeafMetadata)(nil),      // 4: witness.GrpcLeafMetadata
+	(*BatchAddReply)(nil),         // 5: witness.BatchAddReply
+	(*GrpcReceipt)(nil),           // 6: witness.GrpcReceipt
+	(*GrpcLeafStatus)(nil),        // 7: witness.GrpcLeafStatus
+	(*GrpcLeafTransition)(nil),    // 8: witness.GrpcLeafTransition
+	(*GrpcPromise)(nil),           // 9: witness.GrpcPromise
+	(*DataRequest)(nil),           // 10: witness.DataRequest
+	(*VerifyRequest)(nil),         // 11: witness.VerifyRequest
+	(*VerifyReply)(nil),           // 12: witness.VerifyReply
+	(*FindLeavesRequest)(nil),     // 13: witness.FindLeavesRequest
+	(*FoundLeaf)(nil),             // 14: witness.FoundLeaf
+	(*FindLeavesReply)(nil),       // 15: witness.FindLeavesReply
+	(*BatchVerifyReply)(nil),      // 16: witness.BatchVerifyReply
+	(*GrpcLeafVerifyResult)(nil),  // 17: witness.GrpcLeafVerifyResult
+	(*GrpcRootSize)(nil),          // 18: witness.GrpcRootSize
+	(*ContractAddress)(nil),       // 19: witness.ContractAddress
+	(*SubmitHashesReply)(nil),     // 20: witness.SubmitHashesReply
+	(*WatchAnchorsRequest)(nil),   // 21: witness.WatchAnchorsRequest
+	(*AnchorMessage)(nil),         // 22: witness.AnchorMessage
+}
+var file_witness_proto_depIdxs = []int32{
+	3,  // 0: witness.BatchAddRequest.metadata:type_name -> witness.GrpcLeafMetadataParam
+	6,  // 1: witness.BatchAddReply.receipt:type_name -> witness.GrpcReceipt
+	9,  // 2: witness.GrpcReceipt.promises:type_name -> witness.GrpcPromise
+	7,  // 3: witness.GrpcReceipt.duplicates:type_name -> witness.GrpcLeafStatus
+	8,  // 4: witness.GrpcLeafStatus.transitions:type_name -> witness.GrpcLeafTransition
+	3,  // 5: witness.DataRequest.metadata:type_name -> witness.GrpcLeafMetadataParam
+	4,  // 6: witness.VerifyReply.metadata:type_name -> witness.GrpcLeafMetadata
+	14, // 7: witness.FindLeavesReply.leaves:type_name -> witness.FoundLeaf
+	17, // 8: witness.BatchVerifyReply.results:type_name -> witness.GrpcLeafVerifyResult
+	12, // 9: witness.GrpcLeafVerifyResult.result:type_name -> witness.VerifyReply
+	4,  // 10: witness.GrpcLeafVerifyResult.metadata:type_name -> witness.GrpcLeafMetadata
+	2,  // 11: witness.Witness.BatchAdd:input_type -> witness.BatchAddRequest
+	11, // 12: witness.Witness.Verify:input_type -> witness.VerifyRequest
+	10, // 13: witness.Witness.AddData:input_type -> witness.DataRequest
+	10, // 14: witness.Witness.VerifyData:input_type -> witness.DataRequest
+	13, // 15: witness.Witness.FindLeaves:input_type -> witness.FindLeavesRequest
+	1,  // 16: witness.Witness.GetRoot:input_type -> witness.SignedQuery
+	1,  // 17: witness.Witness.GetContractAddress:input_type -> witness.SignedQuery
+	2,  // 18: witness.Witness.SubmitHashes:input_type -> witness.BatchAddRequest
+	21, // 19: witness.Witness.WatchAnchors:input_type -> witness.WatchAnchorsRequest
+	5,  // 20: witness.Witness.BatchAdd:output_type -> witness.BatchAddReply
+	12, // 21: witness.Witness.Verify:output_type -> witness.VerifyReply
+	5,  // 22: witness.Witness.AddData:output_type -> witness.BatchAddReply
+	16, // 23: witness.Witness.VerifyData:output_type -> witness.BatchVerifyReply
+	15, // 24: witness.Witness.FindLeaves:output_type -> witness.FindLeavesReply
+	18, // 25: witness.Witness.GetRoot:output_type -> witness.GrpcRootSize
+	19, // 26: witness.Witness.GetContractAddress:output_type -> witness.ContractAddress
+	20, // 27: witness.Witness.SubmitHashes:output_type -> witness.SubmitHashesReply
+	22, // 28: witness.Witness.WatchAnchors:output_type -> witness.AnchorMessage
+	20, // [20:29] is the sub-list for method output_type
+	11, // [11:20] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_witness_proto_init() }
+func file_witness_proto_init() {
+	if File_witness_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_witness_proto_rawDesc), len(file_witness_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_witness_proto_goTypes,
+		DependencyIndexes: file_witness_proto_depIdxs,
+		MessageInfos:      file_witness_proto_msgTypes,
+	}.Build()
+	File_witness_proto = out.File
+	file_witness_proto_goTypes = nil
+	file_witness_proto_depIdxs = nil
+}
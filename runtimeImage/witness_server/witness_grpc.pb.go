@@ -0,0 +1,430 @@
+// gRPC surface of the witness server. witness.pb.go and witness_grpc.pb.go
+// generated by
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative witness.proto
+// messages named as the server types are prefixed by Grpc.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.0
+// source: witness.proto
+
+package main
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Witness_BatchAdd_FullMethodName           = "/witness.Witness/BatchAdd"
+	Witness_Verify_FullMethodName             = "/witness.Witness/Verify"
+	Witness_AddData_FullMethodName            = "/witness.Witness/AddData"
+	Witness_VerifyData_FullMethodName         = "/witness.Witness/VerifyData"
+	Witness_FindLeaves_FullMethodName         = "/witness.Witness/FindLeaves"
+	Witness_GetRoot_FullMethodName            = "/witness.Witness/GetRoot"
+	Witness_GetContractAddress_FullMethodName = "/witness.Witness/GetContractAddress"
+	Witness_SubmitHashes_FullMethodName       = "/witness.Witness/SubmitHashes"
+	Witness_WatchAnchors_FullMethodName       = "/witness.Witness/WatchAnchors"
+)
+
+// WitnessClient is the client API for Witness service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WitnessClient interface {
+	BatchAdd(ctx context.Context, in *BatchAddRequest, opts ...grpc.CallOption) (*BatchAddReply, error)
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyReply, error)
+	AddData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*BatchAddReply, error)
+	VerifyData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*BatchVerifyReply, error)
+	FindLeaves(ctx context.Context, in *FindLeavesRequest, opts ...grpc.CallOption) (*FindLeavesReply, error)
+	GetRoot(ctx context.Context, in *SignedQuery, opts ...grpc.CallOption) (*GrpcRootSize, error)
+	GetContractAddress(ctx context.Context, in *SignedQuery, opts ...grpc.CallOption) (*ContractAddress, error)
+	// each message is one signed batchAdd chunk.
+	SubmitHashes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BatchAddRequest, SubmitHashesReply], error)
+	WatchAnchors(ctx context.Context, in *WatchAnchorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnchorMessage], error)
+}
+
+type witnessClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWitnessClient(cc grpc.ClientConnInterface) WitnessClient {
+	return &witnessClient{cc}
+}
+
+func (c *witnessClient) BatchAdd(ctx context.Context, in *BatchAddRequest, opts ...grpc.CallOption) (*BatchAddReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchAddReply)
+	err := c.cc.Invoke(ctx, Witness_BatchAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyReply)
+	err := c.cc.Invoke(ctx, Witness_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) AddData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*BatchAddReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchAddReply)
+	err := c.cc.Invoke(ctx, Witness_AddData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) VerifyData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*BatchVerifyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchVerifyReply)
+	err := c.cc.Invoke(ctx, Witness_VerifyData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) FindLeaves(ctx context.Context, in *FindLeavesRequest, opts ...grpc.CallOption) (*FindLeavesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindLeavesReply)
+	err := c.cc.Invoke(ctx, Witness_FindLeaves_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) GetRoot(ctx context.Context, in *SignedQuery, opts ...grpc.CallOption) (*GrpcRootSize, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GrpcRootSize)
+	err := c.cc.Invoke(ctx, Witness_GetRoot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) GetContractAddress(ctx context.Context, in *SignedQuery, opts ...grpc.CallOption) (*ContractAddress, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ContractAddress)
+	err := c.cc.Invoke(ctx, Witness_GetContractAddress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *witnessClient) SubmitHashes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BatchAddRequest, SubmitHashesReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Witness_ServiceDesc.Streams[0], Witness_SubmitHashes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchAddRequest, SubmitHashesReply]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Witness_SubmitHashesClient = grpc.ClientStreamingClient[BatchAddRequest, SubmitHashesReply]
+
+func (c *witnessClient) WatchAnchors(ctx context.Context, in *WatchAnchorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnchorMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Witness_ServiceDesc.Streams[1], Witness_WatchAnchors_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchAnchorsRequest, AnchorMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Witness_WatchAnchorsClient = grpc.ServerStreamingClient[AnchorMessage]
+
+// WitnessServer is the server API for Witness service.
+// All implementations must embed UnimplementedWitnessServer
+// for forward compatibility.
+type WitnessServer interface {
+	BatchAdd(context.Context, *BatchAddRequest) (*BatchAddReply, error)
+	Verify(context.Context, *VerifyRequest) (*VerifyReply, error)
+	AddData(context.Context, *DataRequest) (*BatchAddReply, error)
+	VerifyData(context.Context, *DataRequest) (*BatchVerifyReply, error)
+	FindLeaves(context.Context, *FindLeavesRequest) (*FindLeavesReply, error)
+	GetRoot(context.Context, *SignedQuery) (*GrpcRootSize, error)
+	GetContractAddress(context.Context, *SignedQuery) (*ContractAddress, error)
+	// each message is one signed batchAdd chunk.
+	SubmitHashes(grpc.ClientStreamingServer[BatchAddRequest, SubmitHashesReply]) error
+	WatchAnchors(*WatchAnchorsRequest, grpc.ServerStreamingServer[AnchorMessage]) error
+	mustEmbedUnimplementedWitnessServer()
+}
+
+// UnimplementedWitnessServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWitnessServer struct{}
+
+func (UnimplementedWitnessServer) BatchAdd(context.Context, *BatchAddRequest) (*BatchAddReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchAdd not implemented")
+}
+func (UnimplementedWitnessServer) Verify(context.Context, *VerifyRequest) (*VerifyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedWitnessServer) AddData(context.Context, *DataRequest) (*BatchAddReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddData not implemented")
+}
+func (UnimplementedWitnessServer) VerifyData(context.Context, *DataRequest) (*BatchVerifyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyData not implemented")
+}
+func (UnimplementedWitnessServer) FindLeaves(context.Context, *FindLeavesRequest) (*FindLeavesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindLeaves not implemented")
+}
+func (UnimplementedWitnessServer) GetRoot(context.Context, *SignedQuery) (*GrpcRootSize, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoot not implemented")
+}
+func (UnimplementedWitnessServer) GetContractAddress(context.Context, *SignedQuery) (*ContractAddress, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContractAddress not implemented")
+}
+func (UnimplementedWitnessServer) SubmitHashes(grpc.ClientStreamingServer[BatchAddRequest, SubmitHashesReply]) error {
+	return status.Errorf(codes.Unimplemented, "method SubmitHashes not implemented")
+}
+func (UnimplementedWitnessServer) WatchAnchors(*WatchAnchorsRequest, grpc.ServerStreamingServer[AnchorMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAnchors not implemented")
+}
+func (UnimplementedWitnessServer) mustEmbedUnimplementedWitnessServer() {}
+func (UnimplementedWitnessServer) testEmbeddedByValue()                 {}
+
+// UnsafeWitnessServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WitnessServer will
+// result in compilation errors.
+type UnsafeWitnessServer interface {
+	mustEmbedUnimplementedWitnessServer()
+}
+
+func RegisterWitnessServer(s grpc.ServiceRegistrar, srv WitnessServer) {
+	// If the following call pancis, it indicates UnimplementedWitnessServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Witness_ServiceDesc, srv)
+}
+
+func _Witness_BatchAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchAddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).BatchAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_BatchAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).BatchAdd(ctx, req.(*BatchAddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_AddData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).AddData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_AddData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).AddData(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_VerifyData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).VerifyData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_VerifyData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).VerifyData(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_FindLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).FindLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_FindLeaves_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).FindLeaves(ctx, req.(*FindLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_GetRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignedQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).GetRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_GetRoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).GetRoot(ctx, req.(*SignedQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_GetContractAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignedQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServer).GetContractAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Witness_GetContractAddress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServer).GetContractAddress(ctx, req.(*SignedQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Witness_SubmitHashes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WitnessServer).SubmitHashes(&grpc.GenericServerStream[BatchAddRequest, SubmitHashesReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Witness_SubmitHashesServer = grpc.ClientStreamingServer[BatchAddRequest, SubmitHashesReply]
+
+func _Witness_WatchAnchors_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAnchorsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WitnessServer).WatchAnchors(m, &grpc.GenericServerStream[WatchAnchorsRequest, AnchorMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Witness_WatchAnchorsServer = grpc.ServerStreamingServer[AnchorMessage]
+
+// Witness_ServiceDesc is the grpc.ServiceDesc for Witness service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Witness_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "witness.Witness",
+	HandlerType: (*WitnessServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BatchAdd",
+			Handler:    _Witness_BatchAdd_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _Witness_Verify_Handler,
+		},
+		{
+			MethodName: "AddData",
+			Handler:    _Witness_AddData_Handler,
+		},
+		{
+			MethodName: "VerifyData",
+			Handler:    _Witness_VerifyData_Handler,
+		},
+		{
+			MethodName: "FindLeaves",
+			Handler:    _Witness_FindLeaves_Handler,
+		},
+		{
+			MethodName: "GetRoot",
+			Handler:    _Witness_GetRoot_Handler,
+		},
+		{
+			MethodName: "GetContractAddress",
+			Handler:    _Witness_GetContractAddress_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitHashes",
+			Handler:       _Witness_SubmitHashes_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchAnchors",
+			Handler:       _Witness_WatchAnchors_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "witness.proto",
+}
@@ -51,6 +51,25 @@ const (
 	PREFIX_CURRENT_BLOCKHEIGHT    DataPrefix = 0x7
 	PREFIX_FILEHASH_APPEND_FAILED DataPrefix = 0x8
 	PREFIX_CONTRACT_ADDRESS       DataPrefix = 0x9
+	PREFIX_LEAF_STATUS            DataPrefix = 0xa
+	PREFIX_ROOT_HISTORY           DataPrefix = 0xb
+	PREFIX_LEAF_SUBMITTER         DataPrefix = 0xc
+	PREFIX_WEBHOOK                DataPrefix = 0xd
+	PREFIX_WEBHOOK_OUTBOX         DataPrefix = 0xe
+	PREFIX_BATCH                  DataPrefix = 0xf
+	PREFIX_BATCH_INDEX            DataPrefix = 0x10
+	PREFIX_BATCH_SEND             DataPrefix = 0x11
+	PREFIX_BATCHADD_NONCE         DataPrefix = 0x12
+	PREFIX_AUTHORIZED_KEY         DataPrefix = 0x13
+	PREFIX_RECEIPT                DataPrefix = 0x14
+	PREFIX_PROMISE                DataPrefix = 0x15
+	PREFIX_BROKEN_PROMISE         DataPrefix = 0x16
+	PREFIX_IDEMPOTENCY_KEY        DataPrefix = 0x17
+	PREFIX_LEAF_HASH_ALGORITHM    DataPrefix = 0x18
+	PREFIX_LEAF_METADATA          DataPrefix = 0x19
+	PREFIX_LEAF_METADATA_INDEX    DataPrefix = 0x1a
+	PREFIX_ROOT_HISTORY_BACKFILL  DataPrefix = 0x1b
+	PREFIX_WEBHOOK_OUTBOX_SEQ     DataPrefix = 0x1c
 )
 
 var (
@@ -75,19 +94,51 @@ var (
 )
 
 type ServerConfig struct {
-	Walletname        string   `json:"walletname"`
-	OntNode           string   `json:"ontnode"`
-	SignerAddress     string   `json:"signeraddress"`
-	ServerPort        int      `json:"serverport"`
-	GasPrice          uint64   `json:"gasprice"`
-	CacheTime         uint32   `json:"cachetime"`
-	BatchNum          uint32   `json:"batchnum"`
-	TryChainInterval  uint32   `json:"trychaininterval"`
-	SendTxInterval    uint32   `json:"sendtxinterval"`
-	SendTxSize        uint32   `json:"sendtxsize"`
-	BatchAddSleepTime uint32   `json:"batchaddsleeptime"`
-	ContracthexAddr   string   `json:"contracthexaddr"`
-	Authorize         []string `json:"authorize"`
+	Walletname         string            `json:"walletname"`
+	OntNode            string            `json:"ontnode"`
+	SignerAddress      string            `json:"signeraddress"`
+	ServerPort         int               `json:"serverport"`
+	GasPrice           uint64            `json:"gasprice"`
+	CacheTime          uint32            `json:"cachetime"`
+	BatchNum           uint32            `json:"batchnum"`
+	TryChainInterval   uint32            `json:"trychaininterval"`
+	SendTxInterval     uint32            `json:"sendtxinterval"`
+	SendTxSize         uint32            `json:"sendtxsize"`
+	ContracthexAddr    string            `json:"contracthexaddr"`
+	Authorize          []string          `json:"authorize"`
+	LegacyResponse     bool              `json:"legacyresponse"`
+	MaxBatchLength     uint32            `json:"maxbatchlength"`
+	MaxBatchBodySize   uint32            `json:"maxbatchbodysize"`
+	MaxVerifyNum       uint32            `json:"maxverifynum"`
+	GrpcPort           int               `json:"grpcport"`
+	RequestSkew        uint32            `json:"requestskew"`
+	TenantId           string            `json:"tenantid"`
+	LegacyBatchAddSig  bool              `json:"legacybatchaddsig"`
+	AdminAuthorize     []string          `json:"adminauthorize"`
+	SubmitterAuthorize []string          `json:"submitterauthorize"`
+	VerifierAuthorize  []string          `json:"verifierauthorize"`
+	AuditorAuthorize   []string          `json:"auditorauthorize"`
+	TlsCertFile        string            `json:"tlscertfile"`
+	TlsKeyFile         string            `json:"tlskeyfile"`
+	TlsClientCAFile    string            `json:"tlsclientcafile"`
+	TlsClientAuth      string            `json:"tlsclientauth"`
+	TlsClientIdentity  map[string]string `json:"tlsclientidentity"`
+	CorsAllowOrigins   []string          `json:"corsalloworigins"`
+	CorsAllowMethods   []string          `json:"corsallowmethods"`
+	CorsAllowHeaders   []string          `json:"corsallowheaders"`
+	ReadHeaderTimeout  uint32            `json:"readheadertimeout"`
+	ReadTimeout        uint32            `json:"readtimeout"`
+	WriteTimeout       uint32            `json:"writetimeout"`
+	IdleTimeout        uint32            `json:"idletimeout"`
+	MaxHeaderBytes     int               `json:"maxheaderbytes"`
+	MaxConnections     int               `json:"maxconnections"`
+	MaxRequestBodySize uint32            `json:"maxrequestbodysize"`
+	MaxMergeDelay      uint32            `json:"maxmergedelay"`
+	IdempotentBatchAdd bool              `json:"idempotentbatchadd"`
+	IdempotencyKeyTtl  uint32            `json:"idempotencykeyttl"`
+	AdminAddr          string            `json:"adminaddr"`
+	PublicStatus       bool              `json:"publicstatus"`
+	PublicMetrics      bool              `json:"publicmetrics"`
 }
 
 const (
@@ -100,6 +151,15 @@ const (
 	DUP_HASH        int64 = 41006
 )
 
+// JSON-RPC 2.0 standard error codes.
+const (
+	PARSE_ERROR      int64 = -32700
+	INVALID_REQUEST  int64 = -32600
+	METHOD_NOT_FOUND int64 = -32601
+	INVALID_PARAMS   int64 = -32602
+	INTERNAL_ERROR   int64 = -32603
+)
+
 const TxExecFailed uint32 = 1
 
 var ErrMap = map[int64]string{
@@ -110,22 +170,12 @@ var ErrMap = map[int64]string{
 	NODE_OUTSERVICE: "NODE_OUTSERVICE",
 	NO_AUTH:         "NO_AUTH",
 	DUP_HASH:        "DUP_HASH",
-}
-
-func checkAuthorizeOfAddress(address common.Address) bool {
-	for _, s := range DefConfig.Authorize {
-		addr, err := common.AddressFromBase58(s)
-		if err != nil {
-			log.Errorf("server Authorize address convert err.")
-			return false
-		}
 
-		if addr == address {
-			return true
-		}
-	}
-
-	return false
+	PARSE_ERROR:      "PARSE_ERROR",
+	INVALID_REQUEST:  "INVALID_REQUEST",
+	METHOD_NOT_FOUND: "METHOD_NOT_FOUND",
+	INVALID_PARAMS:   "INVALID_PARAMS",
+	INTERNAL_ERROR:   "INTERNAL_ERROR",
 }
 
 type TransactionStore struct {
@@ -243,11 +293,9 @@ func InitSigner() error {
 }
 
 func getRoot(ontSdk *sdk.OntologySdk, tx *types.MutableTransaction) (common.Uint256, uint32, error) {
-	var result *sdkcom.PreExecResult
-	var err error
 	callCount := uint32(0)
 	for {
-		result, err = ontSdk.ClientMgr.PreExecTransaction(tx)
+		root, size, err := preExecRoot(ontSdk, tx)
 		if err != nil {
 			if callCount > 10 {
 				return merkle.EMPTY_HASH, 0, err
@@ -256,7 +304,15 @@ func getRoot(ontSdk *sdk.OntologySdk, tx *types.MutableTransaction) (common.Uint
 			time.Sleep(time.Second * 1)
 			continue
 		}
-		break
+		return root, size, nil
+	}
+}
+
+// preExecRoot get root and tree size from chain contract once.
+func preExecRoot(ontSdk *sdk.OntologySdk, tx *types.MutableTransaction) (common.Uint256, uint32, error) {
+	result, err := ontSdk.ClientMgr.PreExecTransaction(tx)
+	if err != nil {
+		return merkle.EMPTY_HASH, 0, err
 	}
 	raw, err := result.Result.ToByteArray()
 	if err != nil {
@@ -433,6 +489,11 @@ func InitCompactMerkleTree(updatecontract bool, forceHeight uint32) error {
 		}
 	}
 
+	err = backfillRootHistory(DefStore)
+	if err != nil {
+		return err
+	}
+
 	SendTxChannel = make(chan bool, DefConfig.SendTxSize)
 	for i := uint32(1); i < DefConfig.SendTxSize; i++ {
 		SendTxChannel <- true
@@ -562,6 +623,24 @@ func putLeafIndex(store *leveldbstore.LevelDBStore, leaf common.Uint256, index u
 	store.BatchPut(GetKeyByHash(PREFIX_INDEX, leaf), sink.Bytes())
 }
 
+func putLeafSubmitter(store *leveldbstore.LevelDBStore, leaf common.Uint256, submitter common.Address) {
+	store.BatchPut(GetKeyByHash(PREFIX_LEAF_SUBMITTER, leaf), submitter[:])
+}
+
+// not BatchDelete. same as delLeafIndex
+func delLeafSubmitter(store *leveldbstore.LevelDBStore, leaf common.Uint256) {
+	store.Delete(GetKeyByHash(PREFIX_LEAF_SUBMITTER, leaf))
+}
+
+func getLeafSubmitter(store *leveldbstore.LevelDBStore, leaf common.Uint256) (common.Address, error) {
+	val, err := store.Get(GetKeyByHash(PREFIX_LEAF_SUBMITTER, leaf))
+	if err != nil {
+		return common.ADDRESS_EMPTY, err
+	}
+
+	return common.AddressParseFromBytes(val)
+}
+
 // not this it not BatchDelete
 func delLeafIndex(store *leveldbstore.LevelDBStore, leaf common.Uint256) {
 	store.Delete(GetKeyByHash(PREFIX_INDEX, leaf))
@@ -633,7 +712,13 @@ func constructTransation(ontSdk *sdk.OntologySdk, leafv []common.Uint256) (*type
 	args[0] = "batch_add"
 	args[1] = params
 
-	return getTxWithArgs(ontSdk, args)
+	tx, err := getTxWithArgs(ontSdk, args)
+	if err != nil {
+		return nil, err
+	}
+
+	metricTxConstructed.Inc()
+	return tx, nil
 }
 
 func getTxWithArgs(ontSdk *sdk.OntologySdk, args []interface{}) (*types.MutableTransaction, error) {
@@ -662,7 +747,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 		localHeight, err := getCurrentLocalBlockHeight(&store)
 		if err != nil {
 			log.Errorf("RoutineOfAddToLocalStorage: %s", err)
-			SystemOutOfService = true
+			setOutOfService(fmt.Sprintf("get local block height: %s", err))
 			return
 		}
 
@@ -678,6 +763,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 			time.Sleep(time.Second * time.Duration(DefConfig.TryChainInterval))
 			continue
 		}
+		metricHeightLag.Set(float64(blockHeight - localHeight))
 
 		log.Debugf("Local Height: %d, CurrentBlockHeight: %d", localHeight, blockHeight)
 		blockevents, err := DefSdk.GetSmartContractEventByBlock(localHeight)
@@ -721,6 +807,8 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 
 		// note all block should ledger to vocal or not. can not partly. if error happend all tx in oneblock to local ledger will drop.
 		addHashes := make([]common.Uint256, 0, 0)
+		anchorEvents := make([]*AnchorEvent, 0)
+		submitTimes := make([]uint64, 0)
 		var handledMerkleTx bool
 		handledMerkleTx = false
 
@@ -737,7 +825,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 			txh, err := common.Uint256FromHexString(event.TxHash)
 			if err != nil {
 				log.Warnf("RoutineOfAddToLocalStorage: %s", err)
-				SystemOutOfService = true
+				setOutOfService(fmt.Sprintf("invalid tx hash of event: %s", err))
 				return
 			}
 
@@ -755,7 +843,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 				if err != nil {
 					// if err indicates events wrong. consider data loose? try localHeight again.
 					log.Warnf("RoutineOfAddToLocalStorage: %s", err)
-					SystemOutOfService = true
+					setOutOfService(fmt.Sprintf("get chain root of tx %s: %s", event.TxHash, err))
 					return
 				}
 
@@ -763,13 +851,13 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 				if err != nil {
 					// if failed can get from chain. check the program
 					log.Fatalf("RoutineOfAddToLocalStorage: txhash: %x. get tx error. %s", txh, err)
-					SystemOutOfService = true
+					setOutOfService(fmt.Sprintf("get tx %x: %s", txh, err))
 					return
 				}
 
 				if tx.Hash() != txh {
 					log.Fatalf("RoutineOfAddToLocalStorage: txhash: %x. not equal . %x", txh, tx.Hash())
-					SystemOutOfService = true
+					setOutOfService(fmt.Sprintf("tx hash %x not equal %x", txh, tx.Hash()))
 					return
 				}
 
@@ -777,7 +865,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 				if err != nil {
 					// if failed can get from chain. check the program
 					log.Fatalf("RoutineOfAddToLocalStorage: leafvFromTx. %s", err)
-					SystemOutOfService = true
+					setOutOfService(fmt.Sprintf("leafs of tx %x: %s", txh, err))
 					return
 				}
 
@@ -786,17 +874,28 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 					newtx, err := constructTransation(DefSdk, leafv)
 					if err != nil {
 						log.Errorf("RoutineOfAddToLocalStorage: constructTransation failed. %s", err)
-						SystemOutOfService = true
+						setOutOfService(fmt.Sprintf("construct tx to replace failed tx %x: %s", txh, err))
 						return
 					}
 
 					err = putTransaction(&store, newtx)
 					if err != nil {
 						log.Errorf("RoutineOfAddToLocalStorage: putTransaction failed. %s", err)
-						SystemOutOfService = true
+						setOutOfService(fmt.Sprintf("put tx to replace failed tx %x: %s", txh, err))
 						return
 					}
 
+					putLeafStage(&store, leafv, 0, stageOf(LEAF_STAGE_FAILED, txh), stageOf(LEAF_STAGE_BATCHED, newtx.Hash()))
+					metricTxFailed.Inc()
+					putBatchFailed(&store, txh, leafv, localHeight, newtx.Hash())
+					anchorEvents = append(anchorEvents, &AnchorEvent{
+						Type:        ANCHOR_EVENT_FAILED,
+						Leafs:       leafv,
+						BlockHeight: localHeight,
+						TxHash:      txh,
+						NewTxHash:   newtx.Hash(),
+					})
+
 					// delete old tx. delete from txstore map ok. if failed will Unmarshal from leveldbstore.
 					delTransaction(&store, tx.Hash())
 					log.Warnf("RoutineOfAddToLocalStorage: new tx: %s", newtx.Hash())
@@ -808,22 +907,29 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 				for i := uint32(0); i < uint32(len(leafv)); i++ {
 					if tmpTree.TreeSize() == math.MaxUint32 {
 						log.Errorf("RoutineOfAddToLocalStorage: Over max the MaxUint32 merkle size.")
-						SystemOutOfService = true
+						setOutOfService("over max the MaxUint32 merkle size")
 						return
 					}
 					tmpTree.AppendHash(leafv[i])
 					putLeafIndex(&store, leafv[i], tmpTree.TreeSize()-1, localHeight, event.TxHash)
 				}
+				submitTimes = append(submitTimes, leafSubmitTimes(&store, leafv)...)
+				putLeafStage(&store, leafv, localHeight, stageOf(LEAF_STAGE_ANCHORED, txh))
+				resolvePromises(&store, leafv)
 
 				log.Infof("tx hash, %s, Local Height: %d, CurrentBlockHeight: %d", event.TxHash, localHeight, blockHeight)
 				if newroot != tmpTree.Root() || newtreeSize != tmpTree.TreeSize() {
-					SystemOutOfService = true
+					setOutOfService(fmt.Sprintf("root of tx %s not equal chain root", event.TxHash))
 					log.Fatalf("RoutineOfAddToLocalStorage: chainroot: %x, root : %x, chaintreeSize: %d, treeSize: %d", newroot, tmpTree.Root(), newtreeSize, tmpTree.TreeSize())
 					return
 				}
 
-				putRootBlockHeight(&store, tmpTree.Root(), localHeight)
+				putRootInfo(&store, tmpTree.Root(), tmpTree.TreeSize(), localHeight, txh)
+				putBatchAnchored(&store, txh, leafv, localHeight, tmpTree.Root(), tmpTree.TreeSize())
 				delTransaction(&store, tx.Hash())
+				anchorEvent := newAnchoredEvent(leafv, tmpTree, localHeight, txh)
+				enqueueWebhooks(&store, anchorEvent)
+				anchorEvents = append(anchorEvents, anchorEvent)
 
 				log.Infof("root: %x, treeSize: %d", tmpTree.Root(), tmpTree.TreeSize())
 			} else {
@@ -848,7 +954,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 						if err != nil || txchain == nil {
 							if count > 100 {
 								log.Fatalf("RoutineOfAddToLocalStorage: found transaction not in pool. some one may operate the chain contract. or just get_root need check: %s. chain offline. just restart to try.", err)
-								SystemOutOfService = true
+								setOutOfService(fmt.Sprintf("get tx %s from other server: %s", event.TxHash, err))
 								return
 							}
 							time.Sleep(time.Second * time.Duration(DefConfig.SendTxInterval))
@@ -861,7 +967,7 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 					mutxchain, err := txchain.IntoMutable()
 					if err != nil {
 						log.Fatalf("RoutineOfAddToLocalStorage: found transaction not in pool. some one may operate the chain contract. or just get_root need check: but here tx to mutable err, %s .chain offline. just restart to try.", err)
-						SystemOutOfService = true
+						setOutOfService(fmt.Sprintf("tx %s from other server to mutable: %s", event.TxHash, err))
 						return
 					}
 
@@ -879,21 +985,27 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 					for i := uint32(0); i < uint32(len(leafv)); i++ {
 						if tmpTree.TreeSize() == math.MaxUint32 {
 							log.Errorf("RoutineOfAddToLocalStorage: get tx from other server, Over max the MaxUint32 merkle size.")
-							SystemOutOfService = true
+							setOutOfService("over max the MaxUint32 merkle size")
 							return
 						}
 						tmpTree.AppendHash(leafv[i])
 						putLeafIndex(&store, leafv[i], tmpTree.TreeSize()-1, localHeight, event.TxHash)
 					}
+					submitTimes = append(submitTimes, leafSubmitTimes(&store, leafv)...)
+					putLeafStage(&store, leafv, localHeight, stageOf(LEAF_STAGE_ANCHORED, txh))
+					resolvePromises(&store, leafv)
 
 					log.Infof("tx hash, %s, Local Height: %d, CurrentBlockHeight: %d", event.TxHash, localHeight, blockHeight)
 					if newroot != tmpTree.Root() || newtreeSize != tmpTree.TreeSize() {
-						SystemOutOfService = true
+						setOutOfService(fmt.Sprintf("root of tx %s from other server not equal chain root", event.TxHash))
 						log.Fatalf("RoutineOfAddToLocalStorage: get tx from other server, chainroot: %x, root : %x, chaintreeSize: %d, treeSize: %d", newroot, tmpTree.Root(), newtreeSize, tmpTree.TreeSize())
 						return
 					}
 
-					putRootBlockHeight(&store, tmpTree.Root(), localHeight)
+					putRootInfo(&store, tmpTree.Root(), tmpTree.TreeSize(), localHeight, txh)
+					anchorEvent := newAnchoredEvent(leafv, tmpTree, localHeight, txh)
+					enqueueWebhooks(&store, anchorEvent)
+					anchorEvents = append(anchorEvents, anchorEvent)
 					log.Infof("tx from other server. root: %x, treeSize: %d", tmpTree.Root(), tmpTree.TreeSize())
 				}
 				// here indicate tx not influence contract. check next event.
@@ -916,12 +1028,14 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 		SaveCompactMerkleTree(tmpTree, &store)
 		TxStore.UpdateSelfToBatch(&store, addHashes)
 
-		// BatchCommit here to commit oneblock localstorage.
+		// BatchCommit here to commit oneblock localstorage. under MTlock so markTxSubmitted not overwrite the anchored leafs.
+		MTlock.Lock()
 		if !lastFileHashAppendFailed {
 			err = store.BatchCommit()
 			if err != nil {
+				MTlock.Unlock()
 				log.Errorf("RoutineOfAddToLocalStorage: ledger BatchCommit err, %s", err)
-				SystemOutOfService = true
+				setOutOfService(fmt.Sprintf("ledger BatchCommit: %s", err))
 				return
 			}
 		}
@@ -930,7 +1044,6 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 		TxStore.PublishAddHashes(addHashes)
 
 		// update merkle tree. note new merkle tree has save to leveldb. so restart will see this. here acctually to handle FileHashStore.
-		MTlock.Lock()
 		t := merkle.NewTree(tmpTree.TreeSize(), tmpTree.Hashes(), FileHashStore)
 		err = FileHashStore.Append(memhashstore.Hashes)
 		if err != nil {
@@ -950,6 +1063,9 @@ func RoutineOfAddToLocalStorage(correctDatabase uint32) {
 
 		// clear the lastFileHashAppendFailed. here success
 		lastFileHashAppendFailed = false
+		DefAnchorBroker.Publish(anchorEvents)
+		observeAnchorLatency(submitTimes)
+		notifyWebhook()
 		// block handle done. publish the DefMerkleTree to Verify.
 	}
 }
@@ -959,7 +1075,7 @@ func handleFileStoreFailed(err error, localHeight uint32) {
 	store = *DefStore
 	store.NewBatch()
 
-	SystemOutOfService = true
+	setOutOfService(fmt.Sprintf("FileHashStore append: %s", err))
 	lastFileHashAppendFailed = true
 	// this will cause lose FileHashStore data.
 	log.Errorf("RoutineOfAddToLocalStorage: FileHashStore Flush err, %s", err)
@@ -1070,7 +1186,7 @@ func cacheLeafs() {
 	for {
 		select {
 		case <-cacheQuitChannel:
-			SystemOutOfService = true
+			setOutOfService("server exit")
 			leafsCache = runleafs(leafsCache, true)
 			return
 		case t := <-cacheChannel:
@@ -1079,6 +1195,7 @@ func cacheLeafs() {
 		case <-time.After(time.Second * seconds):
 			leafsCache = runleafs(leafsCache, true)
 		}
+		atomic.StoreInt64(&cachedLeafNum, int64(len(leafsCache)))
 	}
 }
 
@@ -1093,6 +1210,8 @@ func ledgerAppendTx(store leveldbstore.LevelDBStore, leafv []common.Uint256) err
 	if err != nil {
 		return err
 	}
+	putLeafStage(&store, leafv, 0, stageOf(LEAF_STAGE_BATCHED, tx.Hash()))
+	putNewBatch(&store, tx.Hash(), leafv, common.UINT256_EMPTY)
 
 	addHashes := make([]common.Uint256, 0, 1)
 	addHashes = append(addHashes, tx.Hash())
@@ -1114,13 +1233,56 @@ func ledgerAppendTxRoll(store leveldbstore.LevelDBStore, leafv []common.Uint256)
 		log.Infof("ledgerAppendTxRoll err : %s", err)
 		for i := uint32(0); i < uint32(len(leafv)); i++ {
 			delLeafIndex(DefStore, leafv[i])
+			delLeafStatus(DefStore, leafv[i])
+			delLeafSubmitter(DefStore, leafv[i])
+			delLeafMetadata(DefStore, leafv[i])
 		}
 	}
 
 	return err
 }
 
-func RoutineOfBatchAdd(leafv []common.Uint256) ([]string, error) {
+// splitDuplicateLeafs the leafs not seen before and the duplicates. any duplicate is error unless idempotent batchAdd and all submitted by submitter before.
+func splitDuplicateLeafs(store *leveldbstore.LevelDBStore, leafv []common.Uint256, submitter common.Address) ([]common.Uint256, []string, error) {
+	newLeafs := make([]common.Uint256, 0, len(leafv))
+	duplicateLeafs := make([]string, 0)
+	otherLeafs := make([]string, 0)
+	seen := make(map[common.Uint256]bool, len(leafv))
+
+	for _, leaf := range leafv {
+		if DefConfig.IdempotentBatchAdd {
+			// retry may repeat leaf in one batch.
+			if seen[leaf] {
+				continue
+			}
+			seen[leaf] = true
+		}
+
+		_, err := getLeafIndex(store, leaf)
+		if err != nil {
+			newLeafs = append(newLeafs, leaf)
+			continue
+		}
+
+		duplicateLeafs = append(duplicateLeafs, common.ToHexString(leaf[:]))
+		if owner, err := getLeafSubmitter(store, leaf); err != nil || owner != submitter {
+			otherLeafs = append(otherLeafs, common.ToHexString(leaf[:]))
+		}
+	}
+
+	if !DefConfig.IdempotentBatchAdd && len(duplicateLeafs) != 0 {
+		return nil, duplicateLeafs, errors.New("duplicate hash leafs. please check.")
+	}
+	if len(otherLeafs) != 0 {
+		return nil, otherLeafs, errors.New("duplicate hash leafs of other submitter. please check.")
+	}
+
+	return newLeafs, duplicateLeafs, nil
+}
+
+// RoutineOfBatchAdd receipt committed with the leafs if not nil. duplicates of submitter returned without error in idempotent batchAdd. and only the new leafs added.
+// algorithm recorded for the new leafs if hashed by server. metadata one of each leaf.
+func RoutineOfBatchAdd(leafv []common.Uint256, submitter common.Address, receipt *Receipt, promises []*Promise, metadata []*LeafMetadata, algorithm HashAlgorithm) ([]string, error) {
 	var store leveldbstore.LevelDBStore
 	store = *DefStore
 	store.NewBatch()
@@ -1128,18 +1290,32 @@ func RoutineOfBatchAdd(leafv []common.Uint256) ([]string, error) {
 	wg.Add(1)
 	defer wg.Done()
 
-	store.NewBatch()
-
 	var tx *types.MutableTransaction
 	var err error
-	// only batchnum construct tx
-	addHashes := make([]common.Uint256, 0, 1)
+	// only batchnum construct tx. dropped if not all leafs new.
 	if uint32(len(leafv)) == DefConfig.BatchNum {
 		tx, err = constructTransation(DefSdk, leafv)
 		if err != nil {
 			return nil, err
 		}
+	}
 
+	// only lock the duplicate logic
+	Existlock.Lock()
+	defer Existlock.Unlock()
+
+	// check before any leaf put to batch.
+	newLeafs, duplicateLeafs, err := splitDuplicateLeafs(&store, leafv, submitter)
+	metricBatchAddDuplicates.Add(float64(len(duplicateLeafs)))
+	if err != nil {
+		return duplicateLeafs, err
+	}
+	if len(newLeafs) != len(leafv) {
+		tx = nil
+	}
+
+	addHashes := make([]common.Uint256, 0, 1)
+	if tx != nil {
 		err = putTransaction(&store, tx)
 		if err != nil {
 			return nil, err
@@ -1150,41 +1326,56 @@ func RoutineOfBatchAdd(leafv []common.Uint256) ([]string, error) {
 		TxStore.UpdateSelfToBatch(&store, addHashes)
 	}
 
-	// only lock the duplicate logic
-	Existlock.Lock()
-	defer Existlock.Unlock()
-	duplicateLeafs := make([]string, 0)
+	// metadata of the first one if repeated in batch.
+	metadataOf := make(map[common.Uint256]*LeafMetadata, len(metadata))
+	for i := len(metadata) - 1; i >= 0; i-- {
+		metadataOf[leafv[i]] = metadata[i]
+	}
 
-	for i := uint32(0); i < uint32(len(leafv)); i++ {
-		_, err := getLeafIndex(&store, leafv[i])
-		if err == nil {
-			duplicateLeafs = append(duplicateLeafs, common.ToHexString(leafv[i][:]))
+	for _, leaf := range newLeafs {
+		putLeafIndex(&store, leaf, math.MaxUint32, 0, common.UINT256_EMPTY.ToHexString())
+		putLeafSubmitter(&store, leaf, submitter)
+		if algorithm != HASH_CLIENT {
+			putLeafHashAlgorithm(&store, leaf, algorithm)
+		}
+		if m, ok := metadataOf[leaf]; ok {
+			putLeafMetadata(&store, leaf, m)
 		}
-		putLeafIndex(&store, leafv[i], math.MaxUint32, 0, common.UINT256_EMPTY.ToHexString())
 	}
 
-	if len(duplicateLeafs) != 0 {
-		return duplicateLeafs, errors.New("duplicate hash leafs. please check.")
+	if tx != nil {
+		putLeafStage(&store, newLeafs, 0, stageOf(LEAF_STAGE_BATCHED, tx.Hash()))
+		putNewBatch(&store, tx.Hash(), newLeafs, common.UINT256_EMPTY)
+	} else if len(newLeafs) != 0 {
+		putLeafStage(&store, newLeafs, 0, stageOf(LEAF_STAGE_QUEUED, merkle.EMPTY_HASH))
 	}
 
+	if receipt != nil {
+		putReceipt(&store, receipt)
+	}
+	// promises of duplicates made by the first batchAdd.
+	putPromises(&store, promisesExcept(promises, duplicateLeafs))
+
 	// this must be lock.
 	err = store.BatchCommit()
 	if err != nil {
 		return nil, err
 	}
+	metricBatchAddLeafs.Add(float64(len(newLeafs)))
 
 	// send to cache.
-	if uint32(len(leafv)) != DefConfig.BatchNum {
-		leafs := cacheCh{
-			Leafs: leafv,
+	if tx == nil {
+		if len(newLeafs) != 0 {
+			cacheChannel <- cacheCh{
+				Leafs: newLeafs,
+			}
 		}
-		cacheChannel <- leafs
-		return nil, nil
+		return duplicateLeafs, nil
 	}
 
 	TxStore.PublishAddHashes(addHashes)
 
-	return nil, nil
+	return duplicateLeafs, nil
 }
 
 func leafvFromTx(tx *types.MutableTransaction) ([]common.Uint256, error) {
@@ -1273,7 +1464,7 @@ func SendTxIter(k interface{}) (bool, error) {
 	txh, ok := k.(common.Uint256)
 	if !ok {
 		log.Errorf("RoutineOfSendTx, sync map key is not hash type")
-		SystemOutOfService = true
+		setOutOfService("RoutineOfSendTx: sync map key is not hash type")
 		return false, fmt.Errorf("RoutineOfSendTx, sync map key is not hash type")
 	}
 
@@ -1282,13 +1473,13 @@ func SendTxIter(k interface{}) (bool, error) {
 	tx, err := getTransaction(&store, txh)
 	if err != nil {
 		log.Errorf("RoutineOfSendTx: %s", err)
-		SystemOutOfService = true
+		setOutOfService(fmt.Sprintf("RoutineOfSendTx: get tx %x: %s", txh, err))
 		return false, err
 	}
-	_, err = leafvFromTx(tx)
+	leafv, err := leafvFromTx(tx)
 	if err != nil {
 		log.Errorf("RoutineOfSendTx: %s", err)
-		SystemOutOfService = true
+		setOutOfService(fmt.Sprintf("RoutineOfSendTx: leafs of tx %x: %s", txh, err))
 		return false, err
 	}
 
@@ -1297,6 +1488,13 @@ func SendTxIter(k interface{}) (bool, error) {
 		return true, err
 	}
 
+	markTxSubmitted(txh, leafv)
+	if markBatchSent(txh) > 1 {
+		metricTxResent.Inc()
+	} else {
+		metricTxSent.Inc()
+	}
+
 	return true, nil
 }
 
@@ -1321,6 +1519,8 @@ type VerifyResult struct {
 	TxHash      string           `json:"txHash"`
 	LeafHeight  uint32           `json:"leafHeight"`
 	Proof       []common.Uint256 `json:"proof"`
+	// only in verify result. nil if leaf added before metadata recorded.
+	Metadata *LeafMetadataJson `json:"metadata,omitempty"`
 }
 
 func (self VerifyResult) MarshalJSON() ([]byte, error) {
@@ -1331,13 +1531,14 @@ func (self VerifyResult) MarshalJSON() ([]byte, error) {
 	}
 
 	res := struct {
-		Root        string   `json:"root"`
-		TreeSize    uint32   `json:"size"`
-		BlockHeight uint32   `json:"blockheight"`
-		Index       uint32   `json:"index"`
-		TxHash      string   `json:"txHash"`
-		LeafHeight  uint32   `json:"leafHeight"`
-		Proof       []string `json:"proof"`
+		Root        string            `json:"root"`
+		TreeSize    uint32            `json:"size"`
+		BlockHeight uint32            `json:"blockheight"`
+		Index       uint32            `json:"index"`
+		TxHash      string            `json:"txHash"`
+		LeafHeight  uint32            `json:"leafHeight"`
+		Proof       []string          `json:"proof"`
+		Metadata    *LeafMetadataJson `json:"metadata,omitempty"`
 	}{
 		Root:        root,
 		TreeSize:    self.TreeSize,
@@ -1346,6 +1547,7 @@ func (self VerifyResult) MarshalJSON() ([]byte, error) {
 		TxHash:      self.TxHash,
 		LeafHeight:  self.LeafHeight,
 		Proof:       proof,
+		Metadata:    self.Metadata,
 	}
 
 	return json.Marshal(res)
@@ -1353,13 +1555,14 @@ func (self VerifyResult) MarshalJSON() ([]byte, error) {
 
 func (self *VerifyResult) UnmarshalJSON(buf []byte) error {
 	res := struct {
-		Root        string   `json:"root"`
-		TreeSize    uint32   `json:"size"`
-		BlockHeight uint32   `json:"blockheight"`
-		Index       uint32   `json:"index"`
-		TxHash      string   `json:"txHash"`
-		LeafHeight  uint32   `json:"leafHeight"`
-		Proof       []string `json:"proof"`
+		Root        string            `json:"root"`
+		TreeSize    uint32            `json:"size"`
+		BlockHeight uint32            `json:"blockheight"`
+		Index       uint32            `json:"index"`
+		TxHash      string            `json:"txHash"`
+		LeafHeight  uint32            `json:"leafHeight"`
+		Proof       []string          `json:"proof"`
+		Metadata    *LeafMetadataJson `json:"metadata,omitempty"`
 	}{}
 
 	if len(buf) == 0 {
@@ -1385,6 +1588,7 @@ func (self *VerifyResult) UnmarshalJSON(buf []byte) error {
 	self.Proof = proof
 	self.TxHash = res.TxHash
 	self.LeafHeight = res.LeafHeight
+	self.Metadata = res.Metadata
 
 	return nil
 }
@@ -1553,17 +1757,51 @@ func startOGQServer(ctx *cli.Context) error {
 		return err
 	}
 
+	InitBatchHistory(DefStore)
+
+	err = InitAuthorizedKeys(DefStore)
+	if err != nil {
+		return err
+	}
+
+	err = InitWebhook(DefStore)
+	if err != nil {
+		return err
+	}
+
 	if correctDatabase != CORRECT_ONLY {
+		err = InitTls()
+		if err != nil {
+			return err
+		}
+
 		err = initRPCServer()
 		if err != nil {
 			return err
 		}
 
+		if len(DefConfig.AdminAddr) != 0 {
+			err = StartAdminServer()
+			if err != nil {
+				return err
+			}
+		}
+
+		if DefConfig.GrpcPort != 0 {
+			err = StartGrpcServer()
+			if err != nil {
+				return err
+			}
+		}
+
 		if sigDB == nil {
 			return errors.New("sigDB nil. init failed.")
 		}
 		go StoreSigData(sigDataChan, sigDB)
 		go RoutineOfSendTx()
+		go RoutineOfWebhook()
+		go RoutineOfBatchAddNonceExpire()
+		go RoutineOfPromiseCheck()
 	}
 
 	go RoutineOfAddToLocalStorage(correctDatabase)
@@ -1595,11 +1833,26 @@ func initRPCServer() error {
 }
 
 func StartRPCServer() error {
-	http.HandleFunc("/", RpcHandle)
+	listener, err := listenRpc(":" + strconv.Itoa(DefConfig.ServerPort))
+	if err != nil {
+		return fmt.Errorf("Listen error:%s", err)
+	}
 
-	err := http.ListenAndServe(":"+strconv.Itoa(DefConfig.ServerPort), nil)
+	server := newRpcHttpServer(CorsHandler(BodyLimitHandler(newRpcMux())))
+	if DefTlsReloader != nil {
+		server.Handler = TlsIdentityHandler(server.Handler)
+		server.TLSConfig = DefTlsReloader.Config()
+		// certificate from TLSConfig.
+		err = server.ServeTLS(listener, "", "")
+		if err != nil {
+			return fmt.Errorf("ServeTLS error:%s", err)
+		}
+		return nil
+	}
+
+	err = server.Serve(listener)
 	if err != nil {
-		return fmt.Errorf("ListenAndServe error:%s", err)
+		return fmt.Errorf("Serve error:%s", err)
 	}
 	return nil
 }
@@ -1636,51 +1889,39 @@ func getRawDataForVerifySig(hashes []string) ([]byte, error) {
 	return verifyData, nil
 }
 
-func rpcVerify(vargs *RpcParam) map[string]interface{} {
+// pubKey authorize checked by rpc dispatch.
+func rpcVerify(vargs *VerifyParam) map[string]interface{} {
 	if SystemOutOfService {
 		return responsePack(NODE_OUTSERVICE, "Out of Service")
 	}
+	defer observeVerifyLatency(time.Now())
 
 	if len(vargs.Hashes) != 1 {
 		return responsePack(INVALID_PARAM, nil)
 	}
 
-	pubkey, _, err := getPublicSigData(vargs.PubKey, "")
-	if err != nil {
-		log.Infof("%s", err)
-		return responsePack(INVALID_PARAM, nil)
-	}
-
-	address := types.AddressFromPubKey(pubkey)
-	if !checkAuthorizeOfAddress(address) {
-		return responsePack(NO_AUTH, nil)
-	}
-
 	leaf, err := HashFromHexString(vargs.Hashes[0])
 	if err != nil {
 		log.Infof("Verify convert params err: %s\n", err)
 		return responsePack(INVALID_PARAM, nil)
 	}
 
-	var root common.Uint256
-	var treeSize uint32
-	var blockheight uint32
-
+	// root and treeSize of target in params. or the current.
 	MTlock.RLock()
-	root = DefMerkleTree.Root()
-	treeSize = DefMerkleTree.TreeSize()
-	blockheight, err = getRootBlockHeight(DefStore, root)
+	root, treeSize, blockheight, err := verifyTarget(vargs)
 	MTlock.RUnlock()
 	if err != nil {
-		log.Debugf("get blockheight failed, %s", err)
-		return responsePack(VERIFY_FAILED, nil)
+		log.Debugf("get verify target failed, %s", err)
+		return responseFailed(VERIFY_FAILED, err.Error(), nil)
 	}
 
 	proof, index, err := Verify(DefStore, leaf, root, treeSize)
 	if err != nil {
 		log.Debugf("verify failed %s", err)
+		metricVerify.WithLabelValues("miss").Inc()
 		return responsePack(VERIFY_FAILED, nil)
 	}
+	metricVerify.WithLabelValues("hit").Inc()
 
 	_, leafBlockHeight, leafTxHash, err := getLeafInfo(DefStore, leaf)
 	if err == LEAF_HEIGHT_EMPTY_ERR {
@@ -1697,6 +1938,7 @@ func rpcVerify(vargs *RpcParam) map[string]interface{} {
 		TxHash:      leafTxHash,
 		LeafHeight:  leafBlockHeight,
 		Proof:       proof,
+		Metadata:    leafMetadataJson(DefStore, leaf),
 	}
 
 	log.Debugf("Verify leaf ok :%x, root:%x, treeSize: %d\n", leaf, root, treeSize)
@@ -1727,10 +1969,19 @@ func getPublicSigData(pubs string, sigs string) (keypair.PublicKey, []byte, erro
 const (
 	sigDataIndexKey  = "sigDataIndexKey"
 	sigDataKeyPrefix = "sigDataKey"
+	// after hashes of record signed by envelope. not a hex hash so never a leaf.
+	sigDataEnvelopeMark = "envelope"
+	// after leafs of record of signed addData request.
+	sigDataAddDataMark = "addData"
 )
 
+// sigRecord written to sigDB in submit order. params of batchAdd or the signed request of addData.
+type sigRecord interface {
+	writeSigRecord(sink *common.ZeroCopySink)
+}
+
 var (
-	sigDataChan = make(chan *RpcParam, 256)
+	sigDataChan = make(chan sigRecord, 256)
 	sigQuitChan = make(chan bool)
 )
 
@@ -1754,7 +2005,29 @@ func putSigDataIndex(sigDB *leveldbstore.LevelDBStore, index uint32) {
 	sigDB.BatchPut([]byte(sigDataIndexKey), sink.Bytes())
 }
 
-func putSigData(store *leveldbstore.LevelDBStore, sigData *RpcParam) (uint32, error) {
+func (self *RpcParam) writeSigRecord(sink *common.ZeroCopySink) {
+	sink.WriteString(self.PubKey)
+	sink.WriteString(self.Sigature)
+	for _, h := range self.Hashes {
+		sink.WriteString(h)
+	}
+	// legacy records end with hashes.
+	if self.Version != BATCHADD_SIG_LEGACY {
+		sink.WriteString(sigDataEnvelopeMark)
+		sink.WriteUint32(self.Version)
+		sink.WriteUint64(uint64(self.Timestamp))
+		sink.WriteString(self.Nonce)
+		// signed only if set. records without key and metadata end with nonce.
+		if len(self.IdempotencyKey) != 0 || len(self.Metadata) != 0 {
+			sink.WriteString(self.IdempotencyKey)
+		}
+		if len(self.Metadata) != 0 {
+			sink.WriteVarBytes(serializeLeafMetadataParams(self.Metadata))
+		}
+	}
+}
+
+func putSigData(store *leveldbstore.LevelDBStore, sigData sigRecord) (uint32, error) {
 	index, err := getSigDataIndex(store)
 	if err != nil {
 		return 0, err
@@ -1765,11 +2038,7 @@ func putSigData(store *leveldbstore.LevelDBStore, sigData *RpcParam) (uint32, er
 
 	// value bytes
 	sink := common.NewZeroCopySink(nil)
-	sink.WriteString(sigData.PubKey)
-	sink.WriteString(sigData.Sigature)
-	for _, h := range sigData.Hashes {
-		sink.WriteString(h)
-	}
+	sigData.writeSigRecord(sink)
 
 	// key bytes.
 	sinkey := common.NewZeroCopySink(nil)
@@ -1804,23 +2073,79 @@ func verifySigIndex(store *leveldbstore.LevelDBStore, index uint32) error {
 	}
 	source := common.NewZeroCopySource(raw)
 	pks, _, irregular, eof := source.NextString()
-	sigData, _, irregular, eof := source.NextString()
 	if irregular || eof {
 		return errors.New("wrong decode pks")
 	}
-	hashes := make([]string, 0)
-	for {
+	sigData, _, irregular, eof := source.NextString()
+	if irregular || eof {
+		return errors.New("wrong decode sigData")
+	}
+	param := &RpcParam{
+		Hashes: make([]string, 0),
+	}
+	// legacy records end with hashes. fields after nonce only if set. others must be complete.
+	for source.Len() != 0 {
 		h, _, irregular, eof := source.NextString()
-		if eof || irregular {
-			break
+		if irregular || eof {
+			return fmt.Errorf("wrong decode hash %d", len(param.Hashes))
+		}
+		if h == sigDataAddDataMark {
+			record := &DataSigRecord{PubKey: pks, Signature: sigData, Leafs: param.Hashes}
+			err = record.readSigRecord(source)
+			if err != nil {
+				return err
+			}
+			return record.verify()
+		}
+		if h != sigDataEnvelopeMark {
+			param.Hashes = append(param.Hashes, h)
+			continue
+		}
+
+		version, eof := source.NextUint32()
+		if eof {
+			return errors.New("wrong decode envelope version")
+		}
+		timestamp, eof := source.NextUint64()
+		if eof {
+			return errors.New("wrong decode envelope timestamp")
+		}
+		nonce, _, irregular, eof := source.NextString()
+		if irregular || eof {
+			return errors.New("wrong decode envelope nonce")
+		}
+		param.Version = version
+		param.Timestamp = int64(timestamp)
+		param.Nonce = nonce
+		if source.Len() != 0 {
+			key, _, irregular, eof := source.NextString()
+			if irregular || eof {
+				return errors.New("wrong decode envelope idempotencyKey")
+			}
+			param.IdempotencyKey = key
+		}
+		if source.Len() != 0 {
+			raw, _, irregular, eof := source.NextVarBytes()
+			if irregular || eof {
+				return errors.New("wrong decode envelope metadata")
+			}
+			param.Metadata, err = deserializeLeafMetadataParams(raw)
+			if err != nil {
+				return err
+			}
+		}
+		if source.Len() != 0 {
+			return fmt.Errorf("%d bytes after envelope", source.Len())
 		}
-		hashes = append(hashes, h)
 	}
 	pubkeyraw, sigDataraw, err := getPublicSigData(pks, sigData)
 	if err != nil {
 		return err
 	}
-	_, verifyData, err := convertParamsToLeafs(hashes)
+	verifyData, err := batchAddSignData(param)
+	if err != nil {
+		return err
+	}
 	err = signature.Verify(pubkeyraw, verifyData, sigDataraw)
 	if err != nil {
 		return err
@@ -1829,7 +2154,7 @@ func verifySigIndex(store *leveldbstore.LevelDBStore, index uint32) error {
 	return nil
 }
 
-func StoreSigData(sigDataChan chan *RpcParam, sigDB *leveldbstore.LevelDBStore) {
+func StoreSigData(sigDataChan chan sigRecord, sigDB *leveldbstore.LevelDBStore) {
 	for {
 		select {
 		case <-sigQuitChan:
@@ -1891,22 +2216,12 @@ func rpcGetRoot() map[string]interface{} {
 
 const maxDeclineNum uint32 = 512
 
+// pubKey authorize and signature checked by rpc dispatch.
 func rpcBatchAdd(addargs *RpcParam) map[string]interface{} {
 	if SystemOutOfService {
 		return responsePack(NODE_OUTSERVICE, "Out of Service")
 	}
 
-	pubkey, sigData, err := getPublicSigData(addargs.PubKey, addargs.Sigature)
-	if err != nil {
-		log.Infof("%s", err)
-		return responsePack(INVALID_PARAM, err.Error())
-	}
-
-	address := types.AddressFromPubKey(pubkey)
-	if !checkAuthorizeOfAddress(address) {
-		return responsePack(NO_AUTH, "pubkey do not have authorize.")
-	}
-
 	params := addargs.Hashes
 
 	if uint32(len(params)) > maxDeclineNum || uint32(len(params)) == 0 {
@@ -1914,34 +2229,108 @@ func rpcBatchAdd(addargs *RpcParam) map[string]interface{} {
 		return responsePack(INVALID_PARAM, "too much or empty hashes")
 	}
 
-	hashes, verifyData, err := convertParamsToLeafs(params)
+	hashes, _, err := convertParamsToLeafs(params)
 	if err != nil {
 		log.Infof("batch add convert params err: %s\n", err)
 		return responsePack(INVALID_PARAM, err.Error())
 	}
 
-	err = signature.Verify(pubkey, verifyData, sigData)
+	pubkey, _, err := getPublicSigData(addargs.PubKey, "")
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error())
+	}
+
+	metricBatchAddRequests.Inc()
+	response, added := addLeafs(addargs.PubKey, types.AddressFromPubKey(pubkey), hashes, addargs.Metadata, HASH_CLIENT, addargs.IdempotencyKey)
+	if added {
+		sigDataChan <- addargs
+	}
+
+	return response
+}
+
+// addLeafs of submitter with receipt, promises and metadata. the response recorded for the idempotency key. true if the leafs added by this call.
+func addLeafs(pubKey string, submitter common.Address, hashes []common.Uint256, params []*LeafMetadataParam, algorithm HashAlgorithm, idempotencyKey string) (map[string]interface{}, bool) {
+	err := checkLeafMetadataParams(len(hashes), params)
+	if err != nil {
+		return responsePack(INVALID_PARAM, err.Error()), false
+	}
+
+	digest := idempotencyDigest(hashes, params)
+	if len(idempotencyKey) != 0 {
+		err = checkIdempotencyKey(idempotencyKey)
+		if err != nil {
+			return responsePack(INVALID_PARAM, err.Error()), false
+		}
+
+		unlock, err := lockIdempotencyKey(submitter, idempotencyKey)
+		if err != nil {
+			return responseFailed(ADDHASH_FAILED, err.Error(), nil), false
+		}
+		defer unlock()
+
+		res, err := getIdempotentResponse(DefStore, submitter, idempotencyKey, digest)
+		if err != nil {
+			return responsePack(INVALID_PARAM, err.Error()), false
+		}
+		if res != nil {
+			if DefConfig.LegacyResponse {
+				return responseSuccess("Cached Success"), false
+			}
+			return responseSuccess(res), false
+		}
+	}
+
+	receipt, err := newReceipt(submitter, hashes)
+	if err != nil {
+		log.Errorf("batch add sign receipt failed %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil), false
+	}
+	promises, err := newPromises(hashes, receipt.Timestamp)
 	if err != nil {
-		return responsePack(NO_AUTH, "Verify failed. sigData not right.")
+		log.Errorf("batch add sign promises failed %s", err)
+		return responseFailed(INTERNAL_ERROR, err.Error(), nil), false
 	}
 
-	dup, err := RoutineOfBatchAdd(hashes)
+	metadata := newLeafMetadata(pubKey, submitter, params, hashes, receipt.Timestamp)
+	dup, err := RoutineOfBatchAdd(hashes, submitter, receipt, promises, metadata, algorithm)
 	if err != nil {
 		log.Infof("batch add failed %s\n", err)
 		if dup != nil {
-			return responseFailed(DUP_HASH, err.Error(), dup)
+			return responseFailed(DUP_HASH, err.Error(), dup), false
 		} else {
-			return responseFailed(ADDHASH_FAILED, err.Error(), dup)
+			return responseFailed(ADDHASH_FAILED, err.Error(), dup), false
+		}
+	}
+
+	res := receipt.toJson()
+	res.Promises = make([]*PromiseJson, 0, len(promises))
+	for _, promise := range promisesExcept(promises, dup) {
+		res.Promises = append(res.Promises, promise.toJson())
+	}
+	for _, hash := range dup {
+		leaf, _ := HashFromHexString(hash)
+		res.Duplicates = append(res.Duplicates, queryLeafStatus(hash, leaf))
+	}
+	if algorithm != HASH_CLIENT {
+		res.Hashes = make([]string, 0, len(hashes))
+		for _, leaf := range hashes {
+			res.Hashes = append(res.Hashes, hex.EncodeToString(leaf[:]))
 		}
 	}
 
-	sigDataChan <- addargs
+	if len(idempotencyKey) != 0 {
+		err = putIdempotentResponse(DefStore, submitter, idempotencyKey, digest, res)
+		if err != nil {
+			log.Errorf("batch add put idempotent response failed %s", err)
+		}
+	}
 
-	if DefConfig.BatchAddSleepTime != 0 {
-		time.Sleep(time.Second * time.Duration(DefConfig.BatchAddSleepTime))
+	if DefConfig.LegacyResponse {
+		return responseSuccess("Cached Success"), true
 	}
 
-	return responseSuccess("Cached Success")
+	return responseSuccess(res), true
 }
 
 func responseSuccess(result interface{}) map[string]interface{} {
@@ -1973,7 +2362,7 @@ func waitToExit(ctx *cli.Context) {
 	go func() {
 		for sig := range sc {
 			log.Infof("OGQ server received exit signal: %v.", sig.String())
-			SystemOutOfService = true
+			setOutOfService("server exit")
 			time.Sleep(time.Second * time.Duration(10))
 			cacheQuitChannel <- true
 			sigQuitChan <- true
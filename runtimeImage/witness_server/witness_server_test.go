@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	sdk "github.com/ontio/ontology-go-sdk"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+)
+
+func TestVerifySigIndex(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(0)
+	store.Put([]byte(sigDataIndexKey), sink.Bytes())
+
+	acc := sdk.NewAccount()
+	param := &RpcParam{
+		PubKey:         hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+		Hashes:         []string{hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(append(make([]byte, 31), 1))},
+		Version:        BATCHADD_SIG_V1,
+		Timestamp:      time.Now().Unix(),
+		Nonce:          "sig index",
+		IdempotencyKey: "key",
+	}
+	data, err := batchAddSignData(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := acc.Sign(data)
+	param.Sigature = hex.EncodeToString(sig)
+
+	store.NewBatch()
+	index, err := putSigData(store, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifySigIndex(store, index); err != nil {
+		t.Fatalf("stored record: %s", err)
+	}
+
+	sink.Reset()
+	sink.WriteUint32(index)
+	key := append([]byte(sigDataKeyPrefix), sink.Bytes()...)
+	raw, _ := store.Get(key)
+	for n := len(raw) - 1; n > 0; n-- {
+		store.Put(key, raw[:n])
+		if err = verifySigIndex(store, index); err == nil {
+			t.Errorf("record truncated to %d of %d bytes verified", n, len(raw))
+		}
+	}
+
+	store.Put(key, append(append([]byte{}, raw...), 0))
+	if err = verifySigIndex(store, index); err == nil {
+		t.Errorf("record with trailing byte verified")
+	}
+}
+
+func TestVerifySigIndexAddData(t *testing.T) {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(0)
+	store.Put([]byte(sigDataIndexKey), sink.Bytes())
+
+	acc := sdk.NewAccount()
+	param := &DataParam{
+		PubKey:    hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey)),
+		Timestamp: time.Now().Unix(),
+		Nonce:     "sig index data",
+		Algorithm: "sha256",
+		payloads:  [][]byte{[]byte("a"), []byte("b")},
+	}
+	data, err := requestSignData("addData", param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := acc.Sign(data)
+	param.Signature = hex.EncodeToString(sig)
+	request, _ := param.SignData()
+	_, hashes, _ := param.leafs(HASH_SHA256, 2)
+	record := &DataSigRecord{PubKey: param.PubKey, Signature: param.Signature, Leafs: hashes, Timestamp: param.Timestamp, Nonce: param.Nonce, Request: request}
+
+	store.NewBatch()
+	index, err := putSigData(store, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = verifySigIndex(store, index); err != nil {
+		t.Fatalf("stored addData record: %s", err)
+	}
+
+	// leaf dropped. payloads signed not match.
+	record.Leafs = hashes[:1]
+	store.NewBatch()
+	if index, err = putSigData(store, record); err != nil {
+		t.Fatal(err)
+	}
+	if err = verifySigIndex(store, index); err == nil {
+		t.Errorf("record with a leaf dropped verified")
+	}
+
+	record.Leafs, record.Nonce = hashes, "other"
+	store.NewBatch()
+	if index, err = putSigData(store, record); err != nil {
+		t.Fatal(err)
+	}
+	if err = verifySigIndex(store, index); err == nil {
+		t.Errorf("record with nonce changed verified")
+	}
+}
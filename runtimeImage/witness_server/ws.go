@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+)
+
+const (
+	wsChallengeSize   = 32
+	wsMaxLeafs        = 4096
+	wsSendBufSize     = 256
+	wsHubBufSize      = 1024
+	wsPingInterval    = 30 * time.Second
+	wsReadTimeout     = 60 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+	wsMaxMessageBytes = MAX_REQUEST_BODY_SIZE
+)
+
+const (
+	WS_MSG_CHALLENGE = "challenge"
+	WS_MSG_RESULT    = "result"
+	WS_MSG_ANCHORED  = "anchored"
+	WS_MSG_FAILED    = "failed"
+	WS_MSG_ROOT      = "root"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     wsCheckOrigin,
+}
+
+// WsRequest from client. auth sign the challenge of the connection with pubKey. subscribe/unsubscribe need auth first.
+type WsRequest struct {
+	Id        string   `json:"id"`
+	Action    string   `json:"action"`
+	PubKey    string   `json:"pubKey,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+	Leafs     []string `json:"leafs,omitempty"`
+	Owner     bool     `json:"owner,omitempty"`
+	Roots     bool     `json:"roots,omitempty"`
+}
+
+type WsMessage struct {
+	Type        string      `json:"type"`
+	Id          string      `json:"id,omitempty"`
+	Error       int64       `json:"error,omitempty"`
+	Desc        string      `json:"desc,omitempty"`
+	Challenge   string      `json:"challenge,omitempty"`
+	Leaf        string      `json:"leaf,omitempty"`
+	Index       *uint32     `json:"index,omitempty"`
+	Root        string      `json:"root,omitempty"`
+	TreeSize    uint32      `json:"size,omitempty"`
+	BlockHeight uint32      `json:"blockheight,omitempty"`
+	TxHash      string      `json:"txHash,omitempty"`
+	NewTxHash   string      `json:"newTxHash,omitempty"`
+	LeafCount   uint32      `json:"leafCount,omitempty"`
+	Result      interface{} `json:"result,omitempty"`
+}
+
+type wsSession struct {
+	ctx       context.Context
+	conn      *websocket.Conn
+	challenge []byte
+	out       chan *WsMessage
+	closeOnce sync.Once
+	done      chan bool
+
+	lock    sync.RWMutex
+	authed  bool
+	address common.Address
+	leafs   map[common.Uint256]bool
+	owner   bool
+	roots   bool
+}
+
+type wsHub struct {
+	lock     sync.RWMutex
+	sessions map[*wsSession]bool
+}
+
+var (
+	defWsHub     = &wsHub{sessions: make(map[*wsSession]bool)}
+	wsHubRunOnce sync.Once
+)
+
+func (self *wsHub) add(sess *wsSession) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.sessions[sess] = true
+}
+
+func (self *wsHub) remove(sess *wsSession) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.sessions, sess)
+}
+
+func (self *wsHub) run() {
+	_, events := DefAnchorBroker.Subscribe(wsHubBufSize)
+	for ev := range events {
+		self.dispatch(ev)
+	}
+}
+
+func (self *wsHub) dispatch(ev *AnchorEvent) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	// submitters only loaded if some session subscribe owner.
+	var submitters []common.Address
+	for sess := range self.sessions {
+		if submitters == nil && sess.subscribeOwner() {
+			submitters = make([]common.Address, len(ev.Leafs))
+			for i, leaf := range ev.Leafs {
+				submitters[i], _ = getLeafSubmitter(DefStore, leaf)
+			}
+		}
+
+		for _, msg := range sess.match(ev, submitters) {
+			sess.send(msg)
+		}
+	}
+}
+
+func newWsSession(ctx context.Context, conn *websocket.Conn) (*wsSession, error) {
+	challenge := make([]byte, wsChallengeSize)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsSession{
+		ctx:       ctx,
+		conn:      conn,
+		challenge: challenge,
+		out:       make(chan *WsMessage, wsSendBufSize),
+		done:      make(chan bool),
+		leafs:     make(map[common.Uint256]bool),
+	}, nil
+}
+
+func (self *wsSession) close() {
+	self.closeOnce.Do(func() {
+		close(self.done)
+		self.conn.Close()
+	})
+}
+
+// send never block the hub. slow client closed.
+func (self *wsSession) send(msg *WsMessage) {
+	select {
+	case self.out <- msg:
+	case <-self.done:
+	default:
+		log.Warnf("WsHandle: client %s too slow. close", self.conn.RemoteAddr())
+		self.close()
+	}
+}
+
+func (self *wsSession) subscribeOwner() bool {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.owner
+}
+
+func (self *wsSession) match(ev *AnchorEvent, submitters []common.Address) []*WsMessage {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	msgs := make([]*WsMessage, 0)
+	if !self.authed {
+		return msgs
+	}
+
+	if self.roots && ev.Type == ANCHOR_EVENT_ANCHORED {
+		msgs = append(msgs, &WsMessage{
+			Type:        WS_MSG_ROOT,
+			Root:        hex.EncodeToString(ev.Root[:]),
+			TreeSize:    ev.TreeSize,
+			BlockHeight: ev.BlockHeight,
+			TxHash:      ev.TxHash.ToHexString(),
+			LeafCount:   uint32(len(ev.Leafs)),
+		})
+	}
+
+	for i, leaf := range ev.Leafs {
+		if !self.leafs[leaf] && !(self.owner && submitters != nil && submitters[i] == self.address) {
+			continue
+		}
+
+		msg := &WsMessage{
+			Leaf:        hex.EncodeToString(leaf[:]),
+			BlockHeight: ev.BlockHeight,
+			TxHash:      ev.TxHash.ToHexString(),
+		}
+		if ev.Type == ANCHOR_EVENT_ANCHORED {
+			index := ev.StartIndex + uint32(i)
+			msg.Type = WS_MSG_ANCHORED
+			msg.Index = &index
+			msg.Root = hex.EncodeToString(ev.Root[:])
+			msg.TreeSize = ev.TreeSize
+		} else {
+			msg.Type = WS_MSG_FAILED
+			msg.NewTxHash = ev.NewTxHash.ToHexString()
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+func (self *wsSession) result(req *WsRequest, errcode int64, desc string, result interface{}) *WsMessage {
+	if len(desc) == 0 {
+		desc = ErrMap[errcode]
+	}
+
+	return &WsMessage{
+		Type:   WS_MSG_RESULT,
+		Id:     req.Id,
+		Error:  errcode,
+		Desc:   desc,
+		Result: result,
+	}
+}
+
+func (self *wsSession) auth(req *WsRequest) *WsMessage {
+	pubkey, sigData, err := getPublicSigData(req.PubKey, req.Signature)
+	if err != nil {
+		return self.result(req, INVALID_PARAM, err.Error(), nil)
+	}
+
+	address := types.AddressFromPubKey(pubkey)
+	err = checkTlsBinding(self.ctx, address)
+	if err != nil {
+		return self.result(req, NO_AUTH, err.Error(), nil)
+	}
+	if !checkRoleOfAddress(address, ROLE_SUBMITTER|ROLE_VERIFIER|ROLE_AUDITOR) {
+		return self.result(req, NO_AUTH, "pubkey do not have role submitter, verifier or auditor.", nil)
+	}
+
+	err = signature.Verify(pubkey, self.challenge, sigData)
+	if err != nil {
+		return self.result(req, NO_AUTH, "Verify failed. sigData not right.", nil)
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.authed = true
+	self.address = address
+	return self.result(req, SUCCESS, "", address.ToBase58())
+}
+
+func (self *wsSession) subscribe(req *WsRequest, add bool) *WsMessage {
+	leafs, _, err := convertParamsToLeafs(req.Leafs)
+	if err != nil {
+		return self.result(req, INVALID_PARAM, err.Error(), nil)
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if !self.authed {
+		return self.result(req, NO_AUTH, "auth first.", nil)
+	}
+
+	if add && len(self.leafs)+len(leafs) > wsMaxLeafs {
+		return self.result(req, INVALID_PARAM, "too much leafs subscribed.", nil)
+	}
+
+	for _, leaf := range leafs {
+		if add {
+			self.leafs[leaf] = true
+		} else {
+			delete(self.leafs, leaf)
+		}
+	}
+
+	// only set flags by subscribe. unsubscribe clear the flags in request.
+	if add {
+		self.owner = self.owner || req.Owner
+		self.roots = self.roots || req.Roots
+	} else {
+		self.owner = self.owner && !req.Owner
+		self.roots = self.roots && !req.Roots
+	}
+
+	return self.result(req, SUCCESS, "", len(self.leafs))
+}
+
+func (self *wsSession) readLoop() {
+	defer self.close()
+
+	self.conn.SetReadLimit(wsMaxMessageBytes)
+	self.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	self.conn.SetPongHandler(func(string) error {
+		return self.conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+
+	for {
+		req := &WsRequest{}
+		err := self.conn.ReadJSON(req)
+		if err != nil {
+			log.Debugf("WsHandle: read %s: %s", self.conn.RemoteAddr(), err)
+			return
+		}
+
+		var res *WsMessage
+		switch req.Action {
+		case "auth":
+			res = self.auth(req)
+		case "subscribe":
+			res = self.subscribe(req, true)
+		case "unsubscribe":
+			res = self.subscribe(req, false)
+		default:
+			res = self.result(req, METHOD_NOT_FOUND, "action should be auth, subscribe or unsubscribe", nil)
+		}
+		self.send(res)
+	}
+}
+
+func (self *wsSession) writeLoop() {
+	defer self.close()
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-self.done:
+			return
+		case msg := <-self.out:
+			self.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err := self.conn.WriteJSON(msg)
+			if err != nil {
+				log.Debugf("WsHandle: write %s: %s", self.conn.RemoteAddr(), err)
+				return
+			}
+		case <-ticker.C:
+			self.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			err := self.conn.WriteMessage(websocket.PingMessage, nil)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// WsHandle push anchored and failed notifications of subscribed leafs, own leafs or all roots.
+func WsHandle(w http.ResponseWriter, r *http.Request) {
+	wsHubRunOnce.Do(func() {
+		go defWsHub.run()
+	})
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Infof("WsHandle: upgrade %s", err)
+		return
+	}
+
+	sess, err := newWsSession(r.Context(), conn)
+	if err != nil {
+		log.Errorf("WsHandle: %s", err)
+		conn.Close()
+		return
+	}
+
+	defWsHub.add(sess)
+	defer defWsHub.remove(sess)
+
+	sess.send(&WsMessage{
+		Type:      WS_MSG_CHALLENGE,
+		Challenge: hex.EncodeToString(sess.challenge),
+	})
+
+	go sess.readLoop()
+	sess.writeLoop()
+}
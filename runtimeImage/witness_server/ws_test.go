@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+)
+
+func TestWsSessionMatch(t *testing.T) {
+	acc, pubKey, restore := newTestAccount(ROLE_SUBMITTER)
+	defer restore()
+
+	sess := &wsSession{ctx: context.Background(), challenge: []byte("challenge"), leafs: make(map[common.Uint256]bool)}
+	subscribed, own, other := sha256.Sum256([]byte("subscribed")), sha256.Sum256([]byte("own")), sha256.Sum256([]byte("other"))
+	req := &WsRequest{Id: "1", Leafs: []string{hex.EncodeToString(subscribed[:])}, Owner: true}
+
+	if msg := sess.subscribe(req, true); msg.Error != NO_AUTH {
+		t.Errorf("subscribe before auth %+v", msg)
+	}
+	if msg := sess.auth(&WsRequest{PubKey: pubKey, Signature: hex.EncodeToString([]byte("not signed"))}); msg.Error == SUCCESS {
+		t.Errorf("auth without signed challenge %+v", msg)
+	}
+	sig, err := acc.Sign(sess.challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := sess.auth(&WsRequest{PubKey: pubKey, Signature: hex.EncodeToString(sig)}); msg.Error != SUCCESS || msg.Result != acc.Address.ToBase58() {
+		t.Fatalf("auth %+v", msg)
+	}
+	if msg := sess.subscribe(req, true); msg.Error != SUCCESS || msg.Result != 1 {
+		t.Fatalf("subscribe %+v", msg)
+	}
+
+	ev := &AnchorEvent{
+		Type:       ANCHOR_EVENT_ANCHORED,
+		Leafs:      []common.Uint256{other, subscribed, own},
+		StartIndex: 10,
+		Root:       common.Uint256{1},
+		TreeSize:   13,
+	}
+	submitters := []common.Address{common.AddressFromVmCode([]byte("other")), common.AddressFromVmCode([]byte("other")), acc.Address}
+	msgs := sess.match(ev, submitters)
+	if len(msgs) != 2 {
+		t.Fatalf("%d messages, expect subscribed and own leaf", len(msgs))
+	}
+	if msgs[0].Type != WS_MSG_ANCHORED || msgs[0].Leaf != hex.EncodeToString(subscribed[:]) || *msgs[0].Index != 11 || msgs[0].TreeSize != 13 {
+		t.Errorf("anchored of subscribed leaf %+v", msgs[0])
+	}
+	if msgs[1].Leaf != hex.EncodeToString(own[:]) || *msgs[1].Index != 12 {
+		t.Errorf("anchored of own leaf %+v", msgs[1])
+	}
+
+	// roots added. owner cleared by unsubscribe.
+	sess.subscribe(&WsRequest{Roots: true}, true)
+	sess.subscribe(&WsRequest{Owner: true}, false)
+	msgs = sess.match(ev, submitters)
+	if len(msgs) != 2 || msgs[0].Type != WS_MSG_ROOT || msgs[0].LeafCount != 3 || msgs[1].Leaf != hex.EncodeToString(subscribed[:]) {
+		t.Errorf("root and subscribed leaf %+v", msgs)
+	}
+
+	msgs = sess.match(&AnchorEvent{Type: ANCHOR_EVENT_FAILED, Leafs: []common.Uint256{subscribed}, NewTxHash: common.Uint256{2}}, nil)
+	if len(msgs) != 1 || msgs[0].Type != WS_MSG_FAILED || msgs[0].NewTxHash != (common.Uint256{2}).ToHexString() {
+		t.Errorf("failed of subscribed leaf %+v", msgs)
+	}
+}
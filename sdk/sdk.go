@@ -4,7 +4,11 @@ import "fmt"
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -13,6 +17,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -30,49 +35,63 @@ import (
 //JsonRpc version
 const JSON_RPC_VERSION = "2.0"
 
+//ClientConfig CaFile, CertFile/KeyFile and PinSha256 are optional tls options of the witness server.
 type ClientConfig struct {
-	Url     string `json:"url"`
-	AddOnId string `json:"addon_id"`
-	TenatId string `json:"tenant_id"`
-	Wallet  string `json:"wallet"`
-	Singer  string `json:"signer"`
+	Url       string   `json:"url"`
+	AddOnId   string   `json:"addon_id"`
+	TenatId   string   `json:"tenant_id"`
+	Wallet    string   `json:"wallet"`
+	Singer    string   `json:"signer"`
+	CaFile    string   `json:"ca_file,omitempty"`
+	CertFile  string   `json:"cert_file,omitempty"`
+	KeyFile   string   `json:"key_file,omitempty"`
+	PinSha256 []string `json:"pin_sha256,omitempty"`
 }
 
 //JsonRpcRequest object in rpc
 type JsonRpcRequest struct {
-	Version string    `json:"jsonrpc"`
-	Id      string    `json:"id"`
-	Method  string    `json:"method"`
-	Params  *RpcParam `json:"params"`
+	Version string      `json:"jsonrpc"`
+	Id      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
 }
 
-//JsonRpcResponse object response for JsonRpcRequest
-type JsonRpcBatchAddResponse struct {
-	Id     string      `json:"id"`
-	Error  int64       `json:"error"`
-	Desc   string      `json:"desc"`
-	Result interface{} `json:"result"`
+//JsonRpcError object in JsonRpcResponse
+type JsonRpcError struct {
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
 }
 
-type JsonRpcVerifyResponse struct {
-	Id     string       `json:"id"`
-	Error  int64        `json:"error"`
-	Desc   string       `json:"desc"`
-	Result VerifyResult `json:"result"`
+//JsonRpcResponse object response for JsonRpcRequest. Error is a JsonRpcError object for JSON-RPC 2.0 server, or int64 code with Desc for legacy server.
+type JsonRpcResponse struct {
+	Id     json.RawMessage `json:"id"`
+	Error  json.RawMessage `json:"error"`
+	Desc   string          `json:"desc"`
+	Result json.RawMessage `json:"result"`
 }
 
-type JsonGetRootResponse struct {
-	Id     string   `json:"id"`
-	Error  int64    `json:"error"`
-	Desc   string   `json:"desc"`
-	Result RootSize `json:"result"`
-}
+func (self *JsonRpcResponse) GetError() error {
+	if len(self.Error) == 0 || string(self.Error) == "null" {
+		return nil
+	}
+
+	var code int64
+	err := json.Unmarshal(self.Error, &code)
+	if err == nil {
+		if code == 0 {
+			return nil
+		}
+		return fmt.Errorf("JsonRpcResponse error code:%d desc:%s result:%s", code, self.Desc, self.Result)
+	}
 
-type JsonGetContractResponse struct {
-	Id     string `json:"id"`
-	Error  int64  `json:"error"`
-	Desc   string `json:"desc"`
-	Result string `json:"result"`
+	rpcErr := &JsonRpcError{}
+	err = json.Unmarshal(self.Error, rpcErr)
+	if err != nil {
+		return fmt.Errorf("json.Unmarshal JsonRpcError:%s error:%s", self.Error, err)
+	}
+
+	return fmt.Errorf("JsonRpcResponse error code:%d desc:%s data:%s", rpcErr.Code, rpcErr.Message, rpcErr.Data)
 }
 
 type RootSize struct {
@@ -103,6 +122,84 @@ func NewRpcClient(addr string) *RpcClient {
 	}
 }
 
+//NewRpcClientWithConfig return RpcClient instance with tls options of clientConfig
+func NewRpcClientWithConfig(addr string, clientConfig *ClientConfig) (*RpcClient, error) {
+	tlsConfig, err := tlsClientConfig(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewRpcClient(addr)
+	client.httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	return client, nil
+}
+
+// tlsClientConfig trust only CaFile if set. client certificate for mutual tls. PinSha256 is sha256 hex of the public key of any certificate in server chain. pins without CaFile skip the chain verify.
+func tlsClientConfig(clientConfig *ClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(clientConfig.CaFile) != 0 {
+		pem, err := ioutil.ReadFile(clientConfig.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate in ca file %s", clientConfig.CaFile)
+		}
+	}
+
+	if len(clientConfig.CertFile) != 0 || len(clientConfig.KeyFile) != 0 {
+		cert, err := tls.LoadX509KeyPair(clientConfig.CertFile, clientConfig.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(clientConfig.PinSha256) != 0 {
+		pins := make(map[string]bool)
+		for _, pin := range clientConfig.PinSha256 {
+			pins[strings.ToLower(pin)] = true
+		}
+		pinned := func(cert *x509.Certificate) bool {
+			fp := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			return pins[hex.EncodeToString(fp[:])]
+		}
+		if tlsConfig.RootCAs == nil {
+			// pin only. chain not verified, so the leaf sent by server must match.
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return errors.New("no server certificate")
+				}
+				cert, err := x509.ParseCertificate(rawCerts[0])
+				if err != nil {
+					return err
+				}
+				if !pinned(cert) {
+					return errors.New("server certificate not match pin")
+				}
+				return nil
+			}
+		} else {
+			// pin any certificate of the chains verified by ca file.
+			tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+				for _, chain := range verifiedChains {
+					for _, cert := range chain {
+						if pinned(cert) {
+							return nil
+						}
+					}
+				}
+				return errors.New("server certificate not match pin")
+			}
+		}
+	}
+
+	return tlsConfig, nil
+}
+
 //SetAddress set rpc server address. Simple http://localhost:20336
 func (this *RpcClient) SetAddress(addr string) *RpcClient {
 	this.addr = addr
@@ -114,7 +211,7 @@ func (this *RpcClient) GetNextQid() string {
 }
 
 //sendRpcRequest send Rpc request to ontology
-func (this *RpcClient) sendRpcRequest(clientConfig *ClientConfig, qid, method string, params *RpcParam) (interface{}, error) {
+func (this *RpcClient) sendRpcRequest(clientConfig *ClientConfig, qid, method string, params interface{}) (interface{}, error) {
 	rpcReq := &JsonRpcRequest{
 		Version: JSON_RPC_VERSION,
 		Id:      qid,
@@ -126,75 +223,154 @@ func (this *RpcClient) sendRpcRequest(clientConfig *ClientConfig, qid, method st
 		return nil, fmt.Errorf("JsonRpcRequest json.Marsha error:%s", err)
 	}
 
-	req, err := http.NewRequest("POST", this.addr, bytes.NewReader(data))
+	body, err := this.postRpcData(clientConfig, data)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("addonID", clientConfig.AddOnId)
-	req.Header.Set("tenantID", clientConfig.TenatId)
-	log.Infof("%s, %s", clientConfig.AddOnId, clientConfig.TenatId)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := this.httpClient.Do(req)
-
-	//resp, err := this.httpClient.Post(this.addr, "application/json", bytes.NewReader(data))
+	rpcRsp := &JsonRpcResponse{}
+	err = json.Unmarshal(body, rpcRsp)
 	if err != nil {
-		return nil, fmt.Errorf("http post request:%s error:%s", data, err)
+		return nil, fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err)
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	err = rpcRsp.GetError()
 	if err != nil {
-		return nil, fmt.Errorf("read rpc response body error:%s", err)
+		return nil, err
 	}
 
-	if method == "batchAdd" {
-		rpcRsp := &JsonRpcBatchAddResponse{}
-		err = json.Unmarshal(body, rpcRsp)
-		if err != nil {
-			return nil, fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err)
+	if method == "batchAdd" || method == "addData" {
+		// receipt object. string of legacy response.
+		if bytes.HasPrefix(bytes.TrimSpace(rpcRsp.Result), []byte("{")) {
+			receipt := &Receipt{}
+			err = json.Unmarshal(rpcRsp.Result, receipt)
+			if err != nil {
+				return nil, fmt.Errorf("json.Unmarshal batchAdd receipt:%s error:%s", rpcRsp.Result, err)
+			}
+			return receipt, nil
 		}
-		if rpcRsp.Error != 0 {
-			return nil, fmt.Errorf("JsonRpcResponse error code:%d desc:%s result:%v", rpcRsp.Error, rpcRsp.Desc, rpcRsp.Result)
+
+		var result interface{}
+		err = json.Unmarshal(rpcRsp.Result, &result)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal batchAdd result:%s error:%s", rpcRsp.Result, err)
 		}
 
-		return rpcRsp.Result, nil
+		return result, nil
 	} else if method == "verify" {
-		rpcRsp := &JsonRpcVerifyResponse{}
-		err = json.Unmarshal(body, rpcRsp)
-		if rpcRsp.Error != 0 {
-			return nil, fmt.Errorf("JsonRpcResponse error code:%d desc:%s", rpcRsp.Error, rpcRsp.Desc)
+		result := &VerifyResult{}
+		err = json.Unmarshal(rpcRsp.Result, result)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal verify result:%s error:%s", rpcRsp.Result, err)
 		}
-		return &rpcRsp.Result, nil
+
+		return result, nil
 	} else if method == "getRoot" {
-		rpcRsp := &JsonGetRootResponse{}
-		err = json.Unmarshal(body, rpcRsp)
-		if rpcRsp.Error != 0 {
-			return nil, fmt.Errorf("JsonRpcResponse error code:%d desc:%s", rpcRsp.Error, rpcRsp.Desc)
+		result := &RootSize{}
+		err = json.Unmarshal(rpcRsp.Result, result)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal getRoot result:%s error:%s", rpcRsp.Result, err)
 		}
-		log.Infof("Root: %s, size: %d", rpcRsp.Result.Root, rpcRsp.Result.Size)
+		log.Infof("Root: %s, size: %d", result.Root, result.Size)
 
-		return &rpcRsp.Result, nil
+		return result, nil
 	} else if method == "GetContractAddress" {
-		rpcRsp := &JsonGetContractResponse{}
-		err = json.Unmarshal(body, rpcRsp)
-		if rpcRsp.Error != 0 {
-			return nil, fmt.Errorf("JsonRpcResponse error code:%d desc:%s", rpcRsp.Error, rpcRsp.Desc)
+		var result string
+		err = json.Unmarshal(rpcRsp.Result, &result)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal GetContractAddress result:%s error:%s", rpcRsp.Result, err)
 		}
-		log.Infof("Contract: %s", rpcRsp.Result)
+		log.Infof("Contract: %s", result)
 
-		return &rpcRsp.Result, nil
+		return &result, nil
+	} else if method == "getConsistencyProof" {
+		result := &ConsistencyProofResult{}
+		err = json.Unmarshal(rpcRsp.Result, result)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal getConsistencyProof result:%s error:%s", rpcRsp.Result, err)
+		}
 
+		return result, nil
 	}
 
 	return nil, errors.New("error method")
 }
 
+//sendBatchRpcRequest send many Rpc request in one call. responses in the order of methods.
+func (this *RpcClient) sendBatchRpcRequest(clientConfig *ClientConfig, methods []string, params []interface{}) ([]*JsonRpcResponse, error) {
+	if len(methods) != len(params) {
+		return nil, errors.New("sendBatchRpcRequest methods and params len not match")
+	}
+
+	rpcReqs := make([]*JsonRpcRequest, 0, len(methods))
+	for i := range methods {
+		rpcReqs = append(rpcReqs, &JsonRpcRequest{
+			Version: JSON_RPC_VERSION,
+			Id:      this.GetNextQid(),
+			Method:  methods[i],
+			Params:  params[i],
+		})
+	}
+
+	data, err := json.Marshal(rpcReqs)
+	if err != nil {
+		return nil, fmt.Errorf("JsonRpcRequest json.Marsha error:%s", err)
+	}
+
+	body, err := this.postRpcData(clientConfig, data)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcRsps := make([]*JsonRpcResponse, 0, len(methods))
+	err = json.Unmarshal(body, &rpcRsps)
+	if err != nil {
+		// whole batch rejected.
+		rpcRsp := &JsonRpcResponse{}
+		if json.Unmarshal(body, rpcRsp) == nil && rpcRsp.GetError() != nil {
+			return nil, rpcRsp.GetError()
+		}
+		return nil, fmt.Errorf("json.Unmarshal batch JsonRpcResponse:%s error:%s", body, err)
+	}
+
+	if len(rpcRsps) != len(methods) {
+		return nil, fmt.Errorf("batch JsonRpcResponse len %d, require %d", len(rpcRsps), len(methods))
+	}
+
+	return rpcRsps, nil
+}
+
+func (this *RpcClient) postRpcData(clientConfig *ClientConfig, data []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", this.addr, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("addonID", clientConfig.AddOnId)
+	req.Header.Set("tenantID", clientConfig.TenatId)
+	log.Infof("%s, %s", clientConfig.AddOnId, clientConfig.TenatId)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := this.httpClient.Do(req)
+
+	//resp, err := this.httpClient.Post(this.addr, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("http post request:%s error:%s", data, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read rpc response body error:%s", err)
+	}
+
+	return body, nil
+}
+
 func verifyLeaf(clientConfig *ClientConfig, client *RpcClient, leafs []common.Uint256) error {
 	for i := uint32(0); i < uint32(len(leafs)); i++ {
 		vargs := getVerifyArgs(leafs[i])
-		res, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "verify", &vargs)
+		res, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "verify", vargs)
 		if err != nil {
 			return fmt.Errorf("verifyLeaf [%x] Failed: %s\n", leafs[i], err)
 		}
@@ -209,12 +385,62 @@ func verifyLeaf(clientConfig *ClientConfig, client *RpcClient, leafs []common.Ui
 	return nil
 }
 
+// verifyConsistency check the tree of old is append only by current chain root. return the current chain root.
+func verifyConsistency(clientConfig *ClientConfig, client *RpcClient, old *RootSize) (*RootSize, error) {
+	res, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "getRoot", getServerQueryArgs("getRoot"))
+	if err != nil {
+		return nil, err
+	}
+	current := res.(*RootSize)
+
+	if old.Size == current.Size {
+		if old.Root != current.Root {
+			return nil, fmt.Errorf("verifyConsistency root of size %d changed from %s to %s", old.Size, old.Root, current.Root)
+		}
+		return current, nil
+	}
+
+	cargs := &ConsistencyParam{
+		PubKey: hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
+		First:  old.Size,
+		Second: current.Size,
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(cargs.First)
+	sink.WriteUint32(cargs.Second)
+	cargs.Signature, cargs.Timestamp, cargs.Nonce = signRequest("getConsistencyProof", sink.Bytes())
+
+	res, err = client.sendRpcRequest(clientConfig, client.GetNextQid(), "getConsistencyProof", cargs)
+	if err != nil {
+		return nil, err
+	}
+
+	oldRoot, err := HashFromHexString(old.Root)
+	if err != nil {
+		return nil, err
+	}
+	currentRoot, err := HashFromHexString(current.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyConsistencyProof(res.(*ConsistencyProofResult), old.Size, current.Size, oldRoot, currentRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
 func sendtx(clientConfig *ClientConfig) {
 	testUrl := "http://127.0.0.1:32339"
 	//testUrl := "http://127.0.0.1:32338"
 	//testUrl := "http://127.0.0.1:8080"
 	//testUrl := "https://attestation.ont.io"
-	client := NewRpcClient(testUrl)
+	client, err := NewRpcClientWithConfig(testUrl, clientConfig)
+	if err != nil {
+		panic(err)
+	}
 	SystemOut = false
 	wg.Add(1)
 	defer wg.Done()
@@ -227,26 +453,44 @@ func sendtx(clientConfig *ClientConfig) {
 
 		var leafs []common.Uint256
 		leafs = GenerateLeafv(uint32(0)+N*m, N)
-		addArgs := leafvToAddArgs(leafs)
-		_, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "getRoot", nil)
+		_, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "getRoot", getServerQueryArgs("getRoot"))
 		if err != nil {
 			panic(err)
 		}
-		_, err = client.sendRpcRequest(clientConfig, client.GetNextQid(), "GetContractAddress", nil)
+		res, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "GetContractAddress", getServerQueryArgs("GetContractAddress"))
 		if err != nil {
 			panic(err)
 		}
+		contract, err := common.AddressFromHexString(*res.(*string))
+		if err != nil {
+			panic(err)
+		}
+		idempotencyKey := newIdempotencyKey()
+		addArgs := leafvToAddArgs(leafs, nil, contract, clientConfig.TenatId, idempotencyKey)
 
 		if verify {
 			verifyLeaf(clientConfig, client, leafs)
 		} else {
-			_, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "batchAdd", &addArgs)
+			res, err := client.sendRpcRequest(clientConfig, client.GetNextQid(), "batchAdd", &addArgs)
+			if err != nil {
+				// retry once with new nonce. server answer the recorded response if the first one handled.
+				log.Warnf("Add Error: %s, retry with idempotency key %s", err, idempotencyKey)
+				addArgs = leafvToAddArgs(leafs, nil, contract, clientConfig.TenatId, idempotencyKey)
+				res, err = client.sendRpcRequest(clientConfig, client.GetNextQid(), "batchAdd", &addArgs)
+			}
 			if err != nil {
 				if k == 0 {
 					log.Errorf("Add Error: %s, added num: %d\n", err, 0)
 				} else {
 					log.Errorf("Add Error: %s, added num: %d\n", err, k*m)
 				}
+			} else if receipt, ok := res.(*Receipt); ok {
+				err = VerifyReceipt(receipt, leafs)
+				if err != nil {
+					log.Errorf("receipt %s: %s", receipt.Id, err)
+				} else {
+					log.Infof("receipt %s treeSize %d", receipt.Id, receipt.TreeSize)
+				}
 			}
 			k++
 		}
@@ -348,30 +592,111 @@ func GenerateLeafv(start uint32, N uint32) []common.Uint256 {
 	return leafs
 }
 
+const (
+	BATCHADD_SIG_V1     uint32 = 1
+	BATCHADD_SIG_DOMAIN        = "ontology-witness"
+)
+
 type RpcParam struct {
-	PubKey   string   `json:"pubKey"`
-	Sigature string   `json:"signature"`
-	Hashes   []string `json:"hashes"`
+	PubKey         string   `json:"pubKey"`
+	Sigature       string   `json:"signature"`
+	Hashes         []string `json:"hashes"`
+	Version        uint32   `json:"version"`
+	Timestamp      int64    `json:"timestamp"`
+	Nonce          string   `json:"nonce"`
+	IdempotencyKey string   `json:"idempotencyKey,omitempty"`
+	// none or one of each hash.
+	Metadata []*LeafMetadataParam `json:"metadata,omitempty"`
 }
 
-func leafvToAddArgs(leafs []common.Uint256) RpcParam {
+// LeafMetadataParam metadata of one hash. recorded by server with the pubKey and submit time.
+type LeafMetadataParam struct {
+	ExternalId  string   `json:"externalId,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// leafMetadataDigest sha256 of the metadata serialized same as server.
+func leafMetadataDigest(metadata []*LeafMetadataParam) [sha256.Size]byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(metadata)))
+	for _, m := range metadata {
+		sink.WriteString(m.ExternalId)
+		sink.WriteString(m.ContentType)
+		sink.WriteVarUint(uint64(len(m.Labels)))
+		for _, label := range m.Labels {
+			sink.WriteString(label)
+		}
+	}
+	return sha256.Sum256(sink.Bytes())
+}
+
+// batchAddEnvelope same as server. domain, version, method, contract, tenant, timestamp, nonce, sha256 of leafs. idempotency key and digest of metadata if set.
+func batchAddEnvelope(leafData []byte, contract common.Address, tenant string, timestamp int64, nonce string, idempotencyKey string, metadata []*LeafMetadataParam) []byte {
+	digest := sha256.Sum256(leafData)
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(BATCHADD_SIG_DOMAIN)
+	sink.WriteUint32(BATCHADD_SIG_V1)
+	sink.WriteString("batchAdd")
+	sink.WriteAddress(contract)
+	sink.WriteString(tenant)
+	sink.WriteUint64(uint64(timestamp))
+	sink.WriteString(nonce)
+	sink.WriteBytes(digest[:])
+	if len(idempotencyKey) != 0 || len(metadata) != 0 {
+		sink.WriteString(idempotencyKey)
+	}
+	if len(metadata) != 0 {
+		metadataDigest := leafMetadataDigest(metadata)
+		sink.WriteBytes(metadataDigest[:])
+	}
+	return sink.Bytes()
+}
+
+func newIdempotencyKey() string {
+	keyData := make([]byte, 16)
+	_, err := rand.Read(keyData)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(keyData)
+}
+
+// leafvToAddArgs sign the version 1 envelope bound to the contract of server and tenant. retry sign again with the same idempotency key.
+// metadata nil or one of each leaf.
+func leafvToAddArgs(leafs []common.Uint256, metadata []*LeafMetadataParam, contract common.Address, tenant string, idempotencyKey string) RpcParam {
 	leafargs := make([]string, 0, len(leafs))
-	verifyData := make([]byte, 0)
+	leafData := make([]byte, 0)
 
 	for i := range leafs {
 		leafargs = append(leafargs, hex.EncodeToString(leafs[i][:]))
-		verifyData = append(verifyData, leafs[i][:]...)
+		leafData = append(leafData, leafs[i][:]...)
+	}
+
+	nonceData := make([]byte, 16)
+	_, err := rand.Read(nonceData)
+	if err != nil {
+		panic(err)
 	}
+	nonce := hex.EncodeToString(nonceData)
+	timestamp := time.Now().Unix()
 
+	verifyData := batchAddEnvelope(leafData, contract, tenant, timestamp, nonce, idempotencyKey, metadata)
 	sigData, err := DefSigner.Sign(verifyData)
 	if err != nil {
 		panic(err)
 	}
 
 	addargs := RpcParam{
-		PubKey:   hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
-		Sigature: hex.EncodeToString(sigData),
-		Hashes:   leafargs,
+		PubKey:    hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
+		Sigature:  hex.EncodeToString(sigData),
+		Hashes:    leafargs,
+		Version:   BATCHADD_SIG_V1,
+		Timestamp: timestamp,
+		Nonce:     nonce,
+
+		IdempotencyKey: idempotencyKey,
+		Metadata:       metadata,
 	}
 
 	err = signature.Verify(DefSigner.GetPublicKey(), verifyData, sigData)
@@ -388,6 +713,17 @@ type VerifyResult struct {
 	BlockHeight uint32           `json:"blockheight"`
 	Index       uint32           `json:"index"`
 	Proof       []common.Uint256 `json:"proof"`
+	// recorded by server. nil if leaf added before metadata recorded.
+	Metadata *LeafMetadata `json:"metadata,omitempty"`
+}
+
+type LeafMetadata struct {
+	ExternalId  string   `json:"externalId,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	PubKey      string   `json:"pubKey"`
+	Submitter   string   `json:"submitter"`
+	SubmitTime  uint64   `json:"submitTime"`
 }
 
 func (self VerifyResult) MarshalJSON() ([]byte, error) {
@@ -398,17 +734,19 @@ func (self VerifyResult) MarshalJSON() ([]byte, error) {
 	}
 
 	res := struct {
-		Root        string   `json:"root"`
-		TreeSize    uint32   `json:"size"`
-		BlockHeight uint32   `json:"blockheight"`
-		Index       uint32   `json:"index"`
-		Proof       []string `json:"proof"`
+		Root        string        `json:"root"`
+		TreeSize    uint32        `json:"size"`
+		BlockHeight uint32        `json:"blockheight"`
+		Index       uint32        `json:"index"`
+		Proof       []string      `json:"proof"`
+		Metadata    *LeafMetadata `json:"metadata,omitempty"`
 	}{
 		Root:        root,
 		TreeSize:    self.TreeSize,
 		BlockHeight: self.BlockHeight,
 		Index:       self.Index,
 		Proof:       proof,
+		Metadata:    self.Metadata,
 	}
 
 	return json.Marshal(res)
@@ -416,11 +754,12 @@ func (self VerifyResult) MarshalJSON() ([]byte, error) {
 
 func (self *VerifyResult) UnmarshalJSON(buf []byte) error {
 	res := struct {
-		Root        string   `json:"root"`
-		TreeSize    uint32   `json:"size"`
-		BlockHeight uint32   `json:"blockheight"`
-		Index       uint32   `json:"index"`
-		Proof       []string `json:"proof"`
+		Root        string        `json:"root"`
+		TreeSize    uint32        `json:"size"`
+		BlockHeight uint32        `json:"blockheight"`
+		Index       uint32        `json:"index"`
+		Proof       []string      `json:"proof"`
+		Metadata    *LeafMetadata `json:"metadata,omitempty"`
 	}{}
 
 	if len(buf) == 0 {
@@ -443,10 +782,217 @@ func (self *VerifyResult) UnmarshalJSON(buf []byte) error {
 	self.BlockHeight = res.BlockHeight
 	self.Index = res.Index
 	self.Proof = proof
+	self.Metadata = res.Metadata
+
+	return nil
+}
+
+// ConsistencyParam signed request of getConsistencyProof.
+type ConsistencyParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	First     uint32 `json:"first"`
+	Second    uint32 `json:"second"`
+}
+
+type ConsistencyProofResult struct {
+	First      uint32           `json:"first"`
+	Second     uint32           `json:"second"`
+	FirstRoot  common.Uint256   `json:"firstRoot"`
+	SecondRoot common.Uint256   `json:"secondRoot"`
+	Proof      []common.Uint256 `json:"proof"`
+}
+
+func (self *ConsistencyProofResult) UnmarshalJSON(buf []byte) error {
+	res := struct {
+		First      uint32   `json:"first"`
+		Second     uint32   `json:"second"`
+		FirstRoot  string   `json:"firstRoot"`
+		SecondRoot string   `json:"secondRoot"`
+		Proof      []string `json:"proof"`
+	}{}
+
+	if len(buf) == 0 {
+		return nil
+	}
+
+	err := json.Unmarshal(buf, &res)
+	if err != nil {
+		return err
+	}
+
+	firstRoot, err := HashFromHexString(res.FirstRoot)
+	if err != nil {
+		return err
+	}
+	secondRoot, err := HashFromHexString(res.SecondRoot)
+	if err != nil {
+		return err
+	}
+	proof, err := convertParamsToLeafs(res.Proof)
+	if err != nil {
+		return err
+	}
+
+	self.First = res.First
+	self.Second = res.Second
+	self.FirstRoot = firstRoot
+	self.SecondRoot = secondRoot
+	self.Proof = proof
 
 	return nil
 }
 
+const (
+	RECEIPT_SIG_DOMAIN = "ontology-witness-receipt"
+	PROMISE_SIG_DOMAIN = "ontology-witness-sst"
+)
+
+// Receipt of batchAdd signed by the server.
+type Receipt struct {
+	Id          string `json:"receiptId"`
+	Contract    string `json:"contract"`
+	TenantId    string `json:"tenantId"`
+	Submitter   string `json:"submitter"`
+	LeafCount   uint32 `json:"leafCount"`
+	LeafsDigest string `json:"leafsDigest"`
+	Timestamp   uint64 `json:"timestamp"`
+	TreeSize    uint32 `json:"treeSize"`
+	PubKey      string `json:"pubKey"`
+	Signature   string `json:"signature"`
+	// signed timestamp of every leaf added.
+	Promises []*Promise `json:"promises,omitempty"`
+	// leafs submitted before by the same key. only if server batchAdd idempotent.
+	Duplicates []*LeafStatus `json:"duplicates,omitempty"`
+	// leaf hashes computed by server. only in addData result.
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+type LeafStatus struct {
+	Hash        string  `json:"hash"`
+	Stage       string  `json:"stage"`
+	TxHash      string  `json:"txHash,omitempty"`
+	BlockHeight uint32  `json:"blockHeight,omitempty"`
+	Index       *uint32 `json:"index,omitempty"`
+	Algorithm   string  `json:"algorithm,omitempty"`
+}
+
+// Promise the leaf accepted at Timestamp will be anchored within Mmd seconds.
+type Promise struct {
+	Hash      string `json:"hash"`
+	Timestamp uint64 `json:"timestamp"`
+	Mmd       uint32 `json:"mmd"`
+	Signature string `json:"signature"`
+}
+
+func verifyPromise(pubKey keypair.PublicKey, contract common.Address, tenantId string, leaf common.Uint256, promise *Promise) error {
+	if promise.Hash != hex.EncodeToString(leaf[:]) {
+		return fmt.Errorf("promise of %s not of leaf %x", promise.Hash, leaf)
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(PROMISE_SIG_DOMAIN)
+	sink.WriteAddress(contract)
+	sink.WriteString(tenantId)
+	sink.WriteHash(leaf)
+	sink.WriteUint64(promise.Timestamp)
+	sink.WriteUint32(promise.Mmd)
+
+	sig, err := hex.DecodeString(promise.Signature)
+	if err != nil {
+		return err
+	}
+
+	return signature.Verify(pubKey, sink.Bytes(), sig)
+}
+
+// VerifyReceipt check receipt is of leafs and signed by PubKey. and the promises if any. caller should also check PubKey is the key of server.
+func VerifyReceipt(receipt *Receipt, leafs []common.Uint256) error {
+	data := make([]byte, 0, len(leafs)*common.UINT256_SIZE)
+	for i := range leafs {
+		data = append(data, leafs[i][:]...)
+	}
+	digest := sha256.Sum256(data)
+	if receipt.LeafCount != uint32(len(leafs)) || receipt.LeafsDigest != hex.EncodeToString(digest[:]) {
+		return errors.New("receipt not of leafs")
+	}
+
+	contract, err := common.AddressFromHexString(receipt.Contract)
+	if err != nil {
+		return err
+	}
+	submitter, err := common.AddressFromBase58(receipt.Submitter)
+	if err != nil {
+		return err
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(RECEIPT_SIG_DOMAIN)
+	sink.WriteAddress(contract)
+	sink.WriteString(receipt.TenantId)
+	sink.WriteAddress(submitter)
+	sink.WriteUint32(receipt.LeafCount)
+	sink.WriteBytes(digest[:])
+	sink.WriteUint64(receipt.Timestamp)
+	sink.WriteUint32(receipt.TreeSize)
+
+	id := sha256.Sum256(sink.Bytes())
+	if receipt.Id != hex.EncodeToString(id[:]) {
+		return errors.New("receipt id not match")
+	}
+
+	rawPub, err := hex.DecodeString(receipt.PubKey)
+	if err != nil {
+		return err
+	}
+	pubKey, err := keypair.DeserializePublicKey(rawPub)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(receipt.Signature)
+	if err != nil {
+		return err
+	}
+
+	err = signature.Verify(pubKey, sink.Bytes(), sig)
+	if err != nil {
+		return err
+	}
+
+	// no promise of the duplicates.
+	leafOf := make(map[string]common.Uint256, len(leafs))
+	for i := range leafs {
+		leafOf[hex.EncodeToString(leafs[i][:])] = leafs[i]
+	}
+	for _, promise := range receipt.Promises {
+		leaf, ok := leafOf[promise.Hash]
+		if !ok {
+			return fmt.Errorf("promise of %s not of leafs", promise.Hash)
+		}
+		err = verifyPromise(pubKey, contract, receipt.TenantId, leaf, promise)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyConsistencyProof check proof against the sizes and roots hold by client. not the ones returned by server.
+func VerifyConsistencyProof(res *ConsistencyProofResult, first uint32, second uint32, firstRoot common.Uint256, secondRoot common.Uint256) error {
+	if res.First != first || res.Second != second {
+		return fmt.Errorf("VerifyConsistencyProof size not match. first %d/%d, second %d/%d", res.First, first, res.Second, second)
+	}
+	if res.FirstRoot != firstRoot || res.SecondRoot != secondRoot {
+		return fmt.Errorf("VerifyConsistencyProof root not match. first %x/%x, second %x/%x", res.FirstRoot, firstRoot, res.SecondRoot, secondRoot)
+	}
+
+	verify := merkle.NewMerkleVerifier()
+	return verify.VerifyConsistency(res.First, res.Second, firstRoot, secondRoot, res.Proof)
+}
+
 func convertParamsToLeafs(params []string) ([]common.Uint256, error) {
 	leafs := make([]common.Uint256, len(params), len(params))
 
@@ -462,18 +1008,128 @@ func convertParamsToLeafs(params []string) ([]common.Uint256, error) {
 	return leafs, nil
 }
 
-func getVerifyArgs(leaf common.Uint256) RpcParam {
+// VerifyParam signed request of verify. Root or TreeSize set to verify against the history anchored root.
+type VerifyParam struct {
+	PubKey    string   `json:"pubKey"`
+	Signature string   `json:"signature"`
+	Timestamp int64    `json:"timestamp"`
+	Nonce     string   `json:"nonce"`
+	Hashes    []string `json:"hashes"`
+	Root      string   `json:"root,omitempty"`
+	TreeSize  uint32   `json:"treeSize,omitempty"`
+}
+
+// DataParam signed request of addData and verifyData. Data are base64 payloads hashed by server.
+type DataParam struct {
+	PubKey         string   `json:"pubKey"`
+	Signature      string   `json:"signature"`
+	Timestamp      int64    `json:"timestamp"`
+	Nonce          string   `json:"nonce"`
+	Algorithm      string   `json:"algorithm,omitempty"`
+	Data           []string `json:"data"`
+	Root           string   `json:"root,omitempty"`
+	TreeSize       uint32   `json:"treeSize,omitempty"`
+	IdempotencyKey string   `json:"idempotencyKey,omitempty"`
+	// none or one of each data. addData only.
+	Metadata []*LeafMetadataParam `json:"metadata,omitempty"`
+}
+
+// ServerQueryParam signed request of getRoot and GetContractAddress.
+type ServerQueryParam struct {
+	PubKey    string `json:"pubKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+}
+
+// signRequest sign the canonical request same as server. method, params data, timestamp and nonce.
+func signRequest(method string, data []byte) (string, int64, string) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		panic(err)
+	}
+
+	timestamp := time.Now().Unix()
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(method)
+	sink.WriteVarBytes(data)
+	sink.WriteUint64(uint64(timestamp))
+	sink.WriteString(hex.EncodeToString(nonce))
+
+	sigData, err := DefSigner.Sign(sink.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(sigData), timestamp, hex.EncodeToString(nonce)
+}
+
+func signVerifyArgs(method string, vargs *VerifyParam) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(uint64(len(vargs.Hashes)))
+	for _, h := range vargs.Hashes {
+		sink.WriteString(h)
+	}
+	sink.WriteString(vargs.Root)
+	sink.WriteUint32(vargs.TreeSize)
+
+	vargs.Signature, vargs.Timestamp, vargs.Nonce = signRequest(method, sink.Bytes())
+}
+
+func getVerifyArgs(leaf common.Uint256) *VerifyParam {
 	leafs := make([]string, 1, 1)
 	leafs[0] = hex.EncodeToString(leaf[:])
 
-	vargs := RpcParam{
+	vargs := &VerifyParam{
 		PubKey: hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
 		Hashes: leafs,
 	}
+	signVerifyArgs("verify", vargs)
 
 	return vargs
 }
 
+// getDataArgs sign algorithm, count, sha256 of each payload, root, treeSize, idempotency key and digest of metadata same as server.
+func getDataArgs(method string, algorithm string, payloads [][]byte, metadata []*LeafMetadataParam, idempotencyKey string) *DataParam {
+	dargs := &DataParam{
+		PubKey:         hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
+		Algorithm:      algorithm,
+		Data:           make([]string, 0, len(payloads)),
+		IdempotencyKey: idempotencyKey,
+		Metadata:       metadata,
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(dargs.Algorithm)
+	sink.WriteVarUint(uint64(len(payloads)))
+	for _, payload := range payloads {
+		dargs.Data = append(dargs.Data, base64.StdEncoding.EncodeToString(payload))
+		digest := sha256.Sum256(payload)
+		sink.WriteBytes(digest[:])
+	}
+	sink.WriteString(dargs.Root)
+	sink.WriteUint32(dargs.TreeSize)
+	sink.WriteString(dargs.IdempotencyKey)
+	if len(metadata) != 0 {
+		metadataDigest := leafMetadataDigest(metadata)
+		sink.WriteBytes(metadataDigest[:])
+	}
+
+	dargs.Signature, dargs.Timestamp, dargs.Nonce = signRequest(method, sink.Bytes())
+
+	return dargs
+}
+
+func getServerQueryArgs(method string) *ServerQueryParam {
+	qargs := &ServerQueryParam{
+		PubKey: hex.EncodeToString(keypair.SerializePublicKey(DefSigner.GetPublicKey())),
+	}
+	qargs.Signature, qargs.Timestamp, qargs.Nonce = signRequest(method, nil)
+
+	return qargs
+}
+
 func HashFromHexString(s string) (common.Uint256, error) {
 	hx, err := common.HexToBytes(s)
 	if err != nil {